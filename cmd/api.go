@@ -31,6 +31,7 @@ import (
 	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/token"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -50,11 +51,16 @@ func APICommand() *cli.Command {
 				Name:  "config",
 				Value: "k0s.yaml",
 			},
+			&cli.StringFlag{
+				Name:  "node-id",
+				Usage: "id of the node reported by the api",
+			},
 		},
 	}
 }
 
 var kubeClient k8s.Interface
+var tokenManager *token.Manager
 
 func startAPI(ctx *cli.Context) error {
 	clusterConfig, err := configFromCmdFlag(ctx)
@@ -66,16 +72,22 @@ func startAPI(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	tokenManager, err = token.NewManager(constant.AdminKubeconfigConfigPath)
+	if err != nil {
+		return err
+	}
 	prefix := "/v1beta1"
 	router := mux.NewRouter()
 	router.Use(authMiddleware)
 
-	if clusterConfig.Spec.Storage.Type == v1beta1.EtcdStorageType {
-		// Only mount the etcd handler if we're running on etcd storage
+	if clusterConfig.Spec.Storage.Type == v1beta1.EtcdStorageType && !clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+		// Only mount the etcd handler if we're running on etcd storage managed by k0s
 		// by default the mux will return 404 back which the caller should handle
-		router.Path(prefix + "/etcd/members").Methods("POST").Handler(etcdHandler())
+		router.Path(prefix + "/etcd/members").Methods("POST").Handler(etcdHandler(clusterConfig.Spec.Storage.Etcd.ClientURL()))
 	}
 
+	router.Path(prefix + "/node").Methods("GET").Handler(nodeHandler(ctx.String("node-id")))
+
 	if clusterConfig.Spec.Storage.IsJoinable() {
 		router.Path(prefix + "/ca").Methods("GET").Handler(caHandler())
 	}
@@ -95,7 +107,7 @@ func startAPI(ctx *cli.Context) error {
 	return nil
 }
 
-func etcdHandler() http.Handler {
+func etcdHandler(etcdEndpoint string) http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		var etcdReq v1beta1.EtcdRequest
@@ -111,7 +123,7 @@ func etcdHandler() http.Handler {
 			return
 		}
 
-		etcdClient, err := etcd.NewClient()
+		etcdClient, err := etcd.NewClient(etcdEndpoint)
 		if err != nil {
 			sendError(err, resp)
 			return
@@ -152,6 +164,16 @@ func etcdHandler() http.Handler {
 	})
 }
 
+func nodeHandler(nodeID string) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(resp).Encode(v1beta1.NodeResponse{NodeID: nodeID}); err != nil {
+			sendError(err, resp)
+			return
+		}
+	})
+}
+
 func caHandler() http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 
@@ -216,8 +238,18 @@ func authMiddleware(next http.Handler) http.Handler {
 
 		parts := strings.Split(auth, "Bearer ")
 		if len(parts) == 2 {
-			token := parts[1]
-			if !isValidToken(token) {
+			bearerToken := parts[1]
+			if !isValidToken(bearerToken) {
+				// a revoked token gets the same response as an unknown one, not to tell which tokens exist, the
+				// revocation is only logged here
+				tokenID := strings.Split(bearerToken, ".")[0]
+				revoked, err := tokenManager.Revoked(tokenID)
+				if err != nil {
+					logrus.Warnf("failed to look up whether token %s is revoked: %s", tokenID, err)
+				}
+				if revoked {
+					logrus.Warnf("refused the invalidated join token %s", tokenID)
+				}
 				sendError(fmt.Errorf("Go away"), w, http.StatusUnauthorized)
 				return
 			}
@@ -230,7 +262,8 @@ func authMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-/** The token is in form of xyz.foobar where:
+/*
+* The token is in form of xyz.foobar where:
 - xyz: the token "ID" in kube api
 - foobar: the token itself
 We need to validate:
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/applier"
+	"github.com/k0sproject/k0s/pkg/component/server"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// applyPollInterval is how often apply checks whether a reconciler completed its first run
+const applyPollInterval = 200 * time.Millisecond
+
+// ApplyCommand runs the in-cluster component reconcilers and applies their manifests once
+func ApplyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "Run the k0s in-cluster component reconcilers once, apply their manifests and exit",
+		Description: "Runs on a controller with the admin kubeconfig and a reachable API server. Every reconciler writes its " +
+			"manifests once, then all the stacks in the manifests directory are applied, unless spec.applier.enabled is false. " +
+			"Running it again is safe.",
+		Action: apply,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Value:   "k0s.yaml",
+				Usage:   "config file, - to read it from stdin or a http(s) URL to fetch it from",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "time each reconciler gets to complete its first run",
+				Value: 2 * time.Minute,
+			},
+		}, configSourceFlags...),
+	}
+}
+
+func apply(ctx *cli.Context) error {
+	clusterConfig, err := configFromCmdFlag(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(constant.AdminKubeconfigConfigPath); err != nil {
+		return errors.Wrap(err, "admin kubeconfig not found, run apply on a controller")
+	}
+	if err := util.InitDirectory(constant.ManifestsDir, constant.ManifestsDirMode); err != nil {
+		return errors.Wrapf(err, "failed to create manifest bundle dir %s", constant.ManifestsDir)
+	}
+
+	reconcilers, err := createClusterReconcilers(clusterConfig)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, reconciler := range reconcilers {
+		if err := reconcileOnce(ctx.Context, reconciler, ctx.Duration("timeout")); err != nil {
+			fmt.Printf("%-22s failed: %s\n", reconciler.name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%-22s ok\n", reconciler.name)
+	}
+
+	if !clusterConfig.Spec.Applier.Enabled {
+		// like k0s server, the manifests are only written for them to be applied by other means
+		fmt.Printf("manifest applier disabled, the manifests in %s have to be applied to the cluster by other means\n", constant.ManifestsDir)
+		return reconcileFailures(failed)
+	}
+
+	stacks, err := util.GetAllDirs(constant.ManifestsDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the stacks in %s", constant.ManifestsDir)
+	}
+	for _, stack := range stacks {
+		a := applier.NewApplier(filepath.Join(constant.ManifestsDir, stack))
+		a.ServerSideApply = clusterConfig.Spec.Applier.ServerSideApply
+		a.ForceConflicts = clusterConfig.Spec.Applier.ForceConflicts
+		if err := a.Apply(); err != nil {
+			fmt.Printf("%-22s failed to apply: %s\n", "stack "+stack, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%-22s applied\n", "stack "+stack)
+	}
+	return reconcileFailures(failed)
+}
+
+// reconcileFailures returns the error apply exits with for the failed reconcilers and stacks, if any
+func reconcileFailures(failed int) error {
+	if failed > 0 {
+		return fmt.Errorf("%d reconcilers or stacks failed", failed)
+	}
+	return nil
+}
+
+// reconcileOnce runs the reconciler until it reports its first reconcile run, and returns the error of that run. The
+// reconciler is given up on if the run doesn't complete within timeout.
+func reconcileOnce(ctx context.Context, reconciler namedReconciler, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := reconciler.Init(); err != nil {
+		return errors.Wrap(err, "failed to initialize")
+	}
+	if err := reconciler.Run(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := reconciler.Stop(); err != nil {
+			logrus.Warnf("failed to stop %s reconciler: %s", reconciler.name, err)
+		}
+	}()
+
+	ticker := time.NewTicker(applyPollInterval)
+	defer ticker.Stop()
+	for {
+		if status, ok := server.ReconcilerStatusOf(reconciler.name); ok {
+			if status.LastError != "" {
+				return errors.New(status.LastError)
+			}
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("did not complete a run: %s", ctx.Err())
+		}
+	}
+}
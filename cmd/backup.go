@@ -0,0 +1,184 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/backup"
+	"github.com/k0sproject/k0s/pkg/build"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/etcd"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// BackupCommand creates the command for backing up the storage, config and certificates of a controller
+func BackupCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "backup",
+		Usage: "Back up the etcd or kine storage, the config and the certificates of this controller into a tarball",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Value:     "k0s.yaml",
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:      "save-path",
+				Usage:     "path of the backup tarball, a timestamped file in the data directory by default",
+				TakesFile: true,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			clusterConfig, err := ConfigFromYaml(ctx)
+			if err != nil {
+				return err
+			}
+			savePath := ctx.String("save-path")
+			if savePath == "" {
+				savePath = filepath.Join(constant.DataDir, fmt.Sprintf("k0s_backup_%s.tar.gz", time.Now().Format("2006-01-02T15_04_05")))
+			}
+
+			tmpDir, err := ioutil.TempDir(constant.DataDir, "backup")
+			if err != nil {
+				return errors.Wrap(err, "failed to create a temporary directory for the backup")
+			}
+			defer os.RemoveAll(tmpDir)
+
+			var storageName, storagePath string
+			switch clusterConfig.Spec.Storage.Type {
+			case config.EtcdStorageType:
+				if clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+					return fmt.Errorf("the external etcd cluster isn't managed by k0s, back it up with etcdctl snapshot save instead")
+				}
+				storageName, storagePath = backup.EtcdSnapshotName, filepath.Join(tmpDir, backup.EtcdSnapshotName)
+				err = snapshotEtcd(ctx.Context, clusterConfig.Spec.Storage.Etcd, storagePath)
+			case config.KineStorageType:
+				storageName, storagePath = backup.KineDBName, filepath.Join(tmpDir, backup.KineDBName)
+				err = copyKineDB(clusterConfig.Spec.Storage.Kine, storagePath)
+			default:
+				err = &config.InvalidStorageTypeError{Type: clusterConfig.Spec.Storage.Type}
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := writeBackup(ctx.String("config"), clusterConfig, storageName, storagePath, savePath); err != nil {
+				os.Remove(savePath)
+				return err
+			}
+			fmt.Printf("backup saved to %s\n", savePath)
+			return nil
+		},
+	}
+}
+
+// snapshotEtcd saves a snapshot of the local etcd member to path. It refuses to snapshot an unhealthy member, which
+// might not have caught up with the cluster.
+func snapshotEtcd(ctx context.Context, etcdConfig *config.EtcdConfig, path string) error {
+	if err := etcd.CheckDataDir(etcdConfig.GetDataDir()); err != nil {
+		return errors.Wrap(err, "this node does not run an etcd member")
+	}
+	if err := etcd.CheckEtcdReady(etcdConfig.ClientURL()); err != nil {
+		return errors.Wrap(err, "refusing to back up an unhealthy etcd member")
+	}
+
+	etcdClient, err := etcd.NewClient(etcdConfig.ClientURL())
+	if err != nil {
+		return fmt.Errorf("can't connect to the etcd: %v", err)
+	}
+	defer etcdClient.Close()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := etcdClient.Snapshot(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+	logrus.Info("saved the etcd snapshot")
+	return f.Close()
+}
+
+// copyKineDB copies the sqlite database of kine to path, the other kine backends are backed up with their own tools
+func copyKineDB(kineConfig *config.KineConfig, path string) error {
+	if scheme := kineConfig.DataSourceScheme(); scheme != config.KineSQLiteScheme {
+		return fmt.Errorf("only the sqlite kine backend can be backed up, back up the %s database with its own tools", scheme)
+	}
+	if err := backup.CopySQLite(kineConfig.SQLitePath(), path); err != nil {
+		return err
+	}
+	logrus.Info("copied the kine database")
+	return nil
+}
+
+// writeBackup writes the metadata, the storage backup, the config and the certificates into the tarball at savePath
+func writeBackup(configPath string, clusterConfig *config.ClusterConfig, storageName, storagePath, savePath string) error {
+	f, err := os.OpenFile(savePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the backup file")
+	}
+	defer f.Close()
+
+	metadata, err := yaml.Marshal(&backup.Metadata{
+		K0sVersion:  build.Version,
+		StorageType: clusterConfig.Spec.Storage.Type,
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	archive := backup.NewArchive(f)
+	if err := archive.AddBytes(backup.MetadataName, metadata, 0600); err != nil {
+		return err
+	}
+	if err := archive.AddFile(storageName, storagePath); err != nil {
+		return err
+	}
+	// without a config file k0s runs with the default config, which is then saved for the restore
+	if util.FileExists(configPath) {
+		err = archive.AddFile(backup.ConfigName, configPath)
+	} else {
+		var data []byte
+		data, err = yaml.Marshal(clusterConfig)
+		if err == nil {
+			err = archive.AddBytes(backup.ConfigName, data, 0600)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := archive.AddDir(backup.CertsDirName, constant.CertRootDir); err != nil {
+		return errors.Wrap(err, "failed to add the certificates to the backup")
+	}
+	if err := archive.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the backup")
+	}
+	return f.Close()
+}
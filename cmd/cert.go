@@ -0,0 +1,172 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component/server"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// CertCommand creates the command for managing the k0s certificates
+func CertCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cert",
+		Usage: "Manage the certificates of the control plane",
+		Subcommands: []*cli.Command{
+			CertListCommand(),
+			CertRenewCommand(),
+		},
+	}
+}
+
+// CertListCommand creates the command listing the certificates with their expiry
+func CertListCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List the certificates of the control plane on this node with their expiry",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "warn-within",
+				Usage: "mark the certificates expiring within this time",
+				Value: certificate.DefaultExpiryWarningWindow,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			manager := certificate.Manager{}
+			certs, err := manager.List()
+			if err != nil {
+				return err
+			}
+			if len(certs) == 0 {
+				fmt.Println("no certificates found, is this a controller node?")
+				return nil
+			}
+
+			now := time.Now()
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSUBJECT\tEXPIRES\tDAYS LEFT\t")
+			for _, cert := range certs {
+				days := fmt.Sprintf("%d", int(cert.NotAfter.Sub(now).Hours()/24))
+				if cert.NotAfter.Before(now) {
+					days = "expired"
+				} else if cert.ExpiresWithin(ctx.Duration("warn-within"), now) {
+					days += " (!)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", cert.Name, cert.Subject, cert.NotAfter.Format("2006-01-02"), days)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// CertRenewCommand creates the command renewing the certificates of the control plane from the existing CAs
+func CertRenewCommand() *cli.Command {
+	renewable := server.RenewableCertificates()
+	names := make([]string, 0, len(renewable))
+	for _, cert := range renewable {
+		names = append(names, cert.Name)
+	}
+	return &cli.Command{
+		Name:  "renew",
+		Usage: "Issue the certificates of the control plane on this node anew from the existing CAs, the previous files are backed up",
+		Description: "The components of a k0s server running on this node are restarted to load the renewed certificates. " +
+			"The CAs are not rotated: renewing fails if a CA expires within --ca-expiry-window.",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Value:   "k0s.yaml",
+				Usage:   "config file, - to read it from stdin or a http(s) URL to fetch it from",
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: fmt.Sprintf("renew only these certificates, any of %s", strings.Join(names, ", ")),
+			},
+			&cli.DurationFlag{
+				Name:  "ca-expiry-window",
+				Usage: "refuse to renew if the signing CA expires within this time",
+				Value: certificate.DefaultExpiryWarningWindow,
+			},
+		}, configSourceFlags...),
+		Action: certRenew,
+	}
+}
+
+func certRenew(ctx *cli.Context) error {
+	certs, err := selectRenewableCertificates(ctx.StringSlice("only"))
+	if err != nil {
+		return err
+	}
+	clusterConfig, err := configFromCmdFlag(ctx)
+	if err != nil {
+		return err
+	}
+
+	backupSuffix := ".bak-" + time.Now().Format("20060102150405")
+	if err := server.RenewCertificates(certificate.Manager{}, clusterConfig.Spec, certs, ctx.Duration("ca-expiry-window"), backupSuffix); err != nil {
+		return err
+	}
+
+	var restart []string
+	for _, cert := range certs {
+		fmt.Printf("Renewed %s\n", cert.Name)
+		if cert.Component != "" && !util.StringSliceContains(restart, cert.Component) {
+			restart = append(restart, cert.Component)
+		}
+	}
+	for _, name := range restart {
+		if _, err := controlSocketRequest("POST", fmt.Sprintf("/v1beta1/components/%s/restart", url.PathEscape(name))); err != nil {
+			logrus.Warnf("failed to restart %s, restart it or k0s to load the renewed certificates: %s", name, err)
+			continue
+		}
+		logrus.Infof("%s restarted", name)
+	}
+	return nil
+}
+
+// selectRenewableCertificates returns the renewable certificates with the given names, or all of them if none are given
+func selectRenewableCertificates(names []string) ([]server.RenewableCertificate, error) {
+	renewable := server.RenewableCertificates()
+	if len(names) == 0 {
+		return renewable, nil
+	}
+	var certs []server.RenewableCertificate
+	for _, name := range names {
+		found := false
+		for _, cert := range renewable {
+			if cert.Name == name {
+				certs = append(certs, cert)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown certificate %s", name)
+		}
+	}
+	return certs, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/preflight"
+)
+
+// CheckCommand creates the command for running the server preflight checks
+func CheckCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "Run the preflight checks done when the server starts, without starting it",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Value:     "k0s.yaml",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "print the results as json",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			clusterConfig, err := configFromCmdFlag(ctx)
+			if err != nil {
+				return err
+			}
+
+			results := preflight.Run(preflight.ServerChecks(clusterConfig))
+			if ctx.Bool("json") {
+				out, err := json.MarshalIndent(results, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+			} else {
+				for _, r := range results {
+					fmt.Println(r)
+				}
+			}
+			return preflight.Errors(results)
+		},
+	}
+}
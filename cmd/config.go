@@ -19,6 +19,7 @@ import (
 	"fmt"
 
 	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/configdiff"
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v2"
 )
@@ -36,3 +37,56 @@ func buildConfig(ctx *cli.Context) error {
 	fmt.Print(string(conf))
 	return nil
 }
+
+// ConfigInspectCommand creates the command for inspecting the config of the k0s server running on this node
+func ConfigInspectCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect the config of the k0s server running on this node",
+		Subcommands: []*cli.Command{
+			ConfigDiffCommand(),
+		},
+	}
+}
+
+// ConfigDiffCommand creates the command for comparing the config the server runs with to the config file
+func ConfigDiffCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Show how the config file differs from the config the k0s server runs with, secrets are redacted",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Value:     "k0s.yaml",
+				TakesFile: true,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			body, err := controlSocketRequest("GET", "/v1beta1/config")
+			if err != nil {
+				return err
+			}
+			running := &v1beta1.ClusterConfig{}
+			if err := yaml.Unmarshal(body, running); err != nil {
+				return err
+			}
+			file, err := ConfigFromYaml(ctx)
+			if err != nil {
+				return err
+			}
+
+			changes, err := configdiff.Diff(running, file)
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 {
+				fmt.Println("the config file matches the running config")
+				return nil
+			}
+			for _, change := range changes {
+				fmt.Println(change)
+			}
+			return nil
+		},
+	}
+}
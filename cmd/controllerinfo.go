@@ -0,0 +1,114 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/token"
+)
+
+// controllerInfo is what a new controller needs to join the cluster
+type controllerInfo struct {
+	// JoinEndpoint is the controller join API the new controller syncs the CAs and the etcd membership from
+	JoinEndpoint string `yaml:"joinEndpoint"`
+	// ClusterID is the SHA256 fingerprint of the cluster CA certificate
+	ClusterID   string `yaml:"clusterID"`
+	StorageType string `yaml:"storageType"`
+	Expiry      string `yaml:"expiry"`
+	Token       string `yaml:"token"`
+	Command     string `yaml:"command"`
+}
+
+// ControllerInfoCommand prints the info needed to join an additional controller
+func ControllerInfoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "controller-info",
+		Usage: "Print the join info for an additional controller, run on an existing controller",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Value:     "k0s.yaml",
+				TakesFile: true,
+			},
+			&cli.DurationFlag{
+				Name:  "expiry",
+				Usage: "validity of the controller token, 0 for no expiry",
+				Value: time.Hour,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			// Disable logrus, the output is meant to be copied as is
+			logrus.SetLevel(logrus.FatalLevel)
+
+			clusterConfig, err := ConfigFromYaml(c)
+			if err != nil {
+				return err
+			}
+			if !clusterConfig.Spec.Storage.IsJoinable() {
+				return fmt.Errorf("the %s storage of this cluster can't be shared by additional controllers", clusterConfig.Spec.Storage.Type)
+			}
+			expiry := c.Duration("expiry")
+			if expiry < 0 {
+				return fmt.Errorf("invalid --expiry %s, must not be negative", expiry)
+			}
+
+			joinToken, err := createKubeletBootstrapConfig(clusterConfig, "controller", expiry)
+			if err != nil {
+				return err
+			}
+			tokenInfo, err := token.Inspect(joinToken)
+			if err != nil {
+				return err
+			}
+			// the expiry is read back from the bootstrap token secret, which is removed once it expires
+			manager, err := token.NewManager(constant.AdminKubeconfigConfigPath)
+			if err != nil {
+				return err
+			}
+			expiresAt, err := manager.Expiry(tokenInfo.TokenID)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read the expiry of token %s", tokenInfo.TokenID)
+			}
+
+			info := controllerInfo{
+				JoinEndpoint: clusterConfig.Spec.API.ControllerJoinAddress(),
+				ClusterID:    tokenInfo.ClusterID,
+				StorageType:  clusterConfig.Spec.Storage.Type,
+				Expiry:       "never",
+				Token:        joinToken,
+				Command:      fmt.Sprintf("k0s server --config k0s.yaml %s", joinToken),
+			}
+			if !expiresAt.IsZero() {
+				info.Expiry = expiresAt.Format(time.RFC3339)
+			}
+
+			out, err := yaml.Marshal(info)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/drain"
+	"github.com/k0sproject/k0s/pkg/kubernetes"
+)
+
+// DrainCommand creates the command for draining a node before maintenance
+func DrainCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "drain",
+		Usage:     "Cordon a node and evict its pods before maintenance, respecting PodDisruptionBudgets",
+		ArgsUsage: "<node>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "kubeconfig",
+				Usage:     "path to kubeconfig",
+				Value:     constant.AdminKubeconfigConfigPath,
+				EnvVars:   []string{"KUBECONFIG"},
+				TakesFile: true,
+			},
+			&cli.IntFlag{
+				Name:  "max-evict-concurrency",
+				Usage: "number of pods evicted in parallel",
+				Value: drain.DefaultMaxConcurrency,
+			},
+			&cli.DurationFlag{
+				Name:  "eviction-grace-period",
+				Usage: "termination grace period given to the evicted pods, rounded up to whole seconds, the pods' own is used if zero",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "how long to wait for the node to be drained, e.g. while evictions are blocked by PodDisruptionBudgets",
+				Value: 5 * time.Minute,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			nodeName := ctx.Args().First()
+			if nodeName == "" {
+				return fmt.Errorf("node name is required")
+			}
+			if ctx.Int("max-evict-concurrency") < 1 {
+				return fmt.Errorf("max-evict-concurrency must be at least 1")
+			}
+			if ctx.Duration("eviction-grace-period") < 0 {
+				return fmt.Errorf("eviction-grace-period cannot be negative")
+			}
+			client, err := kubernetes.Client(ctx.String("kubeconfig"))
+			if err != nil {
+				return err
+			}
+
+			drainCtx, cancel := context.WithTimeout(context.Background(), ctx.Duration("timeout"))
+			defer cancel()
+			drainer := &drain.Drainer{
+				Client:         client,
+				MaxConcurrency: ctx.Int("max-evict-concurrency"),
+				GracePeriod:    ctx.Duration("eviction-grace-period"),
+			}
+			if err := drainer.Drain(drainCtx, nodeName); err != nil {
+				return err
+			}
+			fmt.Printf("Node %s drained\n", nodeName)
+			return nil
+		},
+	}
+}
@@ -16,10 +16,18 @@ limitations under the License.
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component/server"
+	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/etcd"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -30,9 +38,15 @@ func EtcdCommand() *cli.Command {
 		Name:  "etcd",
 		Usage: "Manage etcd cluster",
 		Before: func(c *cli.Context) error {
-			clusterConfig := ConfigFromYaml(c)
+			clusterConfig, err := ConfigFromYaml(c)
+			if err != nil {
+				return err
+			}
 			if clusterConfig.Spec.Storage.Type != v1beta1.EtcdStorageType {
-				return fmt.Errorf("wrong storage type: %s", clusterConfig.Spec.Storage.Type)
+				return fmt.Errorf("the etcd commands need the etcd storage type, the cluster uses %s", clusterConfig.Spec.Storage.Type)
+			}
+			if clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+				return fmt.Errorf("the external etcd cluster isn't managed by k0s, use etcdctl against its endpoints instead")
 			}
 			return nil
 		},
@@ -45,10 +59,22 @@ func EtcdCommand() *cli.Command {
 		Subcommands: []*cli.Command{
 			LeaveCommand(),
 			ListCommand(),
+			MemberRemoveCommand(),
+			ForceNewClusterCommand(),
+			MoveLeaderCommand(),
 		},
 	}
 }
 
+// localEtcdClient connects to the local etcd member on the client port of the config
+func localEtcdClient(c *cli.Context) (*etcd.Client, error) {
+	clusterConfig, err := ConfigFromYaml(c)
+	if err != nil {
+		return nil, err
+	}
+	return etcd.NewClient(clusterConfig.Spec.Storage.Etcd.ClientURL())
+}
+
 // LeaveCommand force node to leave etcd cluster
 func LeaveCommand() *cli.Command {
 	return &cli.Command{
@@ -60,18 +86,21 @@ func LeaveCommand() *cli.Command {
 			},
 		},
 		Action: func(c *cli.Context) error {
-			peerAddress := c.String("peer-address")
-			if peerAddress == "" {
-				clusterConfig := ConfigFromYaml(c)
-				peerAddress = clusterConfig.Spec.Storage.Etcd.PeerAddress
+			clusterConfig, err := ConfigFromYaml(c)
+			if err != nil {
+				return err
 			}
-			if peerAddress == "" {
+			etcdConfig := *clusterConfig.Spec.Storage.Etcd
+			if peerAddress := c.String("peer-address"); peerAddress != "" {
+				etcdConfig.PeerAddress = peerAddress
+			}
+			if etcdConfig.PeerAddress == "" {
 				return fmt.Errorf("can't leave etcd cluster: peer address is empty, check the config file or use cli argument")
 			}
 
-			peerURL := fmt.Sprintf("https://%s:2380", peerAddress)
+			peerURL := etcdConfig.PeerURL()
 
-			etcdClient, err := etcd.NewClient()
+			etcdClient, err := etcd.NewClient(etcdConfig.ClientURL())
 
 			if err != nil {
 				return fmt.Errorf("can't connect to the etcd: %v", err)
@@ -105,21 +134,197 @@ func ListCommand() *cli.Command {
 		Name:  "member-list",
 		Usage: "returns etcd cluster members list",
 		Action: func(c *cli.Context) error {
-			etcdClient, err := etcd.NewClient()
+			etcdClient, err := localEtcdClient(c)
 			if err != nil {
 				return fmt.Errorf("can't list etcd cluster members: %v", err)
 			}
+			defer etcdClient.Close()
 			members, err := etcdClient.ListMembers(c.Context)
 			if err != nil {
 				return fmt.Errorf("can't list etcd cluster members: %v", err)
 			}
+			details, err := etcdClient.Members(c.Context)
+			if err != nil {
+				return fmt.Errorf("can't list etcd cluster members: %v", err)
+			}
+			// the hex ids by peer URL, as the members which haven't started yet have no name
+			memberIDs := make(map[string]string, len(details))
+			var localID string
+			for _, m := range details {
+				for _, peerURL := range m.PeerURLs {
+					memberIDs[peerURL] = fmt.Sprintf("%x", m.ID)
+				}
+				if m.Local {
+					localID = fmt.Sprintf("%x", m.ID)
+				}
+			}
 			l := logrus.New()
 			l.SetFormatter(&logrus.JSONFormatter{})
 
 			l.WithField("members", members).
+				WithField("memberIDs", memberIDs).
+				WithField("localMemberID", localID).
 				Info("done")
 			return nil
 		},
 	}
 
 }
+
+// MemberRemoveCommand removes a member, e.g. of a failed controller, from the etcd cluster
+func MemberRemoveCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "member-remove",
+		Usage:     "Remove a member, e.g. of a failed controller, from the etcd cluster. Run it on another controller than the one of the member",
+		ArgsUsage: "<member-id|peer-url>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "yes",
+				Usage: "do not ask for confirmation when the removal endangers the quorum",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("expected the hex id or the peer URL of the member to remove")
+			}
+			etcdClient, err := localEtcdClient(c)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			members, err := etcdClient.Members(c.Context)
+			if err != nil {
+				return err
+			}
+			member, err := etcd.FindMemberByIDOrPeerURL(members, c.Args().First())
+			if err != nil {
+				return err
+			}
+			warning, err := etcd.CheckMemberRemoval(members, member)
+			if err != nil {
+				return err
+			}
+			if warning != "" {
+				fmt.Printf("WARNING: %s\n", warning)
+				if !c.Bool("yes") && !confirm(fmt.Sprintf("Remove etcd member %s?", member)) {
+					return fmt.Errorf("aborted")
+				}
+			}
+
+			if err := etcdClient.DeleteMember(c.Context, member.ID); err != nil {
+				return errors.Wrapf(err, "failed to remove etcd member %s", member)
+			}
+			logrus.
+				WithField("peerID", fmt.Sprintf("%x", member.ID)).
+				WithField("peerURLs", member.PeerURLs).
+				Info("Member removed")
+			return nil
+		},
+	}
+}
+
+// MoveLeaderCommand transfers the etcd leadership from the local member to another member
+func MoveLeaderCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "move-leader",
+		Usage:     "Transfer the etcd leadership from the local member to another member, e.g. before rebooting the node. Run it on the controller of the current leader",
+		ArgsUsage: "<target-member-id>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return fmt.Errorf("expected the id or name of the member to move the leadership to")
+			}
+			etcdClient, err := localEtcdClient(c)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			targetID, err := etcdClient.FindMember(c.Context, c.Args().First())
+			if err != nil {
+				return err
+			}
+			if err := etcdClient.MoveLeader(c.Context, targetID); err != nil {
+				return err
+			}
+
+			logrus.
+				WithField("peerID", fmt.Sprintf("%x", targetID)).
+				Info("Leadership moved")
+			return nil
+		},
+	}
+}
+
+// ForceNewClusterCommand recovers from a lost etcd quorum by turning the local member into a single member cluster
+func ForceNewClusterCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "force-new-cluster",
+		Usage: "Recover from a lost quorum by restarting the local etcd member as a new single member cluster, k0s server must be stopped",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "yes",
+				Usage: "do not ask for confirmation",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			clusterConfig, err := ConfigFromYaml(c)
+			if err != nil {
+				return err
+			}
+			// holding the lock makes sure neither k0s server nor its etcd are running
+			dataDirLock, err := util.LockDataDir(constant.ControllerLockPath)
+			if err != nil {
+				return errors.Wrap(err, "stop k0s server before forcing a new etcd cluster")
+			}
+			defer dataDirLock.Unlock()
+
+			dataDir := clusterConfig.Spec.Storage.Etcd.GetDataDir()
+			if err := etcd.CheckDataDir(dataDir); err != nil {
+				return errors.Wrap(err, "refusing to force a new etcd cluster")
+			}
+
+			fmt.Println("WARNING: this discards the etcd cluster membership and restarts the local member as a new single member cluster")
+			fmt.Printf("from the data in %s. Only do this when the majority of the controllers is permanently lost. The remaining\n", dataDir)
+			fmt.Println("controllers must not be started again with their old etcd data, reset and join them to this controller instead.")
+			fmt.Println("Any writes not replicated to this member are lost.")
+			if !c.Bool("yes") && !confirm("Force a new etcd cluster?") {
+				return fmt.Errorf("aborted")
+			}
+
+			etcdMember := &server.Etcd{
+				Config:          clusterConfig.Spec.Storage.Etcd,
+				CertManager:     certificate.Manager{},
+				ForceNewCluster: true,
+			}
+			if err := etcdMember.Init(); err != nil {
+				return err
+			}
+			if err := etcdMember.Run(); err != nil {
+				return err
+			}
+			defer func() {
+				if err := etcdMember.Stop(); err != nil {
+					logrus.Errorf("failed to stop etcd: %s", err)
+				}
+			}()
+			if err := etcdMember.WaitHealthy(); err != nil {
+				return errors.Wrap(err, "etcd did not become healthy as a new cluster")
+			}
+
+			fmt.Println("etcd now runs as a single member cluster, start k0s server again and join the other controllers with new tokens")
+			return nil
+		},
+	}
+}
+
+// confirm asks the user a yes/no question on the terminal, anything but yes is a no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
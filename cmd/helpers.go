@@ -16,19 +16,241 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/build"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
 )
 
-// ConfigFromYaml returns given k0s config or default config
-func ConfigFromYaml(ctx *cli.Context) *config.ClusterConfig {
-	clusterConfig, err := config.FromYaml(ctx.String("config"))
+// defaultConfigFetchTimeout is how long fetching the config from an URL may take if --config-timeout is not set
+const defaultConfigFetchTimeout = 30 * time.Second
+
+// maxConfigSize is the largest config read from stdin or an URL
+const maxConfigSize = 10 << 20
+
+// configSourceFlags configure fetching the config given as a http(s) URL
+var configSourceFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:      "config-ca",
+		Usage:     "CA certificate file verifying the server when the config is fetched from a https URL, the system CAs if not set",
+		TakesFile: true,
+	},
+	&cli.DurationFlag{
+		Name:  "config-timeout",
+		Usage: "timeout of fetching the config from a http(s) URL",
+		Value: defaultConfigFetchTimeout,
+	},
+}
+
+// isConfigURL returns true if the config flag points to a http(s) URL rather than a file
+func isConfigURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// ConfigFromYaml returns given k0s config, overridden by the K0S_ environment variables. The config flag is a file
+// path, - for stdin or a http(s) URL. The default config is used if the config flag was not given and there is no
+// config file at the default path.
+func ConfigFromYaml(ctx *cli.Context) (*config.ClusterConfig, error) {
+	clusterConfig, err := loadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, unknown, err := clusterConfig.ApplyEnvOverlay(withoutFlagEnvVars(os.Environ(), ctx.App))
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range overrides {
+		logrus.Debugf("config %s overridden by %s", override.Path, override.Key)
+	}
+	for _, key := range unknown {
+		logrus.Warnf("%s does not match any config field, ignoring it", key)
+	}
+	return clusterConfig, nil
+}
+
+// withoutFlagEnvVars drops the variables read by the flags of the app from the environment, so the K0S_ prefixed
+// ones aren't taken for config overrides. The flags of constant.ReservedEnvVars are skipped by ApplyEnvOverlay
+// itself, the other flags can't be known there.
+func withoutFlagEnvVars(environ []string, app *cli.App) []string {
+	if app == nil {
+		return environ
+	}
+	flagVars := map[string]bool{}
+	collectFlagEnvVars(flagVars, app.Flags, app.Commands)
+
+	var filtered []string
+	for _, entry := range environ {
+		if !flagVars[strings.SplitN(entry, "=", 2)[0]] {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// collectFlagEnvVars adds the EnvVars of the given flags and of the flags of the commands and their subcommands to
+// vars. cli.Flag doesn't expose them, they are read from the field all the flag types of urfave/cli share.
+func collectFlagEnvVars(vars map[string]bool, flags []cli.Flag, commands []*cli.Command) {
+	for _, flag := range flags {
+		field := reflect.Indirect(reflect.ValueOf(flag)).FieldByName("EnvVars")
+		if !field.IsValid() {
+			continue
+		}
+		if envVars, ok := field.Interface().([]string); ok {
+			for _, envVar := range envVars {
+				vars[envVar] = true
+			}
+		}
+	}
+	for _, command := range commands {
+		collectFlagEnvVars(vars, command.Flags, command.Subcommands)
+	}
+}
+
+// loadConfig reads the config given by the config flag
+func loadConfig(ctx *cli.Context) (*config.ClusterConfig, error) {
+	source := ctx.String("config")
+	if source == "-" {
+		data, err := ioutil.ReadAll(io.LimitReader(os.Stdin, maxConfigSize))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the config from stdin")
+		}
+		return config.FromYamlBytes(data, "stdin")
+	}
+	if isConfigURL(source) {
+		data, err := fetchConfig(source, ctx.String("config-ca"), ctx.Duration("config-timeout"))
+		if err != nil {
+			return nil, err
+		}
+		return config.FromYamlBytes(data, source)
+	}
+
+	clusterConfig, err := config.FromYaml(source)
+	if _, notFound := err.(*config.ConfigNotFoundError); notFound && !ctx.IsSet("config") {
+		logrus.Infof("no config file found at %s, using the default config", ctx.String("config"))
+		return config.DefaultClusterConfig(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clusterConfig, nil
+}
+
+// fetchConfig downloads the config, verifying a https server with the given CA file if set. Any failure is returned,
+// there is no falling back to a local config.
+func fetchConfig(url string, caFile string, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = defaultConfigFetchTimeout
+	}
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if caFile != "" {
+		if !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("--config-ca requires a https config URL, got %s", url)
+		}
+		caCert, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the config CA")
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in the config CA %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+	client := &http.Client{Transport: transport, Timeout: timeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch the config")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the config from %s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxConfigSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch the config from %s", url)
+	}
+	return data, nil
+}
+
+// logStartupBanner logs the version and build info of k0s, the role and machine id of the node, plus the given details, as a single line so the
+// logs of many nodes are easy to correlate. The machine id is the hash given by util.MachineID, not the raw id.
+func logStartupBanner(role string, details logrus.Fields) {
+	machineID, err := util.MachineID()
+	if err != nil {
+		machineID = "unknown"
+	}
+	buildInfo := build.GetInfo()
+	fields := logrus.Fields{
+		"version":   buildInfo.Version,
+		"commit":    buildInfo.GitCommit,
+		"goVersion": buildInfo.GoVersion,
+		"role":      role,
+		"machineID": machineID,
+	}
+	for k, v := range details {
+		fields[k] = v
+	}
+	logrus.WithFields(fields).Info("k0s starting")
+}
+
+// setLogFormat sets the formatter of the logs, text is the formatter set up in main
+func setLogFormat(format string) error {
+	switch format {
+	case "text":
+		return nil
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q, use text or json", format)
+	}
+}
+
+// controlSocketRequest sends a request to the control socket of the k0s server running on this node
+func controlSocketRequest(method string, path string) ([]byte, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", constant.ControlSocketPath)
+			},
+		},
+	}
+
+	req, err := http.NewRequest(method, "http://k0s"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		logrus.Errorf("Failed to read cluster config: %s", err.Error())
-		logrus.Error("THINGS MIGHT NOT WORK PROPERLY AS WE'RE GONNA USE DEFAULTS")
-		clusterConfig = config.DefaultClusterConfig()
+		return nil, errors.Wrap(err, "failed to connect to k0s server, is it running on this node?")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(body)))
 	}
-	return clusterConfig
+	return body, nil
 }
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component/server"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// KubeconfigCommand creates the command for managing the kubeconfigs created by k0s
+func KubeconfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "kubeconfig",
+		Usage: "Manage the kubeconfigs created by k0s",
+		Subcommands: []*cli.Command{
+			RegenerateAdminCommand(),
+		},
+	}
+}
+
+// RegenerateAdminCommand creates the command for regenerating the admin kubeconfig
+func RegenerateAdminCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "regenerate-admin",
+		Usage: fmt.Sprintf("Issue a new admin client certificate and rewrite %s, the previous files are backed up", constant.AdminKubeconfigConfigPath),
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Value:   "k0s.yaml",
+				Usage:   "config file, - to read it from stdin or a http(s) URL to fetch it from",
+			},
+		}, configSourceFlags...),
+		Action: func(ctx *cli.Context) error {
+			clusterConfig, err := configFromCmdFlag(ctx)
+			if err != nil {
+				return err
+			}
+			backupSuffix := ".bak-" + time.Now().Format("20060102150405")
+			if err := server.RegenerateAdminKubeconfig(certificate.Manager{}, clusterConfig.Spec.API, backupSuffix); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote new admin kubeconfig to %s\n", constant.AdminKubeconfigConfigPath)
+			return nil
+		},
+	}
+}
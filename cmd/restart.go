@@ -0,0 +1,45 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// RestartCommand creates the command for restarting a single component of a running k0s server
+func RestartCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "restart",
+		Usage:     "Restart a single component of the k0s server running on this node",
+		ArgsUsage: "<component>",
+		Action: func(ctx *cli.Context) error {
+			name := ctx.Args().First()
+			if name == "" || ctx.Args().Len() > 1 {
+				return fmt.Errorf("exactly one component name must be given")
+			}
+
+			if _, err := controlSocketRequest("POST", fmt.Sprintf("/v1beta1/components/%s/restart", url.PathEscape(name))); err != nil {
+				return err
+			}
+			logrus.Infof("%s restarted", name)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/backup"
+	"github.com/k0sproject/k0s/pkg/build"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// RestoreCommand creates the command for restoring a backup taken with k0s backup on a fresh controller
+func RestoreCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "Restore a backup taken with k0s backup on a fresh controller, k0s must not be running",
+		ArgsUsage: "<backup tarball>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "config-out",
+				Usage:     "path to write the config of the backup to",
+				Value:     "k0s.yaml",
+				TakesFile: true,
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "replace the existing certificates and storage data, which are kept with a .bak suffix, and restore a backup taken with another minor version of k0s",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only print what would be restored",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			backupPath := ctx.Args().First()
+			if backupPath == "" {
+				return fmt.Errorf("the path of the backup tarball is required")
+			}
+			dryRun := ctx.Bool("dry-run")
+			force := ctx.Bool("force")
+
+			if err := checkNoRunningController(); err != nil {
+				return err
+			}
+			if !dryRun {
+				if err := util.InitDirectory(constant.DataDir, constant.DataDirMode); err != nil {
+					return err
+				}
+				// holding the lock makes sure no k0s components are started while their state is replaced
+				dataDirLock, err := util.LockDataDir(constant.ControllerLockPath)
+				if err != nil {
+					return errors.Wrap(err, "stop k0s before restoring a backup")
+				}
+				defer dataDirLock.Unlock()
+			}
+
+			tmpDir, err := ioutil.TempDir("", "k0s-restore")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmpDir)
+
+			f, err := os.Open(backupPath)
+			if err != nil {
+				return err
+			}
+			_, err = backup.Extract(f, tmpDir)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			metadata := &backup.Metadata{}
+			data, err := ioutil.ReadFile(filepath.Join(tmpDir, backup.MetadataName))
+			if err != nil {
+				return errors.Wrapf(err, "%s is not a k0s backup", backupPath)
+			}
+			if err := yaml.Unmarshal(data, metadata); err != nil {
+				return errors.Wrapf(err, "invalid metadata in %s", backupPath)
+			}
+			if err := metadata.CheckVersion(build.Version); err != nil {
+				if !force {
+					return errors.Wrap(err, "use --force to restore it anyway")
+				}
+				logrus.Warn(err)
+			}
+
+			clusterConfig, err := config.FromYaml(filepath.Join(tmpDir, backup.ConfigName))
+			if err != nil {
+				return err
+			}
+
+			steps, err := restoreSteps(tmpDir, clusterConfig, ctx.String("config-out"), force)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("restoring the %s backup taken with k0s %s at %s\n", metadata.StorageType, metadata.K0sVersion, metadata.CreatedAt)
+			if dryRun {
+				for _, step := range steps {
+					fmt.Printf("would %s\n", step.description)
+				}
+				return nil
+			}
+			if err := runRestoreSteps(steps, ".bak-"+time.Now().Format("20060102150405")); err != nil {
+				return err
+			}
+			fmt.Printf("restored, start the controller with k0s server --config %s\n", ctx.String("config-out"))
+			return nil
+		},
+	}
+}
+
+// checkNoRunningController makes sure no controller processes of a k0s server, which might have been started without
+// holding the data dir lock, are running on the host
+func checkNoRunningController() error {
+	for _, name := range []string{"etcd", "kine", "kube-apiserver"} {
+		if pid, running := supervisor.RunningPid(name); running {
+			return fmt.Errorf("%s is running on this host with pid %d, stop k0s before restoring a backup", name, pid)
+		}
+	}
+	return nil
+}
+
+// restoreStep restores a file or directory of the controller state. The restored state is first written to a
+// staging path next to dst, and only moved into place once all the steps have been staged.
+type restoreStep struct {
+	description string
+	dst         string
+	// stale are files moved aside along with an existing dst
+	stale []string
+	stage func(staged string) error
+}
+
+// runRestoreSteps stages all the steps, then moves the staged state into place. The existing state is kept with the
+// backup suffix. Nothing is replaced if any step fails to be staged.
+func runRestoreSteps(steps []restoreStep, backupSuffix string) error {
+	staged := make([]string, len(steps))
+	defer func() {
+		for _, path := range staged {
+			if path != "" {
+				os.RemoveAll(path)
+			}
+		}
+	}()
+	for i, step := range steps {
+		fmt.Println(step.description)
+		path := step.dst + ".restore"
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+		staged[i] = path
+		if err := step.stage(path); err != nil {
+			return err
+		}
+	}
+	for i, step := range steps {
+		if err := backup.Replace(staged[i], step.dst, step.stale, backupSuffix); err != nil {
+			return err
+		}
+		staged[i] = ""
+	}
+	return nil
+}
+
+// restoreSteps plans the restore of the extracted backup. The existing state of a controller is only replaced with
+// force.
+func restoreSteps(backupDir string, clusterConfig *config.ClusterConfig, configOut string, force bool) ([]restoreStep, error) {
+	var steps []restoreStep
+	refuse := func(what string) error {
+		return fmt.Errorf("%s, use --force to replace it, the existing data is kept with a .bak suffix", what)
+	}
+
+	backupConfig := filepath.Join(backupDir, backup.ConfigName)
+	if existing, err := ioutil.ReadFile(configOut); err == nil {
+		restored, err := ioutil.ReadFile(backupConfig)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(existing, restored) {
+			return nil, fmt.Errorf("%s already exists, remove it or use --config-out to write the config of the backup elsewhere", configOut)
+		}
+	} else {
+		steps = append(steps, restoreStep{
+			description: fmt.Sprintf("write the config to %s", configOut),
+			dst:         configOut,
+			stage:       func(staged string) error { return copyFile(backupConfig, staged, 0600) },
+		})
+	}
+
+	if entries, _ := ioutil.ReadDir(constant.CertRootDir); len(entries) > 0 && !force {
+		return nil, refuse(fmt.Sprintf("the certificate directory %s is not empty", constant.CertRootDir))
+	}
+	certsDir := filepath.Join(backupDir, backup.CertsDirName)
+	steps = append(steps, restoreStep{
+		description: fmt.Sprintf("restore the certificates into %s", constant.CertRootDir),
+		dst:         constant.CertRootDir,
+		stage:       func(staged string) error { return restoreCerts(certsDir, staged) },
+	})
+
+	switch clusterConfig.Spec.Storage.Type {
+	case config.EtcdStorageType:
+		if clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+			return nil, fmt.Errorf("the external etcd cluster isn't managed by k0s, restore it with etcdctl snapshot restore instead")
+		}
+		dataDir := clusterConfig.Spec.Storage.Etcd.GetDataDir()
+		if entries, _ := ioutil.ReadDir(dataDir); len(entries) > 0 && !force {
+			return nil, refuse(fmt.Sprintf("the etcd data directory %s is not empty", dataDir))
+		}
+		name, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		peerURL := clusterConfig.Spec.Storage.Etcd.PeerURL()
+		steps = append(steps, restoreStep{
+			description: fmt.Sprintf("restore the etcd snapshot into %s as member %s with peer URL %s", dataDir, name, peerURL),
+			dst:         dataDir,
+			stage: func(staged string) error {
+				if err := util.InitDirectory(filepath.Dir(dataDir), constant.DataDirMode); err != nil {
+					return err
+				}
+				if err := backup.RestoreEtcdSnapshot(filepath.Join(backupDir, backup.EtcdSnapshotName), staged, name, peerURL); err != nil {
+					return err
+				}
+				return chownToUser(staged, constant.EtcdUser)
+			},
+		})
+	case config.KineStorageType:
+		kineConfig := clusterConfig.Spec.Storage.Kine
+		if scheme := kineConfig.DataSourceScheme(); scheme != config.KineSQLiteScheme {
+			return nil, fmt.Errorf("only the sqlite kine backend can be restored, restore the %s database with its own tools", scheme)
+		}
+		dbPath := kineConfig.SQLitePath()
+		if util.FileExists(dbPath) && !force {
+			return nil, refuse(fmt.Sprintf("the kine database %s already exists", dbPath))
+		}
+		steps = append(steps, restoreStep{
+			description: fmt.Sprintf("restore the kine database into %s", dbPath),
+			dst:         dbPath,
+			// the write-ahead log of the replaced database must not be applied to the restored one
+			stale: []string{dbPath + "-wal", dbPath + "-shm"},
+			stage: func(staged string) error {
+				if err := util.InitDirectory(filepath.Dir(dbPath), 0750); err != nil {
+					return err
+				}
+				if err := copyFile(filepath.Join(backupDir, backup.KineDBName), staged, 0600); err != nil {
+					return err
+				}
+				return chownToUser(staged, constant.KineUser)
+			},
+		})
+	default:
+		return nil, &config.InvalidStorageTypeError{Type: clusterConfig.Spec.Storage.Type}
+	}
+	return steps, nil
+}
+
+// restoreCerts copies the certificates of the backup into dir, keeping their permissions
+func restoreCerts(certsDir string, dir string) error {
+	if err := util.InitDirectory(dir, constant.CertRootDirMode); err != nil {
+		return err
+	}
+	return filepath.Walk(certsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(certsDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return err
+		}
+		return copyFile(path, dst, info.Mode().Perm())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(ioutil.WriteFile(dst, data, mode), "failed to write %s", dst)
+}
+
+// chownToUser hands the restored files to the user the component runs as, they stay owned by root if it does not exist
+func chownToUser(path string, user string) error {
+	uid, err := util.GetUID(user)
+	if err != nil {
+		logrus.Warning(errors.Wrapf(err, "leaving %s owned by root", path))
+		return nil
+	}
+	gid, _ := util.GetGID(constant.Group)
+	return filepath.Walk(path, func(p string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chown(p, uid, gid)
+	})
+}
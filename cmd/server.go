@@ -16,9 +16,13 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
 	"os/signal"
+	"path"
 	"strings"
 	"syscall"
 	"time"
@@ -30,6 +34,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
 
 	"github.com/k0sproject/k0s/pkg/applier"
 	"github.com/k0sproject/k0s/pkg/certificate"
@@ -37,89 +42,235 @@ import (
 	"github.com/k0sproject/k0s/pkg/component/server"
 	"github.com/k0sproject/k0s/pkg/component/worker"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/performance"
+	"github.com/k0sproject/k0s/pkg/preflight"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+	"github.com/k0sproject/k0s/pkg/token"
 	"github.com/k0sproject/k0s/pkg/util"
 
 	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 )
 
+const (
+	// serverReadyTimeout is how long the embedded worker waits for the server components to become healthy
+	serverReadyTimeout = 5 * time.Minute
+	// workerBootstrapAttempts is how often the embedded worker tries to create its kubelet bootstrap config by default
+	workerBootstrapAttempts = 10
+	// workerBootstrapRetryDelay is the default delay after the first attempt to create the kubelet bootstrap config,
+	// doubled after each following attempt
+	workerBootstrapRetryDelay = 100 * time.Millisecond
+)
+
 // ServerCommand ...
 func ServerCommand() *cli.Command {
 	return &cli.Command{
 		Name:   "server",
 		Usage:  "Run server",
 		Action: startServer,
-		Flags: []cli.Flag{
+		Flags: append(append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "config",
 				Aliases: []string{"c"},
 				Value:   "k0s.yaml",
+				Usage:   "config file, - to read it from stdin or a http(s) URL to fetch it from",
 			},
 			&cli.BoolFlag{
 				Name:  "enable-worker",
 				Value: false,
 			},
+			&cli.BoolFlag{
+				Name:  "enable-pprof",
+				Usage: "serve the pprof profiles of k0s itself, accessible with client certificates signed by the cluster CA",
+				Value: false,
+			},
+			&cli.StringFlag{
+				Name:  "pprof-address",
+				Usage: "address of the pprof endpoint",
+				Value: server.DefaultPprofAddress,
+			},
+			&cli.DurationFlag{
+				Name:  "cert-expiry-warning",
+				Usage: "warn on startup about the certificates expiring within this time",
+				Value: certificate.DefaultExpiryWarningWindow,
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "format of the k0s logs, text or json",
+				Value: "text",
+			},
+			&cli.StringFlag{
+				Name:  "log-levels",
+				Usage: "log levels of single components, e.g. etcd=debug,konnectivity=trace,*=info where * sets the level of the others",
+			},
+			&cli.DurationFlag{
+				Name:  "worker-bootstrap-timeout",
+				Usage: "how long the embedded worker retries creating its kubelet bootstrap config, 0 for no limit besides the attempts",
+			},
+			&cli.DurationFlag{
+				Name:  "worker-bootstrap-retry-delay",
+				Usage: "delay after the first attempt to create the kubelet bootstrap config of the embedded worker, doubled after each following attempt",
+				Value: workerBootstrapRetryDelay,
+			},
+			&cli.IntFlag{
+				Name:  "worker-bootstrap-attempts",
+				Usage: "maximum attempts to create the kubelet bootstrap config of the embedded worker",
+				Value: workerBootstrapAttempts,
+			},
 			&cli.StringFlag{
-				Name:  "profile",
-				Value: "default",
-				Usage: "worker profile to use on the node",
+				Name:  "metrics-bind-address",
+				Usage: "address to serve the Prometheus metrics of the k0s components on, e.g. 127.0.0.1:9090, disabled if empty",
 			},
-		},
+		}, configSourceFlags...), workerFlags...),
 		ArgsUsage: "[join-token]",
 	}
 }
 
 func configFromCmdFlag(ctx *cli.Context) (*config.ClusterConfig, error) {
-	clusterConfig := ConfigFromYaml(ctx)
+	clusterConfig, err := ConfigFromYaml(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	errors := clusterConfig.Validate()
+	errors, warnings := config.SplitWarnings(clusterConfig.Validate())
+	for _, w := range warnings {
+		logrus.Warnf("config: %s", w.Error())
+	}
 	if len(errors) > 0 {
-		messages := make([]string, len(errors))
+		messages := make([]string, 0, len(errors))
 		for _, e := range errors {
 			messages = append(messages, e.Error())
 		}
-		return nil, fmt.Errorf("config yaml does not pass validation, following errors found:%s", strings.Join(messages, "\n"))
+		return nil, fmt.Errorf("config yaml does not pass validation, following errors found:\n%s", strings.Join(messages, "\n"))
 	}
 
 	return clusterConfig, nil
 }
 
 func startServer(ctx *cli.Context) error {
+	if err := setDataDir(ctx); err != nil {
+		return err
+	}
+	if err := setLogFormat(ctx.String("log-format")); err != nil {
+		return err
+	}
+	if err := logging.SetLevels(ctx.String("log-levels")); err != nil {
+		return err
+	}
 	perfTimer := performance.NewTimer("server-start").Buffer().Start()
 	clusterConfig, err := configFromCmdFlag(ctx)
 	if err != nil {
 		return err
 	}
+	role := "controller"
+	if ctx.Bool("enable-worker") {
+		role = "controller+worker"
+	}
+	storage := clusterConfig.Spec.Storage.Type
+	if storage == config.EtcdStorageType && clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+		storage = "external etcd"
+	}
+	logStartupBanner(role, logrus.Fields{
+		"storage": storage,
+		"network": clusterConfig.Spec.Network.Provider,
+	})
 
 	// create directories early with the proper permissions
 	if err = util.InitDirectory(constant.DataDir, constant.DataDirMode); err != nil {
 		return err
 	}
+	dataDirLock, err := util.LockDataDir(constant.ControllerLockPath)
+	if err != nil {
+		return err
+	}
+	defer dataDirLock.Unlock()
+	if ctx.Bool("enable-worker") {
+		// the embedded worker excludes a standalone worker on the same node, like a second controller is excluded
+		workerLock, err := util.LockDataDir(constant.WorkerLockPath)
+		if err != nil {
+			return errors.Wrap(err, "stop the existing k0s worker before using --enable-worker")
+		}
+		defer workerLock.Unlock()
+	}
 	if err := util.InitDirectory(constant.CertRootDir, constant.CertRootDirMode); err != nil {
 		return err
 	}
+	if err := util.InitDirectory(constant.RunDir, constant.RunDirMode); err != nil {
+		return err
+	}
+
+	preflightResults := preflight.Run(preflight.ServerChecks(clusterConfig))
+	if err := preflight.Save(constant.PreflightResultsPath, preflightResults); err != nil {
+		logrus.Warnf("failed to save preflight results: %s", err)
+	}
+	for _, r := range preflightResults {
+		if !r.Passed {
+			logrus.Warnf("preflight check: %s", r)
+		}
+	}
+	if err := preflight.Errors(preflightResults); err != nil {
+		return err
+	}
+	if err := util.UpdateHostsFile(constant.HostsFilePath, clusterConfig.Spec.HostAliases.HostsFileEntries()); err != nil {
+		return errors.Wrapf(err, "failed to write host aliases to %s", constant.HostsFilePath)
+	}
+	if clusterConfig.Spec.TrustedCABundle != nil {
+		bundle, err := clusterConfig.Spec.TrustedCABundle.PEM()
+		if err != nil {
+			return err
+		}
+		if err := certificate.InstallTrustedCABundle(bundle); err != nil {
+			return err
+		}
+	}
 
 	componentManager := component.NewManager()
+	componentManager.SetStopTimeout(shutdownTimeout(ctx))
+	// on a terminal the startup progress is printed as it goes, otherwise it is only logged
+	progress := performance.NewProgress(os.Stdout)
+	if progress != nil {
+		perfTimer.Notify(progress.Checkpoint)
+		componentManager.ReportProgress(progress)
+	}
+	var metrics *server.Metrics
+	if address := ctx.String("metrics-bind-address"); address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return errors.Wrapf(err, "invalid metrics bind address %s", address)
+		}
+		metrics = server.NewMetrics(address)
+		perfTimer.Notify(metrics.Checkpoint)
+		componentManager.ObserveState(metrics)
+	}
 	certificateManager := certificate.Manager{}
 
 	var join = false
 	var joinClient *v1beta1.JoinClient
-	token := ctx.Args().First()
-	if token != "" {
+	joinToken := ctx.Args().First()
+	if joinToken != "" {
 		join = true
-		joinClient, err = v1beta1.JoinClientFromToken(token)
+		joinClient, err = v1beta1.JoinClientFromToken(joinToken)
 		if err != nil {
+			var expired *token.ExpiredError
+			if errors.As(err, &expired) {
+				return err
+			}
 			return errors.Wrapf(err, "failed to create join client")
 		}
+		if joinClient.Role() == token.RoleWorker {
+			return fmt.Errorf("the token is a worker join token, join this node as a worker with k0s worker <token> or create a controller token with k0s token create --role=controller")
+		}
 
+		// the CA sync is not part of the etcd join and also runs with an external etcd cluster: it fetches the
+		// Kubernetes CA and the service account key, which all the API servers have to share whatever the storage
 		componentManager.AddSync(&server.CASyncer{
 			JoinClient: joinClient,
 		})
 	}
 	componentManager.AddSync(&server.Certificates{
-		ClusterSpec: clusterConfig.Spec,
-		CertManager: certificateManager,
+		ClusterSpec:         clusterConfig.Spec,
+		CertManager:         certificateManager,
+		ExpiryWarningWindow: ctx.Duration("cert-expiry-warning"),
 	})
 
 	logrus.Infof("using public address: %s", clusterConfig.Spec.API.Address)
@@ -134,40 +285,80 @@ func startServer(ctx *cli.Context) error {
 	switch clusterConfig.Spec.Storage.Type {
 	case v1beta1.KineStorageType, "":
 		storageBackend = &server.Kine{
-			Config: clusterConfig.Spec.Storage.Kine,
+			Config:      clusterConfig.Spec.Storage.Kine,
+			StopTimeout: processStopTimeout(ctx),
 		}
 	case v1beta1.EtcdStorageType:
+		if clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+			// the etcd members are managed outside of k0s, so there is no member to run or join here
+			logrus.Infof("using external etcd cluster at %s", strings.Join(clusterConfig.Spec.Storage.Etcd.ExternalCluster.Endpoints, ","))
+			break
+		}
 		storageBackend = &server.Etcd{
 			Config:      clusterConfig.Spec.Storage.Etcd,
 			Join:        join,
 			CertManager: certificateManager,
 			JoinClient:  joinClient,
+			StopTimeout: processStopTimeout(ctx),
 		}
 	default:
-		return errors.New(fmt.Sprintf("Invalid storage type: %s", clusterConfig.Spec.Storage.Type))
+		// already rejected by the config validation
+		return &v1beta1.InvalidStorageTypeError{Type: clusterConfig.Spec.Storage.Type}
 	}
 	logrus.Infof("Using storage backend %s", clusterConfig.Spec.Storage.Type)
-	componentManager.Add(storageBackend)
+	if storageBackend != nil {
+		componentManager.Add(storageBackend)
+	}
 
 	componentManager.Add(&server.APIServer{
 		Storage:       storageBackend,
 		ClusterConfig: clusterConfig,
+		StopTimeout:   processStopTimeout(ctx),
 	})
 	componentManager.Add(&server.Konnectivity{
 		ClusterConfig: clusterConfig,
+		StopTimeout:   processStopTimeout(ctx),
 	})
 	componentManager.Add(&server.Scheduler{
 		ClusterConfig: clusterConfig,
+		StopTimeout:   processStopTimeout(ctx),
 	})
 	componentManager.Add(&server.ControllerManager{
 		ClusterConfig: clusterConfig,
+		StopTimeout:   processStopTimeout(ctx),
 	})
-	componentManager.Add(&applier.Manager{})
+	if clusterConfig.Spec.Applier.Enabled {
+		componentManager.Add(&applier.Manager{
+			ServerSideApply: clusterConfig.Spec.Applier.ServerSideApply,
+			ForceConflicts:  clusterConfig.Spec.Applier.ForceConflicts,
+		})
+	} else {
+		logrus.Warnf("manifest applier disabled, the manifests in %s have to be applied to the cluster by other means", constant.ManifestsDir)
+	}
+	configPath, err := controlAPIConfigPath(ctx, clusterConfig)
+	if err != nil {
+		return err
+	}
 	componentManager.Add(&server.K0SControlAPI{
-		ConfigPath: ctx.String("config"),
+		ConfigPath:    configPath,
+		ClusterConfig: clusterConfig,
+		StopTimeout:   processStopTimeout(ctx),
 	})
 
-	if clusterConfig.Telemetry.Enabled {
+	if ctx.Bool("enable-pprof") {
+		componentManager.Add(&server.Pprof{
+			Address: ctx.String("pprof-address"),
+		})
+	}
+
+	if metrics != nil {
+		componentManager.Add(metrics)
+	}
+
+	// --disable-telemetry is a global flag, found in the parent context
+	if ctx.Bool("disable-telemetry") {
+		logrus.Info("telemetry is disabled by --disable-telemetry")
+	} else if clusterConfig.Telemetry.Enabled {
 		componentManager.Add(&telemetry.Component{
 			ClusterConfig: clusterConfig,
 			Version:       build.Version,
@@ -185,6 +376,18 @@ func startServer(ctx *cli.Context) error {
 	// signals during startup
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	// runCtx is cancelled on the first signal, so that the startup steps waiting on something give up early.
+	// The signal is passed on to the shutdown below.
+	runCtx, cancelRun := context.WithCancel(ctx.Context)
+	defer cancelRun()
+	go func() {
+		select {
+		case sig := <-c:
+			cancelRun()
+			c <- sig
+		case <-runCtx.Done():
+		}
+	}()
 
 	perfTimer.Checkpoint("starting-components")
 	// Start components
@@ -197,21 +400,54 @@ func startServer(ctx *cli.Context) error {
 
 	perfTimer.Checkpoint("starting-reconcilers")
 	// in-cluster component reconcilers
-	reconcilers := createClusterReconcilers(clusterConfig)
+	reconcilers, reconcilerErr := createClusterReconcilers(clusterConfig)
+	if reconcilerErr != nil {
+		logrus.Error(reconcilerErr)
+		if err := componentManager.Stop(); err != nil {
+			logrus.Errorf("componentManager.Stop: %s", err)
+		}
+		return reconcilerErr
+	}
+	perfTimer.Checkpoint("created-reconcilers")
+	reconcilerStarted := func(name string) {
+		perfTimer.Checkpoint(fmt.Sprintf("reconciler-%s-started", name))
+	}
+	deferDependentReconcilers(reconcilers, reconcilerStarted)
 	if err == nil {
-		// Start all reconcilers
+		// Start all reconcilers in the order of registration
 		for _, reconciler := range reconcilers {
-			if err := reconciler.Run(); err != nil {
-				logrus.Errorf("failed to start reconciler: %s", err.Error())
+			if progress != nil {
+				progress.Started("starting", reconciler.name)
+			}
+			err := reconciler.Run()
+			if progress != nil {
+				progress.Finished("starting", reconciler.name, err)
+			}
+			if err != nil {
+				logrus.Errorf("failed to start reconciler %s: %s", reconciler.name, err.Error())
+			} else if !reconciler.deferred {
+				reconcilerStarted(reconciler.name)
 			}
 		}
 	}
 	perfTimer.Checkpoint("started-reconcilers")
+	if metrics != nil {
+		go componentManager.WatchHealth(server.MetricsHealthInterval)
+	}
 
 	if err == nil && ctx.Bool("enable-worker") {
-		perfTimer.Checkpoint("starting-worker")
-		err = enableServerWorker(clusterConfig, componentManager, ctx.String("profile"))
-		if err != nil {
+		perfTimer.Checkpoint("waiting-for-server-ready")
+		readyCtx, cancel := context.WithTimeout(runCtx, serverReadyTimeout)
+		err = componentManager.Ready(readyCtx)
+		cancel()
+		if err == nil {
+			perfTimer.Checkpoint("starting-worker")
+			err = enableServerWorker(ctx, runCtx, clusterConfig, componentManager)
+		}
+		if err != nil && runCtx.Err() != nil {
+			// shutting down already, the components started so far are stopped in order below
+			logrus.Infof("worker start cancelled: %s", err)
+		} else if err != nil {
 			logrus.Errorf("failed to start worker components: %s", err)
 			if err := componentManager.Stop(); err != nil {
 				logrus.Errorf("componentManager.Stop: %s", err)
@@ -221,16 +457,45 @@ func startServer(ctx *cli.Context) error {
 		perfTimer.Checkpoint("started-worker")
 	}
 
+	// the control socket is kept out of the component manager as it drives the manager itself
+	controlSocket := &server.ControlSocket{
+		ComponentManager: componentManager,
+		ClusterConfig:    clusterConfig,
+		Role:             role,
+		Storage:          storage,
+	}
+	if err == nil {
+		if err := controlSocket.Init(); err != nil {
+			logrus.Errorf("failed to init control socket: %s", err)
+		} else if err := controlSocket.Run(); err != nil {
+			logrus.Errorf("failed to start control socket: %s", err)
+		}
+	}
+
+	// the components have all asked for their loggers by now
+	for _, name := range logging.UnusedLevels() {
+		logrus.Warnf("--log-levels: unknown component %s, e.g. etcd, kine, kube-apiserver, konnectivity or kubelet are known", name)
+	}
+
 	perfTimer.Output()
+	summary := logrus.Fields{}
+	if workerStart, ok := perfTimer.Between("finished-component-init", "started-worker"); ok {
+		summary["worker-start-seconds"] = workerStart.Seconds()
+	}
+	perfTimer.LogSummary(summary)
 
 	// Wait for k0s process termination
 	<-c
 	logrus.Info("Shutting down k0s server")
 
-	// Stop all reconcilers first
-	for _, reconciler := range reconcilers {
-		if err := reconciler.Stop(); err != nil {
-			logrus.Warningf("failed to stop reconciler: %s", err.Error())
+	if err := controlSocket.Stop(); err != nil {
+		logrus.Warningf("failed to stop control socket: %s", err.Error())
+	}
+
+	// Stop all reconcilers first, in the reverse order of starting them
+	for i := len(reconcilers) - 1; i >= 0; i-- {
+		if err := reconcilers[i].Stop(); err != nil {
+			logrus.Warningf("failed to stop reconciler %s: %s", reconcilers[i].name, err.Error())
 		}
 	}
 
@@ -241,104 +506,223 @@ func startServer(ctx *cli.Context) error {
 	return nil
 }
 
-func createClusterReconcilers(clusterConf *config.ClusterConfig) map[string]component.Component {
-	reconcilers := make(map[string]component.Component)
+// namedReconciler is an in-cluster component reconciler with the name it is logged and reported with
+type namedReconciler struct {
+	component.Component
+	name string
+	// deferred is true if the reconciler is started in the background once its dependencies are ready
+	deferred bool
+}
+
+// startNotifier calls started once the wrapped component has been run successfully
+type startNotifier struct {
+	component.Component
+	started func()
+}
+
+// Run runs the component and calls started if it succeeded
+func (s *startNotifier) Run() error {
+	if err := s.Component.Run(); err != nil {
+		return err
+	}
+	s.started()
+	return nil
+}
+
+// reconcilerList holds the reconcilers in the order they are created and started
+type reconcilerList []namedReconciler
+
+// get returns the reconciler with the given name, nil if there is none
+func (l reconcilerList) get(name string) component.Component {
+	for _, r := range l {
+		if r.name == name {
+			return r.Component
+		}
+	}
+	return nil
+}
+
+// createClusterReconcilers creates the in-cluster component reconcilers. They are started in this order: default-psp,
+// kube-proxy, coredns, the network provider (calico, cilium or kuberouter), metricServer, kubeletConfig, defaultLimits,
+// defaultNetworkPolicy, systemRBAC, systemPriority and metricsRBAC, and stopped in the reverse order. A reconciler failing to initialize is
+// left out with a warning, unless it is one of the required reconcilers of the config, which fails the startup.
+func createClusterReconcilers(clusterConf *config.ClusterConfig) (reconcilerList, error) {
+	var reconcilers reconcilerList
+	var requiredErr error
 	clusterSpec := clusterConf.Spec
 
-	defaultPSP, err := server.NewDefaultPSP(clusterSpec)
-	if err != nil {
-		logrus.Warnf("failed to initialize default PSP reconciler: %s", err.Error())
-	} else {
-		reconcilers["default-psp"] = defaultPSP
+	add := func(name string, reconciler component.Component, err error) {
+		if err == nil {
+			reconcilers = append(reconcilers, namedReconciler{Component: reconciler, name: name})
+		} else if clusterSpec.Reconcilers.IsRequired(name) {
+			if requiredErr == nil {
+				requiredErr = fmt.Errorf("failed to initialize the required %s reconciler: %s", name, err.Error())
+			}
+		} else {
+			logrus.Warnf("failed to initialize %s reconciler: %s", name, err.Error())
+		}
 	}
 
-	proxy, err := server.NewKubeProxy(clusterConf)
-	if err != nil {
-		logrus.Warnf("failed to initialize kube-proxy reconciler: %s", err.Error())
+	defaultPSP, err := server.NewDefaultPSP(clusterSpec)
+	add("default-psp", defaultPSP, err)
+
+	if clusterSpec.Network.ReplacesKubeProxy() {
+		logrus.Infof("kube-router handles the services, not deploying kube-proxy")
 	} else {
-		reconcilers["kube-proxy"] = proxy
+		proxy, err := server.NewKubeProxy(clusterConf)
+		add("kube-proxy", proxy, err)
 	}
 
 	coreDNS, err := server.NewCoreDNS(clusterConf)
-	if err != nil {
-		logrus.Warnf("failed to initialize CoreDNS reconciler: %s", err.Error())
-	} else {
-		reconcilers["coredns"] = coreDNS
+	add("coredns", coreDNS, err)
+
+	switch clusterSpec.Network.Provider {
+	case "calico":
+		calico, err := newCalico(clusterConf)
+		add("calico", calico, err)
+	case "cilium":
+		cilium, err := newCilium(clusterConf)
+		add("cilium", cilium, err)
+	case "kuberouter":
+		kubeRouter, err := newKubeRouter(clusterConf)
+		add("kuberouter", kubeRouter, err)
+	default:
+		logrus.Warnf("network provider set to custom, k0s will not manage it")
 	}
 
-	initNetwork(reconcilers, clusterConf)
-
 	metricServer, err := server.NewMetricServer(clusterConf)
-	if err != nil {
-		logrus.Warnf("failed to initialize metric server reconciler: %s", err.Error())
-	} else {
-		reconcilers["metricServer"] = metricServer
-	}
+	add("metricServer", metricServer, err)
 
 	kubeletConfig, err := server.NewKubeletConfig(clusterSpec)
-	if err != nil {
-		logrus.Warnf("failed to initialize kubelet config reconciler: %s", err.Error())
-	} else {
-		reconcilers["kubeletConfig"] = kubeletConfig
-	}
+	add("kubeletConfig", kubeletConfig, err)
+
+	// removes the manifests if the default limits are disabled
+	defaultLimits, err := server.NewDefaultLimits(clusterSpec)
+	add("defaultLimits", defaultLimits, err)
+
+	// removes the manifests if the policies are disabled
+	defaultNetworkPolicy, err := server.NewDefaultNetworkPolicy(clusterSpec)
+	add("defaultNetworkPolicy", defaultNetworkPolicy, err)
 
 	systemRBAC, err := server.NewSystemRBAC(clusterSpec)
-	if err != nil {
-		logrus.Warnf("failed to initialize system RBAC reconciler: %s", err.Error())
-	} else {
-		reconcilers["systemRBAC"] = systemRBAC
-	}
+	add("systemRBAC", systemRBAC, err)
+
+	systemPriority, err := server.NewSystemPriority(clusterSpec)
+	add("systemPriority", systemPriority, err)
+
+	metricsRBAC, err := server.NewMetricsRBAC(clusterSpec)
+	add("metricsRBAC", metricsRBAC, err)
 
-	return reconcilers
+	if requiredErr != nil {
+		return nil, requiredErr
+	}
+	return reconcilers, nil
 }
 
-func initNetwork(reconcilers map[string]component.Component, conf *config.ClusterConfig) {
-	if conf.Spec.Network.Provider != "calico" {
-		logrus.Warnf("network provider set to custom, k0s will not manage it")
-		return
+// deferDependentReconcilers wraps the reconcilers depending on the readiness of others, so that they start only once
+// those are ready. Dependencies not managed by k0s, e.g. a custom CNI, are not waited for. started is called with the
+// name of a deferred reconciler once it has actually started in the background.
+func deferDependentReconcilers(reconcilers reconcilerList, started func(name string)) {
+	for i, reconciler := range reconcilers {
+		dependent, ok := reconciler.Component.(component.ReadinessDependent)
+		if !ok {
+			continue
+		}
+		deps := make(map[string]component.ReadinessReporter)
+		for _, dep := range dependent.ReadinessDependencies() {
+			if reporter, ok := reconcilers.get(dep).(component.ReadinessReporter); ok {
+				deps[dep] = reporter
+			} else {
+				logrus.Debugf("%s depends on %s which is not managed by k0s, not waiting for it", reconciler.name, dep)
+			}
+		}
+		if len(deps) > 0 {
+			name := reconciler.name
+			notifier := &startNotifier{Component: reconciler.Component, started: func() { started(name) }}
+			reconcilers[i].Component = component.WaitForDependencies(name, notifier, deps, server.ReconcilerReadinessTimeout)
+			reconcilers[i].deferred = true
+		}
 	}
+}
 
-	manifestsSaver, err := server.NewManifestsSaver()
+func newCalico(conf *config.ClusterConfig) (*server.Calico, error) {
+	manifestsSaver, err := server.NewManifestsSaver("calico")
 	if err != nil {
-		logrus.Warnf("failed to initialize calico reconciler manifests saver: %s", err.Error())
-		return
+		return nil, errors.Wrap(err, "failed to initialize calico reconciler manifests saver")
 	}
+	return server.NewCalico(conf, manifestsSaver)
+}
 
-	calico, err := server.NewCalico(conf, manifestsSaver)
+func newCilium(conf *config.ClusterConfig) (*server.Cilium, error) {
+	manifestsSaver, err := server.NewManifestsSaver("cilium")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize cilium reconciler manifests saver")
+	}
+	return server.NewCilium(conf, manifestsSaver)
+}
 
+func newKubeRouter(conf *config.ClusterConfig) (*server.KubeRouter, error) {
+	manifestsSaver, err := server.NewManifestsSaver("kuberouter")
 	if err != nil {
-		logrus.Warnf("failed to initialize calico reconciler: %s", err.Error())
-		return
+		return nil, errors.Wrap(err, "failed to initialize kube-router reconciler manifests saver")
 	}
+	return server.NewKubeRouter(conf, manifestsSaver)
+}
 
-	reconcilers["calico"] = calico
+// retryKubeletBootstrapConfig creates the kubelet bootstrap config of the embedded worker, retrying as configured by the
+// --worker-bootstrap-* flags until the API accepts the token creation. Cancelling runCtx aborts the retries right away.
+func retryKubeletBootstrapConfig(ctx *cli.Context, runCtx context.Context, clusterConfig *config.ClusterConfig) (string, error) {
+	timeout := ctx.Duration("worker-bootstrap-timeout")
+	attempts := ctx.Int("worker-bootstrap-attempts")
+	if attempts < 1 {
+		return "", fmt.Errorf("invalid --worker-bootstrap-attempts %d, at least one attempt is needed", attempts)
+	}
 
-}
+	bootstrapCtx, cancel := runCtx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		bootstrapCtx, cancel = context.WithTimeout(runCtx, timeout)
+	}
+	defer cancel()
 
-func enableServerWorker(clusterConfig *config.ClusterConfig, componentManager *component.Manager, profile string) error {
-	if !util.FileExists(constant.KubeletAuthConfigPath) {
-		// wait for server to start up
-		err := retry.Do(func() error {
-			if !util.FileExists(constant.AdminKubeconfigConfigPath) {
-				return fmt.Errorf("file does not exist: %s", constant.AdminKubeconfigConfigPath)
-			}
-			return nil
-		})
+	var bootstrapConfig string
+	err := retry.Do(func() error {
+		config, err := createKubeletBootstrapConfig(clusterConfig, "worker", time.Minute)
 		if err != nil {
 			return err
 		}
+		bootstrapConfig = config
+		return nil
+	},
+		retry.Context(bootstrapCtx),
+		retry.Attempts(uint(attempts)),
+		retry.Delay(ctx.Duration("worker-bootstrap-retry-delay")),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			logrus.WithError(err).Debugf("attempt %d to create the kubelet bootstrap config failed", n+1)
+		}))
+	if runCtx.Err() != nil {
+		return "", fmt.Errorf("cancelled while creating the kubelet bootstrap config")
+	}
+	if bootstrapCtx.Err() != nil {
+		return "", fmt.Errorf("failed to create the kubelet bootstrap config within %s", timeout)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create the kubelet bootstrap config in %d attempts: %v", attempts, err)
+	}
+	return bootstrapConfig, nil
+}
 
-		var bootstrapConfig string
-		err = retry.Do(func() error {
-			config, err := createKubeletBootstrapConfig(clusterConfig, "worker", time.Minute)
-			if err != nil {
-				return err
-			}
-			bootstrapConfig = config
-
-			return nil
-		})
+// enableServerWorker starts the worker components embedded in the server. Waiting for the kubelet bootstrap config
+// is given up once runCtx is done, e.g. on shutdown.
+func enableServerWorker(ctx *cli.Context, runCtx context.Context, clusterConfig *config.ClusterConfig, componentManager *component.Manager) error {
+	if err := checkNoRunningWorker(); err != nil {
+		return err
+	}
 
+	// the server components are ready at this point, the kubelet bootstrap only needs the API
+	if !util.FileExists(constant.KubeletAuthConfigPath) {
+		bootstrapConfig, err := retryKubeletBootstrapConfig(ctx, runCtx, clusterConfig)
 		if err != nil {
 			return err
 		}
@@ -346,34 +730,112 @@ func enableServerWorker(clusterConfig *config.ClusterConfig, componentManager *c
 			return err
 		}
 	}
-	worker.KernelSetup()
+	kernelSetup(ctx)
 
 	kubeletConfigClient, err := loadKubeletConfigClient()
 	if err != nil {
 		return err
 	}
 
-	containerd := &worker.ContainerD{}
+	// with an external CRI runtime only kubelet is managed by k0s
+	criSock := ctx.String("cri-socket")
+	var containerd *worker.ContainerD
+	if criSock == "" {
+		containerd, err = newContainerD(ctx)
+		if err != nil {
+			return err
+		}
+	} else if err := worker.CheckCRISocket(criSock); err != nil {
+		return err
+	}
+	verbosity, err := kubeletVerbosity(ctx)
+	if err != nil {
+		return err
+	}
+	cgroupPath, err := cgroupParent(ctx)
+	if err != nil {
+		return err
+	}
 	kubelet := &worker.Kubelet{
 		KubeletConfigClient: kubeletConfigClient,
-		Profile:             profile,
+		Profile:             ctx.String("profile"),
+		CRISocket:           criSock,
+		LogVerbosity:        verbosity,
+		CgroupParent:        cgroupPath,
+		StopTimeout:         processStopTimeout(ctx),
+	}
+	if len(clusterConfig.Spec.ReadinessGates) > 0 {
+		kubelet.Taints = []string{worker.ReadinessGatesTaint + "=:NoSchedule"}
 	}
 
-	if err := containerd.Init(); err != nil {
-		logrus.Errorf("failed to init containerd: %s", err)
+	if containerd != nil {
+		if err := containerd.Init(); err != nil {
+			logrus.Errorf("failed to init containerd: %s", err)
+		}
 	}
 	if err := kubelet.Init(); err != nil {
 		logrus.Errorf("failed to init kubelet: %s", err)
 	}
-	if err := containerd.Run(); err != nil {
-		logrus.Errorf("failed to run containerd: %s", err)
+	if containerd != nil {
+		if err := containerd.Run(); err != nil {
+			logrus.Errorf("failed to run containerd: %s", err)
+		}
+		componentManager.AddRunning(containerd)
 	}
 	if err := kubelet.Run(); err != nil {
 		logrus.Errorf("failed to run kubelet: %s", err)
 	}
 
-	componentManager.Add(containerd)
-	componentManager.Add(kubelet)
+	componentManager.AddRunning(kubelet)
+
+	if len(clusterConfig.Spec.ReadinessGates) > 0 {
+		readinessGates := &worker.NodeReadinessGates{Gates: clusterConfig.Spec.ReadinessGates}
+		if err := readinessGates.Init(); err != nil {
+			logrus.Errorf("failed to init the readiness gates: %s", err)
+		} else if err := readinessGates.Run(); err != nil {
+			logrus.Errorf("failed to wait for the readiness gates: %s", err)
+		}
+		componentManager.AddRunning(readinessGates)
+	}
+
+	return nil
+}
 
+// controlAPIConfigPath returns the config file the control API process is started with. A config read from stdin or
+// fetched from an URL is saved for it, as it can't read the same stdin nor is the URL guaranteed to serve the same
+// config again. Without a config file the empty path is returned, the control API then uses the defaults too.
+func controlAPIConfigPath(ctx *cli.Context, clusterConfig *config.ClusterConfig) (string, error) {
+	configPath := ctx.String("config")
+	if configPath == "-" || isConfigURL(configPath) {
+		data, err := yaml.Marshal(clusterConfig)
+		if err != nil {
+			return "", err
+		}
+		if err := util.InitDirectory(constant.RunDir, constant.RunDirMode); err != nil {
+			return "", err
+		}
+		configPath = path.Join(constant.RunDir, "k0s.yaml")
+		return configPath, ioutil.WriteFile(configPath, data, 0600)
+	}
+	if !ctx.IsSet("config") && !util.FileExists(configPath) {
+		return "", nil
+	}
+	return configPath, nil
+}
+
+// checkNoRunningWorker makes sure no standalone k0s worker is running on the host, the
+// embedded worker would otherwise start a second kubelet registering the same node
+func checkNoRunningWorker() error {
+	for _, name := range []string{"kubelet", "containerd"} {
+		if pid, running := supervisor.RunningPid(name); running {
+			return fmt.Errorf("%s is already running on this host with pid %d, stop the existing k0s worker before using --enable-worker", name, pid)
+		}
+	}
+
+	sock := path.Join(constant.RunDir, "containerd.sock")
+	if conn, err := net.DialTimeout("unix", sock, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("containerd is already serving on %s, stop the existing k0s worker before using --enable-worker", sock)
+	}
 	return nil
 }
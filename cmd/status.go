@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/preflight"
+)
+
+// StatusCommand creates the command for showing the status of the k0s server running on this node
+func StatusCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "Show the status of the k0s server running on this node, fails if any of its components is unhealthy",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "show-args",
+				Usage: "show the full command line of each process launched by k0s, secrets are redacted",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			body, err := controlSocketRequest("GET", "/v1beta1/status")
+			if err != nil {
+				// the preflight results tell why the server may have failed to start
+				if results, loadErr := preflight.Load(constant.PreflightResultsPath); loadErr == nil && preflight.Errors(results) != nil {
+					fmt.Println("Preflight checks of the last server start:")
+					for _, r := range results {
+						fmt.Printf("  %s\n", r)
+					}
+				}
+				return err
+			}
+			var status config.StatusResponse
+			if err := json.Unmarshal(body, &status); err != nil {
+				return err
+			}
+
+			fmt.Printf("Role: %s\n", status.Role)
+			fmt.Printf("Storage: %s\n", status.Storage)
+			fmt.Printf("Components: %s\n", strings.Join(status.Components, ", "))
+			for _, name := range status.Components {
+				health := "healthy"
+				if reason, found := status.Unhealthy[name]; found {
+					health = "unhealthy: " + reason
+				}
+				if s := status.ComponentStatus[name]; s != "" {
+					health += ", " + s
+				}
+				fmt.Printf("  %s: %s\n", name, health)
+			}
+			for _, r := range status.Preflight {
+				if !r.Passed {
+					fmt.Printf("Preflight %s check failed: %s\n", r.Severity, r.Name+": "+r.Detail)
+				}
+			}
+			if len(status.Reconcilers) > 0 {
+				fmt.Println("Reconcilers:")
+				for _, r := range status.Reconcilers {
+					fmt.Printf("  %s: %s\n", r.Name, reconcilerSummary(r))
+				}
+			}
+			if ctx.Bool("show-args") {
+				for _, p := range status.Processes {
+					fmt.Printf("\n%s:\n  %s\n", p.Name, strings.Join(p.Command, " \\\n    "))
+				}
+			}
+			// a non-zero exit code lets the command serve as a health probe
+			if len(status.Unhealthy) > 0 {
+				return fmt.Errorf("%d of %d components are unhealthy", len(status.Unhealthy), len(status.Components))
+			}
+			return nil
+		},
+	}
+}
+
+func reconcilerSummary(r config.ReconcilerStatus) string {
+	summary := "never succeeded"
+	if !r.LastSuccess.IsZero() {
+		summary = fmt.Sprintf("last success %s", r.LastSuccess.Format(time.RFC3339))
+	}
+	if r.Stale {
+		summary += " (stale)"
+	}
+	if r.LastError != "" && r.LastErrorTime.After(r.LastSuccess) {
+		summary += fmt.Sprintf(", last error at %s: %s", r.LastErrorTime.Format(time.RFC3339), r.LastError)
+	}
+	return summary
+}
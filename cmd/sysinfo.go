@@ -0,0 +1,90 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/urfave/cli/v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/preflight"
+	"github.com/k0sproject/k0s/pkg/sysinfo"
+)
+
+// SysinfoCommand creates the command for collecting the diagnostics attached to bug reports
+func SysinfoCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "sysinfo",
+		Usage: "Collect host and cluster diagnostics as json for bug reports, secrets in the config are redacted",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Value:     "k0s.yaml",
+				TakesFile: true,
+			},
+			&cli.StringFlag{
+				Name:      "output",
+				Aliases:   []string{"o"},
+				Usage:     "write the diagnostics to the given file instead of stdout",
+				TakesFile: true,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			info := sysinfo.Collect()
+
+			clusterConfig, err := ConfigFromYaml(ctx)
+			if err != nil {
+				info.AddError("config", err)
+				clusterConfig = config.DefaultClusterConfig()
+			} else if err := info.SetConfig(clusterConfig); err != nil {
+				info.AddError("config", err)
+			}
+
+			// the server is not required to run, its status is just missing then
+			if body, err := controlSocketRequest("GET", "/v1beta1/status"); err != nil {
+				info.AddError("status", err)
+			} else {
+				var status config.StatusResponse
+				if err := json.Unmarshal(body, &status); err != nil {
+					info.AddError("status", err)
+				} else {
+					info.Status = &status
+				}
+			}
+
+			// without the results of a server start, run the checks now
+			if results, err := preflight.Load(constant.PreflightResultsPath); err == nil {
+				info.Preflight = results
+			} else {
+				info.Preflight = preflight.Run(preflight.ServerChecks(clusterConfig))
+			}
+
+			out, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			if ctx.String("output") != "" {
+				return ioutil.WriteFile(ctx.String("output"), out, 0600)
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+}
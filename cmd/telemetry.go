@@ -0,0 +1,75 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/build"
+	"github.com/k0sproject/k0s/pkg/telemetry"
+)
+
+// TelemetryCommand creates the command for inspecting the telemetry of k0s
+func TelemetryCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "telemetry",
+		Usage: "Inspect the telemetry k0s sends",
+		Subcommands: []*cli.Command{
+			TelemetryPreviewCommand(),
+		},
+	}
+}
+
+// TelemetryPreviewCommand creates the command for printing the telemetry payload without sending it
+func TelemetryPreviewCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "preview",
+		Usage: "Print the telemetry k0s sends for this cluster, without sending it. Needs the server running on this node",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Aliases:   []string{"c"},
+				Value:     "k0s.yaml",
+				Usage:     "config file, - to read it from stdin or a http(s) URL to fetch it from",
+				TakesFile: true,
+			},
+		}, configSourceFlags...),
+		Action: func(ctx *cli.Context) error {
+			clusterConfig, err := ConfigFromYaml(ctx)
+			if err != nil {
+				return err
+			}
+			if !clusterConfig.Telemetry.Enabled || ctx.Bool("disable-telemetry") {
+				fmt.Fprintln(os.Stderr, "telemetry is disabled, this is what would be sent if it was enabled")
+			}
+
+			message, err := telemetry.Preview(clusterConfig, build.Version)
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(message, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+}
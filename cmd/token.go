@@ -17,11 +17,17 @@ package cmd
 
 import (
 	"bytes"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"html/template"
 	"io/ioutil"
+	"net"
+	"os"
 	"path"
+	"regexp"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -30,10 +36,12 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/token"
+	"github.com/k0sproject/k0s/pkg/util"
 )
 
 // TokenCommand creates new token management command
@@ -43,6 +51,10 @@ func TokenCommand() *cli.Command {
 		Usage: "Manage join tokens",
 		Subcommands: []*cli.Command{
 			CreateCommand(),
+			DecodeCommand(),
+			TestCommand(),
+			ListTokensCommand(),
+			InvalidateCommand(),
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -56,6 +68,9 @@ func TokenCommand() *cli.Command {
 	}
 }
 
+// bootstrapTokenIDPattern matches the public ID part of the bootstrap tokens
+var bootstrapTokenIDPattern = regexp.MustCompile(`^[a-z0-9]{6}$`)
+
 var (
 	kubeconfigTemplate = template.Must(template.New("kubeconfig").Parse(`
 apiVersion: v1
@@ -76,6 +91,12 @@ users:
 - name: {{.User}}
   user:
     token: {{.Token}}
+{{- if .Expiry}}
+extensions:
+- name: {{.ExpiryExtension}}
+  extension:
+    expiry: "{{.Expiry}}"
+{{- end}}
 `))
 )
 
@@ -87,7 +108,7 @@ func CreateCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "expiry",
-				Usage: "set duration time for token",
+				Usage: "set duration time for token, e.g. 24h, 0 for a token that never expires",
 				Value: "0",
 			},
 			&cli.StringFlag{
@@ -109,11 +130,17 @@ func CreateCommand() *cli.Command {
 			// Disable logrus for token commands
 			logrus.SetLevel(logrus.FatalLevel)
 
-			clusterConfig := ConfigFromYaml(c)
+			clusterConfig, err := ConfigFromYaml(c)
+			if err != nil {
+				return err
+			}
 			expiry, err := time.ParseDuration(c.String("expiry"))
 			if err != nil {
 				return err
 			}
+			if expiry < 0 {
+				return fmt.Errorf("invalid --expiry %s, must not be negative", expiry)
+			}
 
 			var bootstrapConfig string
 			// we will retry every second for two minutes and then error
@@ -140,6 +167,229 @@ func CreateCommand() *cli.Command {
 	}
 }
 
+// DecodeCommand creates new command to print the non-secret contents of a join token
+func DecodeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "decode",
+		Usage:     "Print the contents of a join token, secrets are redacted",
+		ArgsUsage: "<join-token>",
+		Action: func(c *cli.Context) error {
+			// Disable logrus for token commands
+			logrus.SetLevel(logrus.FatalLevel)
+
+			encodedToken := c.Args().First()
+			if encodedToken == "" {
+				return fmt.Errorf("join token must be given")
+			}
+			info, err := token.Inspect(encodedToken)
+			if err != nil {
+				return err
+			}
+
+			// tokens created by older k0s versions don't store the expiry, look it up if we can reach the cluster
+			if info.Expiry == "" {
+				info.Expiry = "unknown, not stored in the token"
+				if info.TokenID != "" && util.FileExists(c.String("kubeconfig")) {
+					if manager, err := token.NewManager(c.String("kubeconfig")); err == nil {
+						if expiry, err := manager.Expiry(info.TokenID); err == nil {
+							if expiry.IsZero() {
+								info.Expiry = "never"
+							} else {
+								info.Expiry = expiry.Format(time.RFC3339)
+							}
+						}
+					}
+				}
+			}
+
+			out, err := yaml.Marshal(info)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+			return nil
+		},
+	}
+}
+
+// ListTokensCommand creates new command to list the join tokens which can still be used
+func ListTokensCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List the join tokens which have neither expired nor been invalidated",
+		Action: func(c *cli.Context) error {
+			// Disable logrus for token commands
+			logrus.SetLevel(logrus.FatalLevel)
+
+			manager, err := token.NewManager(c.String("kubeconfig"))
+			if err != nil {
+				return err
+			}
+			tokens, err := manager.List()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tROLE\tEXPIRES\t")
+			for _, t := range tokens {
+				expiry := "never"
+				if !t.Expiry.IsZero() {
+					expiry = t.Expiry.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t\n", t.ID, t.Role, expiry)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// InvalidateCommand creates new command to revoke join tokens
+func InvalidateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "invalidate",
+		Usage:     "Invalidate join tokens, so that they can't be used to join nodes anymore",
+		ArgsUsage: "<join-token or token ID>...",
+		Action: func(c *cli.Context) error {
+			// Disable logrus for token commands
+			logrus.SetLevel(logrus.FatalLevel)
+
+			if c.Args().Len() == 0 {
+				return fmt.Errorf("at least one join token or token ID must be given")
+			}
+			manager, err := token.NewManager(c.String("kubeconfig"))
+			if err != nil {
+				return err
+			}
+			for _, arg := range c.Args().Slice() {
+				tokenID, err := tokenIDOf(arg)
+				if err != nil {
+					return err
+				}
+				if err := manager.Invalidate(tokenID); err != nil {
+					return err
+				}
+				fmt.Printf("token %s invalidated\n", tokenID)
+			}
+			return nil
+		},
+	}
+}
+
+// tokenIDOf returns the ID of the bootstrap token given either as a join token, as a bootstrap token or just its ID
+func tokenIDOf(arg string) (string, error) {
+	if bootstrapTokenIDPattern.MatchString(arg) {
+		return arg, nil
+	}
+	if parts := strings.SplitN(arg, ".", 2); len(parts) == 2 && bootstrapTokenIDPattern.MatchString(parts[0]) {
+		return parts[0], nil
+	}
+	info, err := token.Inspect(arg)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s is neither a join token nor a token ID", arg)
+	}
+	if info.TokenID == "" {
+		return "", fmt.Errorf("the join token has no bootstrap token")
+	}
+	return info.TokenID, nil
+}
+
+// TestCommand creates new command to check the join endpoints of a token are reachable before joining with it
+func TestCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "test",
+		Usage:     "Check the join endpoints of a token are reachable and accept it, without joining",
+		ArgsUsage: "<join-token>",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "time each check of an endpoint may take",
+				Value: 10 * time.Second,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			// Disable logrus for token commands
+			logrus.SetLevel(logrus.FatalLevel)
+
+			encodedToken := c.Args().First()
+			if encodedToken == "" {
+				return fmt.Errorf("join token must be given")
+			}
+			info, err := token.Inspect(encodedToken)
+			if err != nil {
+				return err
+			}
+			joinClient, err := config.JoinClientFromToken(encodedToken)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("role: %s\n", info.Role)
+			failed := 0
+			for _, endpoint := range info.JoinEndpoints {
+				fmt.Printf("%s\n", endpoint)
+				if !testJoinEndpoint(joinClient.WithAddress(endpoint, c.Duration("timeout")), info.Role, c.Duration("timeout")) {
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d join endpoints failed the test", failed, len(info.JoinEndpoints))
+			}
+			return nil
+		},
+	}
+}
+
+// testJoinEndpoint prints the outcome of the connection, certificate and token checks of the endpoint, and returns
+// false if any of them failed. The CA is fetched like CASyncer does, but not written.
+func testJoinEndpoint(client *config.JoinClient, role string, timeout time.Duration) bool {
+	cert, err := client.Handshake(timeout)
+	if err != nil {
+		fmt.Printf("  connection: failed, %s\n", describeJoinTLSError(err))
+		return false
+	}
+	fmt.Printf("  connection: ok\n")
+	fmt.Printf("  certificate: ok, %s valid until %s\n", cert.Subject.String(), cert.NotAfter.Format(time.RFC3339))
+	if time.Until(cert.NotAfter) < 30*24*time.Hour {
+		fmt.Printf("  certificate: warning, expires within 30 days\n")
+	}
+
+	if role == "controller" {
+		_, err = client.GetCA()
+	} else {
+		err = client.CheckToken()
+	}
+	if err != nil {
+		fmt.Printf("  token: failed, %s\n", err)
+		return false
+	}
+	if role == "controller" {
+		fmt.Printf("  token: ok, accepted by the join API, the CA can be fetched\n")
+	} else {
+		fmt.Printf("  token: ok, accepted by the API server\n")
+	}
+	return true
+}
+
+// describeJoinTLSError explains the usual causes of a failed connection to a join endpoint
+func describeJoinTLSError(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "the server certificate is not signed by the CA of the token, is the token from this cluster?"
+	case errors.As(err, &hostname):
+		return fmt.Sprintf("%s, add the address to spec.api.sans of the cluster config", hostname.Error())
+	case errors.As(err, &invalid):
+		return fmt.Sprintf("invalid server certificate: %s", invalid.Error())
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Sprintf("%s, is a firewall dropping the traffic?", err.Error())
+	}
+	return err.Error()
+}
+
 func createKubeletBootstrapConfig(clusterConfig *config.ClusterConfig, role string, expiry time.Duration) (string, error) {
 	caCert, err := ioutil.ReadFile(path.Join(constant.CertRootDir, "ca.crt"))
 	if err != nil {
@@ -149,18 +399,28 @@ func createKubeletBootstrapConfig(clusterConfig *config.ClusterConfig, role stri
 	if err != nil {
 		return "", err
 	}
-	tokenString, err := manager.Create(expiry, role)
+	var expiresAt time.Time
+	if expiry > 0 {
+		expiresAt = time.Now().Add(expiry).UTC().Truncate(time.Second)
+	}
+	tokenString, err := manager.Create(expiresAt, role)
 	if err != nil {
 		return "", err
 	}
 	data := struct {
-		CACert  string
-		Token   string
-		User    string
-		JoinURL string
+		CACert          string
+		Token           string
+		User            string
+		JoinURL         string
+		ExpiryExtension string
+		Expiry          string
 	}{
-		CACert: base64.StdEncoding.EncodeToString(caCert),
-		Token:  tokenString,
+		CACert:          base64.StdEncoding.EncodeToString(caCert),
+		Token:           tokenString,
+		ExpiryExtension: token.ExpiryExtension,
+	}
+	if !expiresAt.IsZero() {
+		data.Expiry = expiresAt.Format(time.RFC3339)
 	}
 	if role == "worker" {
 		data.User = "kubelet-bootstrap"
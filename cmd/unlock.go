@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// UnlockCommand creates the command for removing the stale data directory locks
+func UnlockCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "unlock",
+		Usage: "Remove the stale locks of the data directory left behind by a crashed k0s controller or worker",
+		Action: func(ctx *cli.Context) error {
+			var unlockErr error
+			removed := false
+			for _, path := range []string{constant.ControllerLockPath, constant.WorkerLockPath} {
+				pid, err := util.ForceUnlock(path)
+				if os.IsNotExist(err) {
+					continue
+				}
+				if err != nil {
+					// the lock of the other role may still be stale
+					unlockErr = err
+					continue
+				}
+				fmt.Printf("removed the stale lock %s of process %d\n", path, pid)
+				removed = true
+			}
+			if unlockErr == nil && !removed {
+				fmt.Println("the data directory is not locked")
+			}
+			return unlockErr
+		},
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+)
+
+// ValidateCommand creates the command for validating k0s resources
+func ValidateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "validate",
+		Usage: "Validate k0s resources",
+		Subcommands: []*cli.Command{
+			ValidateConfigCommand(),
+		},
+	}
+}
+
+// ValidateConfigCommand creates the command for validating the cluster config
+func ValidateConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Validate the cluster config, including advisory warnings which don't prevent k0s from starting",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:      "config",
+				Aliases:   []string{"c"},
+				Value:     "k0s.yaml",
+				Usage:     "config file, - to read it from stdin or a http(s) URL to fetch it from",
+				TakesFile: true,
+			},
+		}, configSourceFlags...),
+		Action: func(ctx *cli.Context) error {
+			clusterConfig, err := ConfigFromYaml(ctx)
+			if err != nil {
+				return err
+			}
+
+			errors, warnings := config.SplitWarnings(clusterConfig.Validate())
+			for _, w := range warnings {
+				fmt.Printf("warning: %s\n", w.Error())
+			}
+			for _, e := range errors {
+				fmt.Printf("error: %s\n", e.Error())
+			}
+			if len(errors) > 0 {
+				return fmt.Errorf("config is invalid, %d error(s) found", len(errors))
+			}
+			fmt.Println("config is valid")
+			return nil
+		},
+	}
+}
@@ -21,11 +21,15 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"path/filepath"
 	"syscall"
+	"time"
 
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/component/worker"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/token"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
@@ -37,26 +41,167 @@ import (
 // WorkerCommand ...
 func WorkerCommand() *cli.Command {
 	return &cli.Command{
-		Name:   "worker",
-		Usage:  "Run worker",
-		Action: startWorker,
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:  "profile",
-				Value: "default",
-				Usage: "worker profile to use on the node",
-			},
-			&cli.StringFlag{
-				Name:  "cri-socket",
-				Usage: "contrainer runtime socket to use, default to internal containerd. Format: [remote|docker]:[path-to-socket]",
-			},
-		},
+		Name:      "worker",
+		Usage:     "Run worker",
+		Action:    startWorker,
+		Flags:     workerFlags,
 		ArgsUsage: "[join-token]",
 	}
 }
 
-func startWorker(ctx *cli.Context) error {
+// workerFlags are the flags shared by the worker and the server's embedded worker
+var workerFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:      "data-dir",
+		Value:     constant.DataDir,
+		Usage:     "directory holding all k0s state, e.g. certificates, etcd or kine data and the kubelet kubeconfig",
+		EnvVars:   []string{constant.DataDirEnv},
+		TakesFile: true,
+	},
+	&cli.StringFlag{
+		Name:  "profile",
+		Value: "default",
+		Usage: "worker profile to use on the node",
+	},
+	&cli.StringFlag{
+		Name:  "cri-socket",
+		Usage: "contrainer runtime socket to use, default to internal containerd. Format: [remote|docker]:[path-to-socket]",
+	},
+	&cli.IntFlag{
+		Name:  "image-pull-max-concurrent-downloads",
+		Value: 3,
+		Usage: "maximum number of image layers containerd pulls in parallel",
+	},
+	&cli.DurationFlag{
+		Name:  "image-pull-timeout",
+		Value: 5 * time.Minute,
+		Usage: "time an image pull may go without any progress before containerd cancels it",
+	},
+	&cli.StringFlag{
+		Name:      "containerd-config",
+		Usage:     "TOML drop-in merged into the containerd config generated by k0s, e.g. for registry mirrors",
+		TakesFile: true,
+	},
+	&cli.DurationFlag{
+		Name:  "process-stop-timeout",
+		Value: supervisor.DefaultTimeoutStop,
+		Usage: "grace period of the supervised processes, e.g. kubelet or etcd, between SIGTERM and SIGKILL",
+	},
+	&cli.DurationFlag{
+		Name:  "shutdown-timeout",
+		Value: component.DefaultStopTimeout,
+		// has to exceed the grace period the supervised processes get between SIGTERM and SIGKILL, for the processes
+		// to be killed before their components are abandoned; shorter timeouts are raised by shutdownTimeout
+		Usage: "time each component gets to stop on shutdown before it is abandoned, 0 waits indefinitely. At least the --process-stop-timeout plus 5s",
+	},
+	&cli.BoolFlag{
+		Name:    "skip-kernel-setup",
+		Usage:   "do not load kernel modules nor set sysctls, only check them, for hosts where these are pre-configured",
+		EnvVars: []string{constant.SkipKernelSetupEnv},
+	},
+	&cli.StringFlag{
+		Name:  "cgroup-parent",
+		Usage: "systemd slice, e.g. k0s.slice, or on cgroup v1 cgroupfs path, e.g. /k0s, to place kubelet, containerd and the pods under",
+	},
+	&cli.IntFlag{
+		Name:  "kubelet-verbosity",
+		Usage: "log verbosity (--v) of kubelet, between 0 and 10, levels above 4 impact the performance",
+	},
+}
+
+// setDataDir relocates the k0s state to the --data-dir flag and exports it to the supervised k0s processes
+func setDataDir(ctx *cli.Context) error {
+	dir, err := filepath.Abs(ctx.String("data-dir"))
+	if err != nil {
+		return errors.Wrap(err, "invalid data dir")
+	}
+	constant.SetDataDir(dir)
+	return os.Setenv(constant.DataDirEnv, dir)
+}
+
+// cgroupParent returns the validated cgroup path of the --cgroup-parent flag, empty if not set
+func cgroupParent(ctx *cli.Context) (string, error) {
+	parent := ctx.String("cgroup-parent")
+	if parent == "" {
+		return "", nil
+	}
+	return util.CgroupParentPath(parent, util.CgroupVersion())
+}
+
+// kubeletVerbosity returns the validated kubelet log verbosity, warning about levels impacting the performance
+func kubeletVerbosity(ctx *cli.Context) (int, error) {
+	level := ctx.Int("kubelet-verbosity")
+	if err := config.ValidateLogVerbosity("--kubelet-verbosity", level); err != nil {
+		if _, ok := err.(*config.ValidationWarning); !ok {
+			return 0, err
+		}
+		logrus.Warn(err)
+	}
+	return level, nil
+}
+
+// kernelSetup prepares the kernel for the worker unless told to only check it
+func kernelSetup(ctx *cli.Context) {
+	if ctx.Bool("skip-kernel-setup") {
+		logrus.Info("skipping kernel setup, checking the prerequisites only")
+		worker.KernelCheck()
+		return
+	}
 	worker.KernelSetup()
+}
+
+// newContainerD creates the containerd component configured by the command line flags
+func newContainerD(ctx *cli.Context) (*worker.ContainerD, error) {
+	maxDownloads := ctx.Int("image-pull-max-concurrent-downloads")
+	if maxDownloads <= 0 {
+		return nil, fmt.Errorf("invalid --image-pull-max-concurrent-downloads %d, must be positive", maxDownloads)
+	}
+	pullTimeout := ctx.Duration("image-pull-timeout")
+	if pullTimeout <= 0 {
+		return nil, fmt.Errorf("invalid --image-pull-timeout %s, must be positive", pullTimeout)
+	}
+	cgroupPath, err := cgroupParent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dropIn := ctx.String("containerd-config")
+	if dropIn != "" && !util.FileExists(dropIn) {
+		return nil, fmt.Errorf("invalid --containerd-config %s, the file does not exist", dropIn)
+	}
+
+	return &worker.ContainerD{
+		MaxConcurrentDownloads: maxDownloads,
+		ImagePullTimeout:       pullTimeout,
+		CgroupParent:           cgroupPath,
+		Config:                 dropIn,
+		StopTimeout:            processStopTimeout(ctx),
+	}, nil
+}
+
+func startWorker(ctx *cli.Context) error {
+	if err := setDataDir(ctx); err != nil {
+		return err
+	}
+	logStartupBanner("worker", nil)
+	if err := util.InitDirectory(constant.DataDir, constant.DataDirMode); err != nil {
+		return err
+	}
+	dataDirLock, err := util.LockDataDir(constant.WorkerLockPath)
+	if err != nil {
+		return err
+	}
+	defer dataDirLock.Unlock()
+
+	verbosity, err := kubeletVerbosity(ctx)
+	if err != nil {
+		return err
+	}
+	cgroupPath, err := cgroupParent(ctx)
+	if err != nil {
+		return err
+	}
+
+	kernelSetup(ctx)
 
 	token := ctx.Args().First()
 	if token == "" && !util.FileExists(constant.KubeletAuthConfigPath) {
@@ -76,14 +221,24 @@ func startWorker(ctx *cli.Context) error {
 	}
 
 	componentManager := component.NewManager()
+	componentManager.SetStopTimeout(shutdownTimeout(ctx))
 	criSock := ctx.String("cri-socket")
 	if criSock == "" {
-		componentManager.Add(&worker.ContainerD{})
+		containerd, err := newContainerD(ctx)
+		if err != nil {
+			return err
+		}
+		componentManager.Add(containerd)
+	} else if err := worker.CheckCRISocket(criSock); err != nil {
+		return err
 	}
 	componentManager.Add(&worker.Kubelet{
 		KubeletConfigClient: kubeletConfigClient,
 		Profile:             ctx.String("profile"),
 		CRISocket:           ctx.String("cri-socket"),
+		LogVerbosity:        verbosity,
+		CgroupParent:        cgroupPath,
+		StopTimeout:         processStopTimeout(ctx),
 	})
 
 	// extract needed components
@@ -133,6 +288,9 @@ func handleKubeletBootstrapToken(encodedToken string) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to decode token")
 	}
+	if err := token.CheckExpiry(kubeconfig, time.Now()); err != nil {
+		return err
+	}
 
 	// Load the bootstrap kubeconfig to validate it
 	clientCfg, err := clientcmd.Load(kubeconfig)
@@ -158,3 +316,28 @@ func handleKubeletBootstrapToken(encodedToken string) error {
 
 	return nil
 }
+
+// processStopTimeout returns the --process-stop-timeout, the grace period of the supervised processes between SIGTERM
+// and SIGKILL
+func processStopTimeout(ctx *cli.Context) time.Duration {
+	if timeout := ctx.Duration("process-stop-timeout"); timeout > 0 {
+		return timeout
+	}
+	return supervisor.DefaultTimeoutStop
+}
+
+// shutdownTimeout returns the --shutdown-timeout, raised to exceed the grace period of the supervised processes so
+// that a process not exiting on SIGTERM is killed before its component is abandoned, leaving it running
+func shutdownTimeout(ctx *cli.Context) time.Duration {
+	timeout := ctx.Duration("shutdown-timeout")
+	minimum := processStopTimeout(ctx) + component.StopTimeoutMargin
+	if !ctx.IsSet("shutdown-timeout") && timeout < minimum {
+		// the default follows a longer --process-stop-timeout
+		return minimum
+	}
+	if timeout > 0 && timeout < minimum {
+		logrus.Warnf("--shutdown-timeout %s is shorter than the grace period of the processes, using %s", timeout, minimum)
+		return minimum
+	}
+	return timeout
+}
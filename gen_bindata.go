@@ -1,3 +1,4 @@
+//go:build ignore
 // +build ignore
 
 /*
@@ -20,6 +21,8 @@ package main
 
 import (
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
@@ -42,6 +45,8 @@ type fileInfo struct {
 	Path         string
 	TempFile     string
 	Offset, Size int64
+	// Sum is the hex encoded sha256 of the uncompressed file
+	Sum string
 }
 
 func compressFiles(prefix string) []fileInfo {
@@ -49,6 +54,8 @@ func compressFiles(prefix string) []fileInfo {
 
 	// compress the files
 	var wg sync.WaitGroup
+	var sumsMu sync.Mutex
+	sums := map[string]string{}
 	for _, dir := range flag.Args() {
 		files, err := ioutil.ReadDir(dir)
 		if err != nil {
@@ -79,11 +86,15 @@ func compressFiles(prefix string) []fileInfo {
 			}
 
 			wg.Add(1)
-			go func(wg *sync.WaitGroup) {
-				size, err := io.Copy(gz, inf)
+			go func(wg *sync.WaitGroup, name string) {
+				hash := sha256.New()
+				size, err := io.Copy(io.MultiWriter(gz, hash), inf)
 				if err != nil {
 					log.Fatal(err)
 				}
+				sumsMu.Lock()
+				sums[name] = hex.EncodeToString(hash.Sum(nil))
+				sumsMu.Unlock()
 
 				fi, err := tmpf.Stat()
 				if err != nil {
@@ -94,10 +105,13 @@ func compressFiles(prefix string) []fileInfo {
 				gz.Close()
 				fmt.Fprintf(os.Stderr, "%s: %d/%d MiB\n", name, fi.Size()/(1024*1024), size/(1024*1024))
 				wg.Done()
-			}(&wg)
+			}(&wg, name)
 		}
 	}
 	wg.Wait()
+	for i := range tmpFiles {
+		tmpFiles[i].Sum = sums[tmpFiles[i].Name]
+	}
 	return tmpFiles
 }
 
@@ -180,6 +194,11 @@ var (
 		"{{ .Name }}": { {{ .Offset }}, {{ .Size }}}, {{ end }}
 	}
 
+	BinSums = map[string]string{
+	{{ range .BinData }}
+		"{{ .Name }}": "{{ .Sum }}", {{ end }}
+	}
+
 	BinDataSize int64 = {{ .BinDataSize }}
 )
 
@@ -16,11 +16,13 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 
 	"github.com/k0sproject/k0s/pkg/build"
+	"github.com/k0sproject/k0s/pkg/constant"
 
 	"github.com/k0sproject/k0s/cmd"
 	"github.com/sirupsen/logrus"
@@ -38,6 +40,11 @@ func init() {
 	customFormatter.TimestampFormat = "2006-01-02 15:04:05"
 	customFormatter.FullTimestamp = true
 	logrus.SetFormatter(customFormatter)
+
+	// relocate the data dir before the commands pick their defaults from it
+	if dir := os.Getenv(constant.DataDirEnv); dir != "" {
+		constant.SetDataDir(dir)
+	}
 }
 
 func main() {
@@ -49,9 +56,24 @@ func main() {
 			cmd.ServerCommand(),
 			cmd.WorkerCommand(),
 			cmd.TokenCommand(),
+			cmd.ControllerInfoCommand(),
 			cmd.APICommand(),
 			cmd.EtcdCommand(),
+			cmd.BackupCommand(),
+			cmd.RestoreCommand(),
 			cmd.ConfigCommand(),
+			cmd.ConfigInspectCommand(),
+			cmd.RestartCommand(),
+			cmd.StatusCommand(),
+			cmd.KubeconfigCommand(),
+			cmd.DrainCommand(),
+			cmd.CheckCommand(),
+			cmd.SysinfoCommand(),
+			cmd.ValidateCommand(),
+			cmd.UnlockCommand(),
+			cmd.ApplyCommand(),
+			cmd.CertCommand(),
+			cmd.TelemetryCommand(),
 			versionCommand(),
 		},
 		Flags: []cli.Flag{
@@ -61,6 +83,11 @@ func main() {
 				Aliases: []string{"d"},
 				EnvVars: []string{"DEBUG"},
 			},
+			&cli.BoolFlag{
+				Name:    "disable-telemetry",
+				Usage:   "do not send any telemetry, regardless of the telemetry config",
+				EnvVars: []string{constant.DisableTelemetryEnv},
+			},
 		},
 		Before: func(ctx *cli.Context) error {
 			if ctx.Bool("debug") {
@@ -81,7 +108,21 @@ func versionCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "version",
 		Usage: "Print version info",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "print the version, git commit, Go version and platform of the build as JSON",
+			},
+		},
 		Action: func(ctx *cli.Context) error {
+			if ctx.Bool("json") {
+				out, err := json.MarshalIndent(build.GetInfo(), "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+				return nil
+			}
 			fmt.Println(build.Version)
 			return nil
 		},
@@ -0,0 +1,41 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+// ApplierSpec defines how the manifests in the k0s manifests directory are applied
+type ApplierSpec struct {
+	// Enabled runs the applier, when disabled the manifests are still generated but applying them is up to the user
+	Enabled bool `yaml:"enabled"`
+	// ServerSideApply makes the applier use server-side apply with the field manager "k0s"
+	ServerSideApply bool `yaml:"serverSideApply"`
+	// ForceConflicts takes over fields owned by other field managers instead of failing the apply
+	ForceConflicts bool `yaml:"forceConflicts"`
+}
+
+// DefaultApplierSpec creates the ApplierSpec with the applier enabled, using the default client-side apply
+func DefaultApplierSpec() *ApplierSpec {
+	return &ApplierSpec{
+		Enabled: true,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (a *ApplierSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*a = *DefaultApplierSpec()
+
+	type yapplierspec ApplierSpec
+	return unmarshal((*yapplierspec)(a))
+}
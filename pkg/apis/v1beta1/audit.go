@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"strconv"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// AuditSpec defines the API server audit logging
+type AuditSpec struct {
+	Enabled bool `yaml:"enabled"`
+	// PolicyFile is the audit policy, k0s writes one logging the metadata of all requests if not set
+	PolicyFile string `yaml:"policyFile"`
+	LogPath    string `yaml:"logPath"`
+	// MaxAge is the number of days rotated logs are kept
+	MaxAge int `yaml:"maxAge"`
+	// MaxBackup is the number of rotated logs kept
+	MaxBackup int `yaml:"maxBackup"`
+	// MaxSize is the size in megabytes a log is rotated at
+	MaxSize int `yaml:"maxSize"`
+}
+
+// DefaultAuditSpec creates the AuditSpec with sane defaults, disabled
+func DefaultAuditSpec() *AuditSpec {
+	return &AuditSpec{
+		Enabled:   false,
+		LogPath:   constant.AuditLogPath,
+		MaxAge:    30,
+		MaxBackup: 10,
+		MaxSize:   100,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (a *AuditSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*a = *DefaultAuditSpec()
+
+	type yauditspec AuditSpec
+	return unmarshal((*yauditspec)(a))
+}
+
+// Validate validates the rotation settings are not negative
+func (a *AuditSpec) Validate() []error {
+	var errors []error
+	if a == nil {
+		return errors
+	}
+	for name, value := range map[string]int{"maxAge": a.MaxAge, "maxBackup": a.MaxBackup, "maxSize": a.MaxSize} {
+		if value < 0 {
+			errors = append(errors, newValidationError("spec.api.audit."+name, ValidationErrorInvalid, "api.audit.%s cannot be negative, got %d", name, value))
+		}
+	}
+	return errors
+}
+
+// GetPolicyFile returns the audit policy passed to the API server
+func (a *AuditSpec) GetPolicyFile() string {
+	if a.PolicyFile == "" {
+		return constant.AuditPolicyPath
+	}
+	return a.PolicyFile
+}
+
+// Args returns the kube-apiserver flags of the audit settings, none if disabled
+func (a *AuditSpec) Args() map[string]string {
+	args := map[string]string{}
+	if a == nil || !a.Enabled {
+		return args
+	}
+	args["audit-policy-file"] = a.GetPolicyFile()
+	args["audit-log-path"] = a.LogPath
+	args["audit-log-maxage"] = strconv.Itoa(a.MaxAge)
+	args["audit-log-maxbackup"] = strconv.Itoa(a.MaxBackup)
+	args["audit-log-maxsize"] = strconv.Itoa(a.MaxSize)
+	return args
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestAuditDefaults(t *testing.T) {
+	a := &AuditSpec{}
+	assert.NoError(t, yaml.Unmarshal([]byte("enabled: true\nmaxSize: 500"), a))
+
+	assert.Equal(t, map[string]string{
+		"audit-policy-file":   constant.AuditPolicyPath,
+		"audit-log-path":      constant.AuditLogPath,
+		"audit-log-maxage":    "30",
+		"audit-log-maxbackup": "10",
+		"audit-log-maxsize":   "500",
+	}, a.Args())
+	assert.Empty(t, a.Validate())
+
+	a.Enabled = false
+	assert.Empty(t, a.Args())
+}
+
+func TestAuditValidation(t *testing.T) {
+	a := DefaultAuditSpec()
+	a.MaxBackup = -1
+	errors := a.Validate()
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "api.audit.maxBackup cannot be negative, got -1", errors[0].Error())
+}
@@ -18,6 +18,10 @@ package v1beta1
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
@@ -41,38 +45,225 @@ type ClusterMeta struct {
 
 // ClusterSpec ...
 type ClusterSpec struct {
-	API               *APISpec               `yaml:"api"`
-	ControllerManager *ControllerManagerSpec `yaml:"controllerManager"`
-	Scheduler         *SchedulerSpec         `yaml:"scheduler"`
-	Storage           *StorageSpec           `yaml:"storage"`
-	Network           *Network               `yaml:"network"`
-	PodSecurityPolicy *PodSecurityPolicy     `yaml:"podSecurityPolicy"`
-	WorkerProfiles    WorkerProfiles         `yaml:"workerProfiles"`
+	API                 *APISpec               `yaml:"api"`
+	ControllerManager   *ControllerManagerSpec `yaml:"controllerManager"`
+	Scheduler           *SchedulerSpec         `yaml:"scheduler"`
+	Storage             *StorageSpec           `yaml:"storage"`
+	Network             *Network               `yaml:"network"`
+	PodSecurityPolicy   *PodSecurityPolicy     `yaml:"podSecurityPolicy"`
+	WorkerProfiles      WorkerProfiles         `yaml:"workerProfiles"`
+	DefaultLimits       *DefaultLimits         `yaml:"defaultLimits"`
+	Applier             *ApplierSpec           `yaml:"applier"`
+	CoreDNS             *CoreDNSSpec           `yaml:"coredns"`
+	Konnectivity        *KonnectivitySpec      `yaml:"konnectivity"`
+	TrustedCABundle     *TrustedCABundle       `yaml:"trustedCABundle"`
+	HostAliases         HostAliases            `yaml:"hostAliases"`
+	SystemPriority      *SystemPriority        `yaml:"systemPriority"`
+	SafeToEvict         *SafeToEvict           `yaml:"safeToEvict"`
+	LogVerbosity        *LogVerbosity          `yaml:"logVerbosity"`
+	Reconcilers         *ReconcilersSpec       `yaml:"reconcilers"`
+	KubeletCertificates *KubeletCertificates   `yaml:"kubeletCertificates"`
+	ReadinessGates      ReadinessGates         `yaml:"readinessGates"`
+	Metrics             *MetricsSpec           `yaml:"metrics"`
 }
 
 // APISpec ...
 type APISpec struct {
-	Address   string            `yaml:"address"`
-	SANs      []string          `yaml:"sans"`
-	ExtraArgs map[string]string `yaml:"extraArgs"`
+	Address string `yaml:"address"`
+	// BindAddress is the local address the API server listens on, all interfaces if not set
+	BindAddress string            `yaml:"bindAddress"`
+	SANs        SANList           `yaml:"sans"`
+	ExtraArgs   map[string]string `yaml:"extraArgs"`
+	Audit       *AuditSpec        `yaml:"audit"`
+	// AutoSANs adds the address and hostname of the node to the SANs when the certificates are synced
+	AutoSANs bool `yaml:"autoSANs"`
+	// EnableProfiling enables the profiling endpoints of the API server
+	EnableProfiling bool `yaml:"enableProfiling"`
+	// KubeletPreferredAddressTypes is the order of the node address types used to connect to the kubelets
+	KubeletPreferredAddressTypes []string `yaml:"kubeletPreferredAddressTypes"`
+	// WatchCache sets the sizes of the API server watch caches
+	WatchCache *WatchCacheSpec `yaml:"watchCache"`
+}
+
+// DefaultKubeletPreferredAddressTypes is the order of the node address types used to connect to the kubelets if not configured
+var DefaultKubeletPreferredAddressTypes = []string{"InternalIP", "ExternalIP", "Hostname"}
+
+// validNodeAddressTypes are the node address types known to kubernetes
+var validNodeAddressTypes = []string{"Hostname", "InternalIP", "ExternalIP", "InternalDNS", "ExternalDNS"}
+
+// GetKubeletPreferredAddressTypes returns the order of the node address types used to connect to the kubelets
+func (a *APISpec) GetKubeletPreferredAddressTypes() []string {
+	if len(a.KubeletPreferredAddressTypes) == 0 {
+		return DefaultKubeletPreferredAddressTypes
+	}
+	return a.KubeletPreferredAddressTypes
+}
+
+// Validate validates the api config
+func (a *APISpec) Validate() []error {
+	var errors []error
+	if a == nil {
+		return errors
+	}
+	if a.BindAddress != "" {
+		if err := validateBindAddress("api.bindAddress", a.BindAddress); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	seen := map[string]bool{}
+	for _, addressType := range a.KubeletPreferredAddressTypes {
+		if !util.StringSliceContains(validNodeAddressTypes, addressType) {
+			errors = append(errors, newValidationError("spec.api.kubeletPreferredAddressTypes", ValidationErrorUnsupported, "api.kubeletPreferredAddressTypes: invalid address type %q, valid types are: %s", addressType, strings.Join(validNodeAddressTypes, ", ")))
+		} else if seen[addressType] {
+			errors = append(errors, newValidationError("spec.api.kubeletPreferredAddressTypes", ValidationErrorDuplicate, "api.kubeletPreferredAddressTypes: duplicate address type %q", addressType))
+		}
+		seen[addressType] = true
+	}
+	errors = append(errors, a.Audit.Validate()...)
+	errors = append(errors, a.WatchCache.Validate()...)
+	return errors
+}
+
+// CertificateSANs returns the SANs of the API certificates, with AutoSANs the configured SANs merged with the
+// current address and hostname of this node
+func (a *APISpec) CertificateSANs() ([]string, error) {
+	if !a.AutoSANs {
+		return a.SANs, nil
+	}
+	nodeSANs, err := util.NodeSANs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to detect the SANs of this node")
+	}
+	return dedupSANs(append(append([]string{}, a.SANs...), nodeSANs...)), nil
+}
+
+// BindAddressReachable tells whether the API server is reachable through the address or SANs
+// clients are given, when it only listens on BindAddress
+func (a *APISpec) BindAddressReachable() bool {
+	if a.BindAddress == "" || net.ParseIP(a.BindAddress).IsUnspecified() || a.BindAddress == a.Address {
+		return true
+	}
+	return util.StringSliceContains(a.SANs, a.BindAddress)
+}
+
+// validateBindAddress checks the address is an IP assigned to this node
+func validateBindAddress(field string, address string) error {
+	local, err := util.IsLocalAddress(address)
+	if err != nil {
+		return newValidationError("spec."+field, ValidationErrorInvalid, "%s: %s", field, err.Error())
+	}
+	if !local {
+		return newValidationError("spec."+field, ValidationErrorInvalid, "%s: %s is not an address of this node", field, address)
+	}
+	return nil
 }
 
 // ControllerManagerSpec ...
 type ControllerManagerSpec struct {
 	ExtraArgs map[string]string `yaml:"extraArgs"`
+	// TerminatedPodGCThreshold is the number of terminated pods kept before they are garbage collected, kube default if not set
+	TerminatedPodGCThreshold int `yaml:"terminatedPodGCThreshold"`
+	// ConcurrentGCSyncs is the number of garbage collector workers, kube default if not set
+	ConcurrentGCSyncs int `yaml:"concurrentGCSyncs"`
+	// BindAddress is the address the secure port serving the metrics listens on, localhost if not set
+	BindAddress string `yaml:"bindAddress"`
+}
+
+// Validate validates the controller manager config
+func (c *ControllerManagerSpec) Validate() []error {
+	var errors []error
+	if c == nil {
+		return errors
+	}
+	if c.TerminatedPodGCThreshold < 0 {
+		errors = append(errors, newValidationError("spec.controllerManager.terminatedPodGCThreshold", ValidationErrorInvalid, "controllerManager.terminatedPodGCThreshold must be a positive integer, got %d", c.TerminatedPodGCThreshold))
+	}
+	if c.ConcurrentGCSyncs < 0 {
+		errors = append(errors, newValidationError("spec.controllerManager.concurrentGCSyncs", ValidationErrorInvalid, "controllerManager.concurrentGCSyncs must be a positive integer, got %d", c.ConcurrentGCSyncs))
+	}
+	if c.BindAddress != "" {
+		errors = append(errors, validateMetricsBindAddress("controllerManager.bindAddress", c.BindAddress)...)
+	}
+	return errors
+}
+
+// validateMetricsBindAddress validates the bind address of the secure port of a control plane component,
+// and warns about it being reachable from other hosts
+func validateMetricsBindAddress(field string, address string) []error {
+	if err := validateBindAddress(field, address); err != nil {
+		return []error{err}
+	}
+	if !net.ParseIP(address).IsLoopback() {
+		return []error{&ValidationWarning{Message: fmt.Sprintf("%s %s exposes the metrics and health endpoints beyond localhost, only authorized clients can scrape them but the port should still be firewalled from untrusted networks", field, address)}}
+	}
+	return nil
+}
+
+// DefaultNodeCIDRMaskSize is the prefix length of the pod CIDR assigned to each node
+const DefaultNodeCIDRMaskSize = 24
+
+// NodeCIDRMaskSize returns the prefix length of the pod CIDR assigned to each node, which can be changed with extraArgs
+func (c *ControllerManagerSpec) NodeCIDRMaskSize() int {
+	if c != nil {
+		if size, err := strconv.Atoi(c.ExtraArgs["node-cidr-mask-size"]); err == nil {
+			return size
+		}
+	}
+	return DefaultNodeCIDRMaskSize
+}
+
+// Args returns the kube-controller-manager flags of the configured settings
+func (c *ControllerManagerSpec) Args() map[string]string {
+	args := map[string]string{}
+	if c.TerminatedPodGCThreshold > 0 {
+		args["terminated-pod-gc-threshold"] = strconv.Itoa(c.TerminatedPodGCThreshold)
+	}
+	if c.ConcurrentGCSyncs > 0 {
+		args["concurrent-gc-syncs"] = strconv.Itoa(c.ConcurrentGCSyncs)
+	}
+	if c.BindAddress != "" {
+		args["bind-address"] = c.BindAddress
+	}
+	return args
 }
 
 // SchedulerSpec ...
 type SchedulerSpec struct {
 	ExtraArgs map[string]string `yaml:"extraArgs"`
+	// BindAddress is the address the secure port serving the metrics listens on, localhost if not set
+	BindAddress string `yaml:"bindAddress"`
 }
 
-// Validate validates cluster config
+// Validate validates the scheduler config
+func (s *SchedulerSpec) Validate() []error {
+	if s == nil || s.BindAddress == "" {
+		return nil
+	}
+	return validateMetricsBindAddress("scheduler.bindAddress", s.BindAddress)
+}
+
+// Validate validates cluster config. The result includes ValidationWarnings, which are not fatal.
 func (c *ClusterConfig) Validate() []error {
 	var errors []error
 
+	errors = append(errors, c.Spec.API.Validate()...)
+	errors = append(errors, c.Spec.ControllerManager.Validate()...)
+	errors = append(errors, c.Spec.Scheduler.Validate()...)
+	errors = append(errors, c.Spec.Storage.Validate()...)
 	errors = append(errors, c.Spec.Network.Validate()...)
 	errors = append(errors, c.Spec.WorkerProfiles.Validate()...)
+	errors = append(errors, c.Spec.DefaultLimits.Validate()...)
+	errors = append(errors, c.Spec.CoreDNS.Validate()...)
+	errors = append(errors, c.Spec.Konnectivity.Validate()...)
+	errors = append(errors, c.Spec.TrustedCABundle.Validate()...)
+	errors = append(errors, c.Spec.HostAliases.Validate()...)
+	errors = append(errors, c.Spec.LogVerbosity.Validate()...)
+	errors = append(errors, c.Spec.Reconcilers.Validate()...)
+	errors = append(errors, c.Spec.ReadinessGates.Validate()...)
+	errors = append(errors, c.Spec.Metrics.Validate()...)
+	errors = append(errors, c.Telemetry.Validate()...)
+	errors = append(errors, c.Spec.validateExclusions()...)
+	errors = append(errors, c.Spec.Network.ScaleWarnings(c.Spec.ControllerManager.NodeCIDRMaskSize())...)
 	// TODO We need to validate all other parts too
 
 	return errors
@@ -83,22 +274,134 @@ func (a *APISpec) APIAddress() string {
 	return fmt.Sprintf("https://%s:6443", a.Address)
 }
 
+// LocalAPIAddress returns the URL the components of the controller reach the local API server at: localhost, unless
+// the API server only listens on the bind address
+func (a *APISpec) LocalAPIAddress() string {
+	host := "localhost"
+	if a.BindAddress != "" && !net.ParseIP(a.BindAddress).IsUnspecified() {
+		host = a.BindAddress
+	}
+	return fmt.Sprintf("https://%s", net.JoinHostPort(host, "6443"))
+}
+
 // ControllerJoinAddress returns the controller join APIs address
 func (a *APISpec) ControllerJoinAddress() string {
 	return fmt.Sprintf("https://%s:9443", a.Address)
 }
 
+// ConfigNotFoundError is returned when the config file does not exist
+type ConfigNotFoundError struct {
+	Path string
+}
+
+func (e *ConfigNotFoundError) Error() string {
+	return fmt.Sprintf("config file not found at %s, create one with `k0s default-config > %s`", e.Path, e.Path)
+}
+
+// InvalidConfigError is returned when the config file cannot be parsed
+type InvalidConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *InvalidConfigError) Error() string {
+	return fmt.Sprintf("config file %s is invalid YAML: %s", e.Path, e.Err.Error())
+}
+
+// ValidationErrorCode is the machine readable reason of a validation error, named after the Kubernetes field errors
+type ValidationErrorCode string
+
+// validation error codes
+const (
+	// ValidationErrorInvalid is a value that is malformed or out of range
+	ValidationErrorInvalid ValidationErrorCode = "Invalid"
+	// ValidationErrorRequired is a value that must be given but is missing
+	ValidationErrorRequired ValidationErrorCode = "Required"
+	// ValidationErrorUnsupported is a value that is not one of the supported choices
+	ValidationErrorUnsupported ValidationErrorCode = "Unsupported"
+	// ValidationErrorDuplicate is a value given more than once in a list
+	ValidationErrorDuplicate ValidationErrorCode = "Duplicate"
+	// ValidationErrorForbidden is a field that can't be set, e.g. as k0s manages it or it conflicts with another one
+	ValidationErrorForbidden ValidationErrorCode = "Forbidden"
+)
+
+// FieldError is implemented by the validation errors of a single config field
+type FieldError interface {
+	error
+	// FieldPath is the path of the offending field, e.g. spec.network.podCIDR
+	FieldPath() string
+	// ErrorCode is the machine readable reason of the error
+	ErrorCode() ValidationErrorCode
+}
+
+// ValidationError is a validation failure of a single config field
+type ValidationError struct {
+	// Field is the path of the offending field, e.g. spec.network.podCIDR
+	Field string
+	Code  ValidationErrorCode
+	// Message is the human readable description of the error
+	Message string
+}
+
+// newValidationError creates a ValidationError with a formatted message
+func newValidationError(field string, code ValidationErrorCode, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error returns the human readable message, which names the field already
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// FieldPath implements FieldError
+func (e *ValidationError) FieldPath() string {
+	return e.Field
+}
+
+// ErrorCode implements FieldError
+func (e *ValidationError) ErrorCode() ValidationErrorCode {
+	return e.Code
+}
+
+// ValidationWarning is an advisory result of the config validation, it doesn't prevent k0s from running
+type ValidationWarning struct {
+	Message string
+}
+
+func (w *ValidationWarning) Error() string {
+	return w.Message
+}
+
+// SplitWarnings separates the validation warnings from the actual validation errors
+func SplitWarnings(validationErrors []error) (errors []error, warnings []error) {
+	for _, err := range validationErrors {
+		if _, ok := err.(*ValidationWarning); ok {
+			warnings = append(warnings, err)
+		} else {
+			errors = append(errors, err)
+		}
+	}
+	return errors, warnings
+}
+
 // FromYaml ...
 func FromYaml(filename string) (*ClusterConfig, error) {
 	buf, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, &ConfigNotFoundError{Path: filename}
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to read config file at %s", filename)
 	}
+	return FromYamlBytes(buf, filename)
+}
 
+// FromYamlBytes parses the config read from the given source, e.g. a file path or an URL
+func FromYamlBytes(buf []byte, source string) (*ClusterConfig, error) {
 	config := &ClusterConfig{}
-	err = yaml.Unmarshal(buf, &config)
+	err := yaml.Unmarshal(buf, &config)
 	if err != nil {
-		return config, err
+		return config, &InvalidConfigError{Path: source, Err: err}
 	}
 
 	if config.Spec == nil {
@@ -148,19 +451,29 @@ func DefaultAPISpec() *APISpec {
 	addresses, _ := util.AllAddresses()
 	publicAddress, _ := util.FirstPublicAddress()
 	return &APISpec{
-		SANs:    append(addresses, publicAddress),
+		SANs:    dedupSANs(append(addresses, publicAddress)),
 		Address: publicAddress,
+		Audit:   DefaultAuditSpec(),
 	}
 }
 
 // DefaultClusterSpec default settings
 func DefaultClusterSpec() *ClusterSpec {
 	return &ClusterSpec{
-		Storage:           DefaultStorageSpec(),
-		Network:           DefaultNetwork(),
-		API:               DefaultAPISpec(),
-		ControllerManager: &ControllerManagerSpec{},
-		Scheduler:         &SchedulerSpec{},
-		PodSecurityPolicy: DefaultPodSecurityPolicy(),
+		Storage:             DefaultStorageSpec(),
+		Network:             DefaultNetwork(),
+		API:                 DefaultAPISpec(),
+		ControllerManager:   &ControllerManagerSpec{},
+		Scheduler:           &SchedulerSpec{},
+		PodSecurityPolicy:   DefaultPodSecurityPolicy(),
+		DefaultLimits:       DefaultDefaultLimits(),
+		Applier:             DefaultApplierSpec(),
+		CoreDNS:             DefaultCoreDNSSpec(),
+		Konnectivity:        DefaultKonnectivitySpec(),
+		SystemPriority:      DefaultSystemPriority(),
+		SafeToEvict:         DefaultSafeToEvict(),
+		LogVerbosity:        &LogVerbosity{},
+		Reconcilers:         DefaultReconcilersSpec(),
+		KubeletCertificates: DefaultKubeletCertificates(),
 	}
 }
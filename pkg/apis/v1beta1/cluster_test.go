@@ -16,6 +16,9 @@ limitations under the License.
 package v1beta1
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/k0sproject/k0s/pkg/util"
@@ -76,6 +79,23 @@ func fromYaml(t *testing.T, yamlData string) (*ClusterConfig, error) {
 	return config, nil
 }
 
+func TestFromYamlErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	missing := filepath.Join(dir, "missing.yaml")
+	_, err = FromYaml(missing)
+	assert.IsType(t, &ConfigNotFoundError{}, err)
+	assert.Contains(t, err.Error(), "config file not found at "+missing)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	assert.NoError(t, ioutil.WriteFile(invalid, []byte("spec: [foo"), 0644))
+	_, err = FromYaml(invalid)
+	assert.IsType(t, &InvalidConfigError{}, err)
+	assert.Contains(t, err.Error(), "is invalid YAML")
+}
+
 func TestNetworkValidation_Custom(t *testing.T) {
 	yamlData := `
 apiVersion: k0s.k0sproject.io/v1beta1
@@ -114,6 +134,57 @@ spec:
 	assert.Equal(t, 0, len(errors))
 }
 
+func TestNetworkValidation_Cilium(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+metadata:
+  name: foobar
+spec:
+  network:
+    provider: cilium
+    defaultNetworkPolicy:
+      enabled: true
+  storage:
+    type: etcd
+`
+
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	errors := c.Validate()
+	assert.Equal(t, 0, len(errors))
+	assert.True(t, c.Spec.Network.EnforcesNetworkPolicy())
+	assert.True(t, c.Spec.Reconcilers.IsRequired("cilium"))
+}
+
+func TestNetworkValidation_KubeRouter(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+metadata:
+  name: foobar
+spec:
+  network:
+    provider: kuberouter
+    kuberouter:
+      serviceProxy: true
+  storage:
+    type: etcd
+`
+
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.Empty(t, c.Validate())
+	assert.Equal(t, 0, c.Spec.Network.KubeRouter.MTU)
+	assert.True(t, c.Spec.Network.ReplacesKubeProxy())
+	assert.True(t, c.Spec.Network.EnforcesNetworkPolicy())
+
+	c.Spec.Network.KubeRouter.MTU = -1
+	errors := c.Validate()
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "network.kuberouter.mtu cannot be negative, got -1", errors[0].Error())
+}
+
 func TestNetworkValidation_Invalid(t *testing.T) {
 	yamlData := `
 apiVersion: k0s.k0sproject.io/v1beta1
@@ -133,3 +204,309 @@ spec:
 	assert.Equal(t, 1, len(errors))
 	assert.Equal(t, "unsupported network provider: invalidProvider", errors[0].Error())
 }
+
+func TestControllerManagerGC(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  controllerManager:
+    terminatedPodGCThreshold: 1000
+    concurrentGCSyncs: -1
+`
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	errors := c.Validate()
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "controllerManager.concurrentGCSyncs must be a positive integer, got -1", errors[0].Error())
+
+	c.Spec.ControllerManager.ConcurrentGCSyncs = 50
+	assert.Equal(t, map[string]string{
+		"terminated-pod-gc-threshold": "1000",
+		"concurrent-gc-syncs":         "50",
+	}, c.Spec.ControllerManager.Args())
+}
+
+func TestSystemPriority(t *testing.T) {
+	c, err := fromYaml(t, "apiVersion: k0s.k0sproject.io/v1beta1")
+	assert.NoError(t, err)
+	assert.True(t, c.Spec.SystemPriority.Enabled)
+
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  systemPriority:
+    enabled: false
+`
+	c, err = fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.False(t, c.Spec.SystemPriority.Enabled)
+}
+
+func TestApplierEnabled(t *testing.T) {
+	c, err := fromYaml(t, "apiVersion: k0s.k0sproject.io/v1beta1")
+	assert.NoError(t, err)
+	assert.True(t, c.Spec.Applier.Enabled)
+
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  applier:
+    serverSideApply: true
+`
+	c, err = fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.True(t, c.Spec.Applier.Enabled)
+	assert.True(t, c.Spec.Applier.ServerSideApply)
+
+	yamlData = `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  applier:
+    enabled: false
+`
+	c, err = fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.False(t, c.Spec.Applier.Enabled)
+}
+
+func TestReconcilersSpec(t *testing.T) {
+	c, err := fromYaml(t, "apiVersion: k0s.k0sproject.io/v1beta1")
+	assert.NoError(t, err)
+	assert.True(t, c.Spec.Reconcilers.IsRequired("calico"))
+	assert.True(t, c.Spec.Reconcilers.IsRequired("coredns"))
+	assert.False(t, c.Spec.Reconcilers.IsRequired("metricServer"))
+
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  reconcilers:
+    required: [coredns, metricsServer]
+`
+	c, err = fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.False(t, c.Spec.Reconcilers.IsRequired("calico"))
+	errors := c.Spec.Reconcilers.Validate()
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Error(), `unknown reconciler "metricsServer"`)
+}
+
+func TestSafeToEvict(t *testing.T) {
+	c, err := fromYaml(t, "apiVersion: k0s.k0sproject.io/v1beta1")
+	assert.NoError(t, err)
+	assert.Equal(t, "", c.Spec.SafeToEvict.CoreDNSValue())
+	assert.Equal(t, "", c.Spec.SafeToEvict.MetricsServerValue())
+
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  safeToEvict:
+    enabled: true
+    metricsServer: false
+`
+	c, err = fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.Equal(t, "true", c.Spec.SafeToEvict.CoreDNSValue())
+	assert.Equal(t, "false", c.Spec.SafeToEvict.MetricsServerValue())
+}
+
+func TestLogVerbosity(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  logVerbosity:
+    apiServer: 2
+    scheduler: 6
+    controllerManager: 11
+`
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, c.Spec.LogVerbosity.APIServer)
+	assert.Equal(t, "2", VerbosityArg(c.Spec.LogVerbosity.APIServer))
+	assert.Equal(t, "", VerbosityArg(0))
+
+	errors, warnings := SplitWarnings(c.Spec.LogVerbosity.Validate())
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "logVerbosity.controllerManager must be between 0 and 10, got 11", errors[0].Error())
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Error(), "logVerbosity.scheduler is 6")
+}
+
+func TestBindAddressValidation(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+metadata:
+  name: foobar
+spec:
+  api:
+    bindAddress: 192.0.2.1
+  storage:
+    type: etcd
+    etcd:
+      bindAddress: 127.0.0.1
+`
+
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	errors := c.Validate()
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "api.bindAddress: 192.0.2.1 is not an address of this node", errors[0].Error())
+	assert.Equal(t, "127.0.0.1", c.Spec.Storage.Etcd.GetBindAddress())
+}
+
+func TestMetricsBindAddresses(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+metadata:
+  name: foobar
+spec:
+  controllerManager:
+    bindAddress: 0.0.0.0
+  scheduler:
+    bindAddress: 192.0.2.1
+  metrics:
+    readers: [monitoring/prometheus, prometheus]
+`
+
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	errors, warnings := SplitWarnings(c.Validate())
+	assert.Len(t, errors, 2)
+	assert.Equal(t, "scheduler.bindAddress: 192.0.2.1 is not an address of this node", errors[0].Error())
+	assert.Equal(t, `metrics.readers: "prometheus" is not a namespace/name service account reference`, errors[1].Error())
+	assert.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].Error(), "controllerManager.bindAddress 0.0.0.0 exposes the metrics")
+	assert.Equal(t, "0.0.0.0", c.Spec.ControllerManager.Args()["bind-address"])
+	assert.Equal(t, []ServiceAccount{{Namespace: "monitoring", Name: "prometheus"}}, c.Spec.Metrics.ServiceAccounts())
+}
+
+func TestKubeletPreferredAddressTypes(t *testing.T) {
+	api := &APISpec{}
+	assert.Equal(t, DefaultKubeletPreferredAddressTypes, api.GetKubeletPreferredAddressTypes())
+	assert.Empty(t, api.Validate())
+
+	api.KubeletPreferredAddressTypes = []string{"ExternalIP", "InternalIP", "ExternalIP", "PublicIP"}
+	errors := api.Validate()
+	assert.Equal(t, 2, len(errors))
+	assert.Equal(t, `api.kubeletPreferredAddressTypes: duplicate address type "ExternalIP"`, errors[0].Error())
+	assert.Contains(t, errors[1].Error(), `invalid address type "PublicIP"`)
+}
+
+func TestBindAddressReachable(t *testing.T) {
+	api := &APISpec{Address: "10.0.0.1", SANs: SANList{"10.0.0.2"}}
+	assert.True(t, api.BindAddressReachable())
+
+	api.BindAddress = "0.0.0.0"
+	assert.True(t, api.BindAddressReachable())
+	api.BindAddress = "10.0.0.2"
+	assert.True(t, api.BindAddressReachable())
+	api.BindAddress = "10.0.0.3"
+	assert.False(t, api.BindAddressReachable())
+}
+
+func TestLocalAPIAddress(t *testing.T) {
+	api := &APISpec{Address: "10.0.0.1"}
+	assert.Equal(t, "https://localhost:6443", api.LocalAPIAddress())
+	api.BindAddress = "0.0.0.0"
+	assert.Equal(t, "https://localhost:6443", api.LocalAPIAddress())
+	api.BindAddress = "10.0.0.2"
+	assert.Equal(t, "https://10.0.0.2:6443", api.LocalAPIAddress())
+	api.BindAddress = "fd00::2"
+	assert.Equal(t, "https://[fd00::2]:6443", api.LocalAPIAddress())
+}
+
+func TestCertificateSANs(t *testing.T) {
+	api := &APISpec{SANs: SANList{"10.0.0.2"}}
+	sans, err := api.CertificateSANs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.2"}, sans)
+
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+	api.SANs = SANList{"10.0.0.2", hostname}
+	api.AutoSANs = true
+	sans, err = api.CertificateSANs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.2", hostname}, sans[:2])
+	assert.Len(t, sans, 3, "the hostname is not added twice")
+}
+
+func TestDefaultLimits(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+metadata:
+  name: foobar
+spec:
+  defaultLimits:
+    enabled: true
+    namespaces: [default, apps]
+    quota:
+      pods: "50"
+`
+
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+	assert.True(t, c.Spec.DefaultLimits.Enabled)
+	assert.Equal(t, []string{"default", "apps"}, c.Spec.DefaultLimits.Namespaces)
+	assert.Equal(t, "128Mi", c.Spec.DefaultLimits.DefaultRequest["memory"])
+	assert.Equal(t, "50", c.Spec.DefaultLimits.Quota["pods"])
+	assert.Len(t, c.Validate(), 0)
+
+	c.Spec.DefaultLimits.Default["cpu"] = "lots"
+	assert.Len(t, c.Validate(), 1)
+}
+
+func TestValidationErrorFieldPaths(t *testing.T) {
+	yamlData := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  network:
+    provider: invalidProvider
+  storage:
+    type: consul
+  logVerbosity:
+    scheduler: 11
+  workerProfiles:
+  - name: default
+  - name: custom
+    maxPods: -1
+telemetry:
+  interval: -1s
+`
+	c, err := fromYaml(t, yamlData)
+	assert.NoError(t, err)
+
+	var fields []string
+	var codes []ValidationErrorCode
+	errors, _ := SplitWarnings(c.Validate())
+	for _, err := range errors {
+		if fieldErr, ok := err.(FieldError); assert.True(t, ok, "%T is not a FieldError", err) {
+			fields = append(fields, fieldErr.FieldPath())
+			codes = append(codes, fieldErr.ErrorCode())
+		}
+	}
+	assert.Equal(t, []string{
+		"spec.storage.type",
+		"spec.network.provider",
+		"spec.workerProfiles[1].maxPods",
+		"spec.logVerbosity.scheduler",
+		"telemetry.interval",
+	}, fields)
+	assert.Equal(t, []ValidationErrorCode{
+		ValidationErrorUnsupported,
+		ValidationErrorUnsupported,
+		ValidationErrorInvalid,
+		ValidationErrorInvalid,
+		ValidationErrorInvalid,
+	}, codes)
+}
@@ -15,7 +15,10 @@ limitations under the License.
 */
 package v1beta1
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // CaResponse defines the reponse type for /ca control API
 type CaResponse struct {
@@ -49,3 +52,48 @@ type EtcdResponse struct {
 	CA             CaResponse `json:"ca"`
 	InitialCluster []string   `json:"initialCluster"`
 }
+
+// NodeResponse defines the response type for the /node control API
+type NodeResponse struct {
+	// NodeID is the id of the controller node, stable across restarts
+	NodeID string `json:"nodeID"`
+}
+
+// StatusResponse defines the response type for the /status API of the local control socket
+type StatusResponse struct {
+	// Role is the role of the node, controller or controller+worker
+	Role string `json:"role"`
+	// Storage is the storage backend type, or external etcd
+	Storage         string            `json:"storage"`
+	Components      []string          `json:"components"`
+	ComponentStatus map[string]string `json:"componentStatus,omitempty"`
+	// Unhealthy are the reasons by name of the components failing their health check
+	Unhealthy   map[string]string  `json:"unhealthy,omitempty"`
+	Processes   []ProcessStatus    `json:"processes"`
+	Reconcilers []ReconcilerStatus `json:"reconcilers"`
+	Preflight   []PreflightResult  `json:"preflight"`
+}
+
+// PreflightResult is the outcome of a preflight check run when the server started
+type PreflightResult struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Detail   string `json:"detail,omitempty"`
+	Severity string `json:"severity"`
+}
+
+// ProcessStatus describes a process launched by k0s, secret bearing arguments are redacted
+type ProcessStatus struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+}
+
+// ReconcilerStatus describes the outcome of the latest runs of a manifest reconciler
+type ReconcilerStatus struct {
+	Name          string        `json:"name"`
+	Interval      time.Duration `json:"interval"`
+	LastSuccess   time.Time     `json:"lastSuccess"`
+	LastError     string        `json:"lastError,omitempty"`
+	LastErrorTime time.Time     `json:"lastErrorTime"`
+	Stale         bool          `json:"stale"`
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reservedCorefilePlugins are the plugins k0s configures in the default Corefile server block
+var reservedCorefilePlugins = map[string]bool{
+	"errors":      true,
+	"health":      true,
+	"ready":       true,
+	"kubernetes":  true,
+	"prometheus":  true,
+	"forward":     true,
+	"cache":       true,
+	"loop":        true,
+	"reload":      true,
+	"loadbalance": true,
+}
+
+var corefilePluginName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// CoreDNSSpec defines additional configuration for CoreDNS
+type CoreDNSSpec struct {
+	// ExtraConfig is a Corefile snippet of plugin directives inserted into the default server block,
+	// after the ready plugin and before the kubernetes plugin
+	ExtraConfig string `yaml:"extraConfig"`
+}
+
+// DefaultCoreDNSSpec creates the CoreDNSSpec with no extra config
+func DefaultCoreDNSSpec() *CoreDNSSpec {
+	return &CoreDNSSpec{}
+}
+
+// Validate validates the extra config is made of balanced plugin directives which don't redefine any of the plugins configured by k0s
+func (c *CoreDNSSpec) Validate() []error {
+	var errors []error
+	if c == nil || strings.TrimSpace(c.ExtraConfig) == "" {
+		return errors
+	}
+
+	depth := 0
+	for i, line := range strings.Split(c.ExtraConfig, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if depth == 0 && !strings.HasPrefix(fields[0], "}") {
+			plugin := fields[0]
+			if !corefilePluginName.MatchString(plugin) {
+				errors = append(errors, newValidationError("spec.coredns.extraConfig", ValidationErrorInvalid, "coredns.extraConfig line %d: %q is not a plugin directive, server blocks are not allowed", i+1, plugin))
+			} else if reservedCorefilePlugins[plugin] {
+				errors = append(errors, newValidationError("spec.coredns.extraConfig", ValidationErrorForbidden, "coredns.extraConfig line %d: plugin %q is configured by k0s and cannot be redefined", i+1, plugin))
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth < 0 {
+			return append(errors, newValidationError("spec.coredns.extraConfig", ValidationErrorInvalid, "coredns.extraConfig line %d: unexpected closing brace", i+1))
+		}
+	}
+	if depth != 0 {
+		errors = append(errors, newValidationError("spec.coredns.extraConfig", ValidationErrorInvalid, "coredns.extraConfig has unbalanced braces"))
+	}
+
+	return errors
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoreDNSSpecValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		extraConfig string
+		errors      int
+	}{
+		{"empty", "", 0},
+		{"rewrite", "rewrite name foo.example.com foo.default.svc.cluster.local", 0},
+		{"hosts block", "hosts {\n  10.0.0.1 legacy.example.com\n  fallthrough\n}\n", 0},
+		{"comment", "# legacy hosts\nhosts /etc/coredns/hosts { # inline\n  fallthrough\n}", 0},
+		{"reserved plugin", "forward . 8.8.8.8", 1},
+		{"reserved plugin after block", "hosts {\n  fallthrough\n}\nkubernetes cluster.local", 1},
+		{"server block", "example.com:53 {\n  whoami\n}", 1},
+		{"unbalanced", "hosts {\n  fallthrough\n", 1},
+		{"extra closing brace", "whoami\n}\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &CoreDNSSpec{ExtraConfig: tt.extraConfig}
+			assert.Len(t, spec.Validate(), tt.errors)
+		})
+	}
+}
+
+func TestCoreDNSDefaults(t *testing.T) {
+	c, err := fromYaml(t, `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  coredns:
+    extraConfig: |
+      rewrite name foo.example.com foo.default.svc.cluster.local
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "rewrite name foo.example.com foo.default.svc.cluster.local\n", c.Spec.CoreDNS.ExtraConfig)
+	assert.Empty(t, c.Validate())
+}
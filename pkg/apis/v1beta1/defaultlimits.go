@@ -0,0 +1,88 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import "k8s.io/apimachinery/pkg/api/resource"
+
+// DefaultLimitsOptOutLabel is the namespace label used to opt out of the default limits,
+// namespaces labeled with value "disabled" are skipped
+const DefaultLimitsOptOutLabel = "k0s.k0sproject.io/default-limits"
+
+// DefaultLimits defines the default LimitRange and ResourceQuota installed in namespaces
+type DefaultLimits struct {
+	Enabled    bool     `yaml:"enabled"`
+	Namespaces []string `yaml:"namespaces"`
+	// DefaultRequest are the container resource requests set by the LimitRange
+	DefaultRequest map[string]string `yaml:"defaultRequest"`
+	// Default are the container resource limits set by the LimitRange
+	Default map[string]string `yaml:"default"`
+	// Quota are the hard limits of the ResourceQuota, none is created if empty
+	Quota map[string]string `yaml:"quota"`
+}
+
+// DefaultDefaultLimits creates the DefaultLimits with sane default values, disabled
+func DefaultDefaultLimits() *DefaultLimits {
+	return &DefaultLimits{
+		Enabled:    false,
+		Namespaces: []string{"default"},
+		DefaultRequest: map[string]string{
+			"cpu":    "100m",
+			"memory": "128Mi",
+		},
+		Default: map[string]string{
+			"cpu":    "500m",
+			"memory": "512Mi",
+		},
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (d *DefaultLimits) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*d = *DefaultDefaultLimits()
+
+	type ydefaultlimits DefaultLimits
+	yd := (*ydefaultlimits)(d)
+
+	if err := unmarshal(yd); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates all the resource quantities are valid
+func (d *DefaultLimits) Validate() []error {
+	var errors []error
+	if d == nil || !d.Enabled {
+		return errors
+	}
+
+	if len(d.Namespaces) == 0 {
+		errors = append(errors, newValidationError("spec.defaultLimits.namespaces", ValidationErrorRequired, "defaultLimits enabled without any namespaces"))
+	}
+	for section, values := range map[string]map[string]string{
+		"defaultRequest": d.DefaultRequest,
+		"default":        d.Default,
+		"quota":          d.Quota,
+	} {
+		for name, value := range values {
+			if _, err := resource.ParseQuantity(value); err != nil {
+				errors = append(errors, newValidationError("spec.defaultLimits."+section+"."+name, ValidationErrorInvalid, "invalid defaultLimits.%s.%s value %q: %v", section, name, value, err))
+			}
+		}
+	}
+	return errors
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import "k8s.io/apimachinery/pkg/labels"
+
+// SystemNamespaces are the namespaces of the cluster components, never given the default network policies
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// DefaultNetworkPolicy defines the default-deny NetworkPolicies installed in the workload namespaces
+type DefaultNetworkPolicy struct {
+	Enabled bool `yaml:"enabled"`
+	// NamespaceSelector is the label selector of the namespaces given the policies, all namespaces if empty
+	NamespaceSelector string `yaml:"namespaceSelector"`
+	// ExcludedNamespaces are left without the policies, on top of the system namespaces
+	ExcludedNamespaces []string `yaml:"excludedNamespaces"`
+	// Ingress denies all ingress traffic of the pods not allowed by other policies
+	Ingress bool `yaml:"ingress"`
+	// Egress denies all egress traffic of the pods not allowed by other policies
+	Egress bool `yaml:"egress"`
+	// AllowDNS keeps the egress to the cluster DNS open, without it denying egress breaks name resolution
+	AllowDNS bool `yaml:"allowDNS"`
+}
+
+// DefaultDefaultNetworkPolicy creates the DefaultNetworkPolicy denying ingress and egress except DNS, disabled
+func DefaultDefaultNetworkPolicy() *DefaultNetworkPolicy {
+	return &DefaultNetworkPolicy{
+		Enabled:  false,
+		Ingress:  true,
+		Egress:   true,
+		AllowDNS: true,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (d *DefaultNetworkPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*d = *DefaultDefaultNetworkPolicy()
+
+	type ydefaultnetworkpolicy DefaultNetworkPolicy
+	return unmarshal((*ydefaultnetworkpolicy)(d))
+}
+
+// Validate validates the namespace selector and that the policies deny something
+func (d *DefaultNetworkPolicy) Validate() []error {
+	var errors []error
+	if d == nil || !d.Enabled {
+		return errors
+	}
+	if _, err := labels.Parse(d.NamespaceSelector); err != nil {
+		errors = append(errors, newValidationError("spec.network.defaultNetworkPolicy.namespaceSelector", ValidationErrorInvalid, "network.defaultNetworkPolicy.namespaceSelector: %s", err.Error()))
+	}
+	if !d.Ingress && !d.Egress {
+		errors = append(errors, newValidationError("spec.network.defaultNetworkPolicy", ValidationErrorInvalid, "network.defaultNetworkPolicy enabled without denying ingress nor egress"))
+	}
+	return errors
+}
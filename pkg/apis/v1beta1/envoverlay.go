@@ -0,0 +1,208 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// EnvOverlayPrefix is the prefix of the environment variables overriding config fields, e.g. K0S_SPEC_API_ADDRESS
+// overrides spec.api.address
+const EnvOverlayPrefix = "K0S_"
+
+// EnvOverride is a config field overridden by an environment variable
+type EnvOverride struct {
+	// Key is the environment variable
+	Key string
+	// Path is the yaml path of the overridden field, e.g. spec.api.address
+	Path string
+}
+
+// ApplyEnvOverlay overrides the config fields named by the K0S_ prefixed variables of the given environment, in the
+// os.Environ() format. The path of a field is made of the yaml names of it and its parents, either in upper snake
+// case or just upper cased: K0S_SPEC_CONTROLLER_MANAGER_BIND_ADDRESS and K0S_SPEC_CONTROLLERMANAGER_BINDADDRESS both
+// set spec.controllerManager.bindAddress. Values are coerced to the type of the field, lists are comma separated.
+// The applied overrides and the variables not naming any field are returned, a value which can't be coerced is an
+// error.
+func (c *ClusterConfig) ApplyEnvOverlay(environ []string) (overrides []EnvOverride, unknown []string, err error) {
+	sorted := append([]string{}, environ...)
+	sort.Strings(sorted)
+
+	for _, entry := range sorted {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], EnvOverlayPrefix) || isReservedEnvVar(parts[0]) {
+			continue
+		}
+		key, value := parts[0], parts[1]
+		path, found, err := setEnvField(reflect.ValueOf(c).Elem(), strings.TrimPrefix(key, EnvOverlayPrefix), value, "")
+		if err != nil {
+			return overrides, unknown, errors.Wrapf(err, "invalid %s", key)
+		}
+		if !found {
+			unknown = append(unknown, key)
+			continue
+		}
+		overrides = append(overrides, EnvOverride{Key: key, Path: path})
+	}
+
+	// the storage type may have been switched without giving the config of the new type
+	if c.Spec != nil && c.Spec.Storage != nil {
+		c.Spec.Storage.setTypeDefaults()
+	}
+	return overrides, unknown, nil
+}
+
+// isReservedEnvVar tells if the variable is one of constant.ReservedEnvVars, which the commands also leave out along
+// with the other variables of their flags
+func isReservedEnvVar(key string) bool {
+	for _, reserved := range constant.ReservedEnvVars {
+		if key == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnvField sets the field of the struct named by the key to the value, returning the yaml path of the field and
+// whether the key named a field at all
+func setEnvField(v reflect.Value, key string, value string, parent string) (string, bool, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		path := name
+		if parent != "" {
+			path = parent + "." + name
+		}
+		field := v.Field(i)
+
+		for _, envName := range envNames(name) {
+			if key == envName {
+				return path, true, setEnvValue(field, value, path)
+			}
+			if !strings.HasPrefix(key, envName+"_") {
+				continue
+			}
+			rest := strings.TrimPrefix(key, envName+"_")
+			switch {
+			case field.Kind() == reflect.Struct:
+				if fieldPath, found, err := setEnvField(field, rest, value, path); found {
+					return fieldPath, found, err
+				}
+			case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct:
+				// an unset section is only created if the key names one of its fields
+				section := field
+				if field.IsNil() {
+					section = reflect.New(field.Type().Elem())
+				}
+				if fieldPath, found, err := setEnvField(section.Elem(), rest, value, path); found {
+					if err == nil && field.IsNil() {
+						field.Set(section)
+					}
+					return fieldPath, found, err
+				}
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// envNames returns the upper snake case and the upper case variants of the yaml name, e.g. CONTROLLER_MANAGER and
+// CONTROLLERMANAGER for controllerManager
+func envNames(name string) []string {
+	var snake strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			snake.WriteRune('_')
+		}
+		snake.WriteRune(unicode.ToUpper(r))
+	}
+	upper := strings.ToUpper(name)
+	if snake.String() == upper {
+		return []string{upper}
+	}
+	return []string{snake.String(), upper}
+}
+
+// setEnvValue coerces the value to the type of the field and sets it
+func setEnvValue(field reflect.Value, value string, path string) error {
+	if field.Kind() == reflect.Ptr {
+		target := reflect.New(field.Type().Elem())
+		if err := setEnvValue(target.Elem(), value, path); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s expects a boolean, got %q", path, value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s expects a duration, got %q", path, value)
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s expects an integer, got %q", path, value)
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s expects a non-negative integer, got %q", path, value)
+		}
+		field.SetUint(u)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s can't be set from the environment", path)
+		}
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = reflect.Append(items, reflect.ValueOf(item).Convert(field.Type().Elem()))
+			}
+		}
+		field.Set(items)
+	default:
+		return fmt.Errorf("%s can't be set from the environment, set its fields instead", path)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEnvOverlay(t *testing.T) {
+	c := DefaultClusterConfig()
+	overrides, unknown, err := c.ApplyEnvOverlay([]string{
+		"K0S_SPEC_API_ADDRESS=10.0.0.10",
+		"K0S_SPEC_API_SANS=api.example.com, 10.0.0.10",
+		"K0S_SPEC_STORAGE_TYPE=kine",
+		"K0S_SPEC_CONTROLLERMANAGER_BINDADDRESS=0.0.0.0",
+		"K0S_SPEC_API_WATCH_CACHE_DEFAULT_SIZE=50",
+		"K0S_TELEMETRY_ENABLED=false",
+		"K0S_TELEMETRY_INTERVAL=1h",
+		"K0S_SKIP_KERNEL_SETUP=true",
+		"K0S_DATA_DIR=/var/lib/k0s",
+		"K0S_DISABLE_TELEMETRY=true",
+		"K0S_SPEC_API_ADRESS=10.0.0.11",
+		"HOME=/root",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "10.0.0.10", c.Spec.API.Address)
+	assert.Equal(t, SANList{"api.example.com", "10.0.0.10"}, c.Spec.API.SANs)
+	assert.Equal(t, KineStorageType, c.Spec.Storage.Type)
+	assert.NotNil(t, c.Spec.Storage.Kine)
+	assert.Equal(t, "0.0.0.0", c.Spec.ControllerManager.BindAddress)
+	require.NotNil(t, c.Spec.API.WatchCache)
+	require.NotNil(t, c.Spec.API.WatchCache.DefaultSize)
+	assert.Equal(t, 50, *c.Spec.API.WatchCache.DefaultSize)
+	assert.False(t, c.Telemetry.Enabled)
+	assert.Equal(t, time.Hour, c.Telemetry.Interval)
+
+	assert.Contains(t, overrides, EnvOverride{Key: "K0S_SPEC_API_ADDRESS", Path: "spec.api.address"})
+	assert.Contains(t, overrides, EnvOverride{Key: "K0S_SPEC_API_WATCH_CACHE_DEFAULT_SIZE", Path: "spec.api.watchCache.defaultSize"})
+	assert.Len(t, overrides, 7)
+	assert.Equal(t, []string{"K0S_SPEC_API_ADRESS"}, unknown)
+}
+
+func TestApplyEnvOverlayInvalidValues(t *testing.T) {
+	for _, env := range []string{
+		"K0S_TELEMETRY_ENABLED=maybe",
+		"K0S_TELEMETRY_INTERVAL=10",
+		"K0S_SPEC_API_WATCH_CACHE_DEFAULT_SIZE=many",
+		"K0S_SPEC_API=10.0.0.10",
+		"K0S_SPEC_API_EXTRA_ARGS=foo=bar",
+	} {
+		_, _, err := DefaultClusterConfig().ApplyEnvOverlay([]string{env})
+		assert.Error(t, err, env)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import "fmt"
+
+// MutuallyExclusiveError is returned when two config options can't be used together
+type MutuallyExclusiveError struct {
+	Field      string
+	OtherField string
+	Reason     string
+}
+
+func (e *MutuallyExclusiveError) Error() string {
+	return fmt.Sprintf("%s and %s can't be used together: %s", e.Field, e.OtherField, e.Reason)
+}
+
+// FieldPath implements FieldError
+func (e *MutuallyExclusiveError) FieldPath() string {
+	return "spec." + e.Field
+}
+
+// ErrorCode implements FieldError
+func (e *MutuallyExclusiveError) ErrorCode() ValidationErrorCode {
+	return ValidationErrorForbidden
+}
+
+// validateExclusions checks the options that are valid on their own but conflict with each other
+func (s *ClusterSpec) validateExclusions() []error {
+	var errors []error
+	if s.Storage != nil {
+		if s.Storage.Type == KineStorageType && s.Storage.Etcd != nil && s.Storage.Etcd.ExternalCluster != nil {
+			errors = append(errors, &MutuallyExclusiveError{
+				Field:      "storage.etcd.externalCluster",
+				OtherField: "storage.type kine",
+				Reason:     "the external etcd cluster is only used with the etcd storage type",
+			})
+		}
+		if s.Storage.Type == EtcdStorageType && s.Storage.Kine != nil {
+			errors = append(errors, &MutuallyExclusiveError{
+				Field:      "storage.kine",
+				OtherField: "storage.type etcd",
+				Reason:     "kine is only run with the kine storage type",
+			})
+		}
+	}
+	if n := s.Network; n != nil && n.KubeRouter != nil && n.KubeRouter.ServiceProxy && n.Provider != "kuberouter" {
+		errors = append(errors, &MutuallyExclusiveError{
+			Field:      "network.kuberouter.serviceProxy",
+			OtherField: fmt.Sprintf("network.provider %s", n.Provider),
+			Reason:     "only kube-router replaces kube-proxy, the other providers need it",
+		})
+	}
+	if a := s.Applier; a != nil && a.ForceConflicts && !a.ServerSideApply {
+		errors = append(errors, &MutuallyExclusiveError{
+			Field:      "applier.forceConflicts",
+			OtherField: "applier.serverSideApply false",
+			Reason:     "conflicts only exist with server-side apply",
+		})
+	}
+	return errors
+}
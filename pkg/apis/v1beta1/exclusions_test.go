@@ -0,0 +1,110 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutuallyExclusiveOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		field      string
+		otherField string
+	}{
+		{
+			name: "external etcd with kine",
+			spec: `
+  storage:
+    type: kine
+    etcd:
+      externalCluster:
+        endpoints: [https://etcd.example.com:2379]
+`,
+			field:      "storage.etcd.externalCluster",
+			otherField: "storage.type kine",
+		},
+		{
+			name: "kine with etcd",
+			spec: `
+  storage:
+    type: etcd
+    kine:
+      dataSource: sqlite:///var/lib/k0s/db/state.db
+`,
+			field:      "storage.kine",
+			otherField: "storage.type etcd",
+		},
+		{
+			name: "kube-router service proxy with calico",
+			spec: `
+  network:
+    provider: calico
+    kuberouter:
+      serviceProxy: true
+`,
+			field:      "network.kuberouter.serviceProxy",
+			otherField: "network.provider calico",
+		},
+		{
+			name: "force conflicts without server-side apply",
+			spec: `
+  applier:
+    forceConflicts: true
+`,
+			field:      "applier.forceConflicts",
+			otherField: "applier.serverSideApply false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := fromYaml(t, "apiVersion: k0s.k0sproject.io/v1beta1\nkind: Cluster\nspec:"+tt.spec)
+			assert.NoError(t, err)
+			errors, _ := SplitWarnings(c.Validate())
+			if assert.Len(t, errors, 1) && assert.IsType(t, &MutuallyExclusiveError{}, errors[0]) {
+				err := errors[0].(*MutuallyExclusiveError)
+				assert.Equal(t, tt.field, err.Field)
+				assert.Equal(t, tt.otherField, err.OtherField)
+				assert.Contains(t, err.Error(), tt.field)
+				assert.Contains(t, err.Error(), tt.otherField)
+			}
+		})
+	}
+
+	t.Run("valid combinations", func(t *testing.T) {
+		c, err := fromYaml(t, `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  storage:
+    type: kine
+    kine:
+      dataSource: sqlite:///var/lib/k0s/db/state.db
+  network:
+    provider: kuberouter
+    kuberouter:
+      serviceProxy: true
+  applier:
+    serverSideApply: true
+    forceConflicts: true
+`)
+		assert.NoError(t, err)
+		assert.Empty(t, c.Spec.validateExclusions())
+	})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+)
+
+// ExternalCluster defines an etcd cluster managed outside of k0s, which the API server connects to instead of an
+// etcd member run by k0s
+type ExternalCluster struct {
+	// Endpoints are the client URLs of the etcd members, e.g. https://etcd-1:2379
+	Endpoints []string `yaml:"endpoints"`
+	// CAFile is the CA bundle verifying the etcd server certificates, the system trust store if not set
+	CAFile string `yaml:"caFile"`
+	// ClientCertFile is the certificate the API server authenticates to etcd with
+	ClientCertFile string `yaml:"clientCertFile"`
+	// ClientKeyFile is the key of ClientCertFile
+	ClientKeyFile string `yaml:"clientKeyFile"`
+}
+
+// IsExternalClusterUsed returns true if k0s connects to an external etcd cluster instead of running a member
+func (e *EtcdConfig) IsExternalClusterUsed() bool {
+	return e != nil && e.ExternalCluster != nil && len(e.ExternalCluster.Endpoints) > 0
+}
+
+// validateExternalCluster checks the endpoints and files of the external cluster, and that none of the options of
+// the k0s managed etcd members are set along with it
+func (e *EtcdConfig) validateExternalCluster() []error {
+	if e.ExternalCluster == nil {
+		return nil
+	}
+	var errors []error
+	if len(e.ExternalCluster.Endpoints) == 0 {
+		errors = append(errors, newValidationError("spec.storage.etcd.externalCluster.endpoints", ValidationErrorRequired, "storage.etcd.externalCluster.endpoints: at least one endpoint is required"))
+	}
+	for i, endpoint := range e.ExternalCluster.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, newValidationError(fmt.Sprintf("spec.storage.etcd.externalCluster.endpoints[%d]", i), ValidationErrorInvalid, "storage.etcd.externalCluster.endpoints[%d]: %q is not a http or https URL", i, endpoint))
+		}
+	}
+	for field, path := range map[string]string{
+		"caFile":         e.ExternalCluster.CAFile,
+		"clientCertFile": e.ExternalCluster.ClientCertFile,
+		"clientKeyFile":  e.ExternalCluster.ClientKeyFile,
+	} {
+		if path != "" && !filepath.IsAbs(path) {
+			errors = append(errors, newValidationError("spec.storage.etcd.externalCluster."+field, ValidationErrorInvalid, "storage.etcd.externalCluster.%s must be an absolute path, got %q", field, path))
+		}
+	}
+	if (e.ExternalCluster.ClientCertFile == "") != (e.ExternalCluster.ClientKeyFile == "") {
+		errors = append(errors, newValidationError("spec.storage.etcd.externalCluster", ValidationErrorRequired, "storage.etcd.externalCluster: clientCertFile and clientKeyFile must be set together"))
+	}
+
+	// peerAddress is always defaulted, so it can't be told apart from a configured one and is ignored instead
+	managed := []struct {
+		field string
+		set   bool
+	}{
+		{"bindAddress", e.BindAddress != ""},
+		{"dataDir", e.DataDir != ""},
+		{"autoCompactionMode", e.AutoCompactionMode != ""},
+		{"autoCompactionRetention", e.AutoCompactionRetention != ""},
+		{"maxClockSkew", e.MaxClockSkew != 0 && e.MaxClockSkew != DefaultEtcdMaxClockSkew},
+		{"peerPort", e.PeerPort != 0},
+		{"clientPort", e.ClientPort != 0},
+	}
+	for _, m := range managed {
+		if m.set {
+			errors = append(errors, newValidationError("spec.storage.etcd."+m.field, ValidationErrorForbidden, "storage.etcd.%s applies to the etcd members run by k0s and can't be set with an externalCluster", m.field))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// HostAlias maps hostnames to an IP address for the components, without relying on DNS
+type HostAlias struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
+}
+
+// HostAliases is the list of host aliases written to the hosts file of the node
+type HostAliases []HostAlias
+
+// Validate validates each alias is a valid IP address with at least one valid hostname
+func (h HostAliases) Validate() []error {
+	var errors []error
+	for i, alias := range h {
+		if net.ParseIP(alias.IP) == nil {
+			errors = append(errors, newValidationError(fmt.Sprintf("spec.hostAliases[%d].ip", i), ValidationErrorInvalid, "hostAliases[%d]: invalid IP address %q", i, alias.IP))
+		}
+		if len(alias.Hostnames) == 0 {
+			errors = append(errors, newValidationError(fmt.Sprintf("spec.hostAliases[%d].hostnames", i), ValidationErrorRequired, "hostAliases[%d]: no hostnames given for %s", i, alias.IP))
+		}
+		for _, hostname := range alias.Hostnames {
+			if msgs := validation.IsDNS1123Subdomain(hostname); len(msgs) > 0 {
+				errors = append(errors, newValidationError(fmt.Sprintf("spec.hostAliases[%d].hostnames", i), ValidationErrorInvalid, "hostAliases[%d]: invalid hostname %q: %s", i, hostname, strings.Join(msgs, ", ")))
+			}
+		}
+	}
+	return errors
+}
+
+// HostsFileEntries returns the aliases formatted as hosts file lines
+func (h HostAliases) HostsFileEntries() []string {
+	var entries []string
+	for _, alias := range h {
+		entries = append(entries, fmt.Sprintf("%s\t%s", alias.IP, strings.Join(alias.Hostnames, " ")))
+	}
+	return entries
+}
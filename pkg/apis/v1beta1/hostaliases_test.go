@@ -0,0 +1,39 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostAliases(t *testing.T) {
+	c, err := fromYaml(t, `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  hostAliases:
+  - ip: 10.0.0.5
+    hostnames: [oidc.internal, sso.internal]
+  - ip: not-an-ip
+    hostnames: [under_score]
+  - ip: fd00::5
+`)
+	assert.NoError(t, err)
+	assert.Len(t, c.Spec.HostAliases.Validate(), 3)
+	assert.Equal(t, "10.0.0.5\toidc.internal sso.internal", c.Spec.HostAliases.HostsFileEntries()[0])
+}
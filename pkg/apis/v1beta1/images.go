@@ -40,7 +40,9 @@ type ClusterImages struct {
 	KubeProxy     ImageSpec `yaml:"kubeproxy"`
 	CoreDNS       ImageSpec `yaml:"coredns"`
 
-	Calico CalicoImageSpec `yaml:"calico"`
+	Calico     CalicoImageSpec     `yaml:"calico"`
+	Cilium     CiliumImageSpec     `yaml:"cilium"`
+	KubeRouter KubeRouterImageSpec `yaml:"kuberouter"`
 
 	Repository string `yaml:"repository"`
 }
@@ -71,6 +73,10 @@ func (ci *ClusterImages) overrideImageRepositories() {
 	override(&ci.Calico.FlexVolume)
 	override(&ci.Calico.Node)
 	override(&ci.Calico.KubeControllers)
+	override(&ci.Cilium.Agent)
+	override(&ci.Cilium.Operator)
+	override(&ci.KubeRouter.CNI)
+	override(&ci.KubeRouter.CNIInstaller)
 }
 
 // CalicoImageSpec config group for calico related image settings
@@ -81,6 +87,18 @@ type CalicoImageSpec struct {
 	KubeControllers ImageSpec `yaml:"kubecontrollers"`
 }
 
+// CiliumImageSpec config group for cilium related image settings
+type CiliumImageSpec struct {
+	Agent    ImageSpec `yaml:"agent"`
+	Operator ImageSpec `yaml:"operator"`
+}
+
+// KubeRouterImageSpec config group for kube-router related image settings
+type KubeRouterImageSpec struct {
+	CNI          ImageSpec `yaml:"cni"`
+	CNIInstaller ImageSpec `yaml:"cniInstaller"`
+}
+
 // DefaultClusterImages default image settings
 func DefaultClusterImages() *ClusterImages {
 	return &ClusterImages{
@@ -118,6 +136,26 @@ func DefaultClusterImages() *ClusterImages {
 				Version: constant.KubeControllerImageVersion,
 			},
 		},
+		Cilium: CiliumImageSpec{
+			Agent: ImageSpec{
+				Image:   constant.CiliumImage,
+				Version: constant.CiliumImageVersion,
+			},
+			Operator: ImageSpec{
+				Image:   constant.CiliumOperatorImage,
+				Version: constant.CiliumOperatorImageVersion,
+			},
+		},
+		KubeRouter: KubeRouterImageSpec{
+			CNI: ImageSpec{
+				Image:   constant.KubeRouterCNIImage,
+				Version: constant.KubeRouterCNIImageVersion,
+			},
+			CNIInstaller: ImageSpec{
+				Image:   constant.KubeRouterCNIInstallerImage,
+				Version: constant.KubeRouterCNIInstallerImageVersion,
+			},
+		},
 	}
 }
 
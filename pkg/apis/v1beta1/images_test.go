@@ -46,6 +46,10 @@ func TestImagesRepoOverrideInConfiguration(t *testing.T) {
 			require.Equal(t, fmt.Sprintf("my.repo/calico/pod2daemon-flexvol:%s", constant.FlexVolumeImageVersion), testingConfig.Images.Calico.FlexVolume.URI())
 			require.Equal(t, fmt.Sprintf("my.repo/calico/node:%s", constant.CalicoNodeImageVersion), testingConfig.Images.Calico.Node.URI())
 			require.Equal(t, fmt.Sprintf("my.repo/calico/kube-controllers:%s", constant.KubeControllerImageVersion), testingConfig.Images.Calico.KubeControllers.URI())
+			require.Equal(t, fmt.Sprintf("my.repo/cilium/cilium:%s", constant.CiliumImageVersion), testingConfig.Images.Cilium.Agent.URI())
+			require.Equal(t, fmt.Sprintf("my.repo/cilium/operator-generic:%s", constant.CiliumOperatorImageVersion), testingConfig.Images.Cilium.Operator.URI())
+			require.Equal(t, fmt.Sprintf("my.repo/cloudnativelabs/kube-router:%s", constant.KubeRouterCNIImageVersion), testingConfig.Images.KubeRouter.CNI.URI())
+			require.Equal(t, fmt.Sprintf("my.repo/k0sproject/cni-node:%s", constant.KubeRouterCNIInstallerImageVersion), testingConfig.Images.KubeRouter.CNIInstaller.URI())
 		})
 		t.Run("config_with_custom_images", func(t *testing.T) {
 			cfg := DefaultClusterConfig()
@@ -22,8 +22,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/k0sproject/k0s/pkg/token"
 	"github.com/pkg/errors"
@@ -35,15 +39,21 @@ import (
 type JoinClient struct {
 	joinAddress string
 	httpClient  http.Client
+	tlsConfig   *tls.Config
 	bearerToken string
+	role        string
 }
 
-// JoinClientFromToken creates a new join api client from a token
+// JoinClientFromToken creates a new join api client from a token. A *token.ExpiredError is returned for
+// a token past its expiry.
 func JoinClientFromToken(encodedToken string) (*JoinClient, error) {
 	tokenBytes, err := token.JoinDecode(encodedToken)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to decode token")
 	}
+	if err := token.CheckExpiry(tokenBytes, time.Now()); err != nil {
+		return nil, err
+	}
 
 	clientConfig, err := clientcmd.NewClientConfigFromBytes(tokenBytes)
 	if err != nil {
@@ -53,6 +63,10 @@ func JoinClientFromToken(encodedToken string) (*JoinClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	rawConfig, err := clientConfig.RawConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	ca := x509.NewCertPool()
 	ca.AppendCertsFromPEM(config.CAData)
@@ -63,13 +77,90 @@ func JoinClientFromToken(encodedToken string) (*JoinClient, error) {
 	tr := &http.Transport{TLSClientConfig: tlsConfig}
 	c := &JoinClient{
 		httpClient:  http.Client{Transport: tr},
+		tlsConfig:   tlsConfig,
 		bearerToken: config.BearerToken,
+		role:        token.Role(&rawConfig),
 	}
 	c.joinAddress = config.Host
 	logrus.Info("initialized join client succesfully")
 	return c, nil
 }
 
+// Role returns the role the token joins nodes as, token.RoleWorker or token.RoleController, empty for a token not
+// created by k0s
+func (j *JoinClient) Role() string {
+	return j.role
+}
+
+// TokenID returns the public ID part of the bootstrap token
+func (j *JoinClient) TokenID() string {
+	return strings.SplitN(j.bearerToken, ".", 2)[0]
+}
+
+// rejectedError explains the join API refusing the token, which doesn't tell an invalidated token from an unknown one
+func (j *JoinClient) rejectedError() error {
+	return fmt.Errorf("the controller refused join token %s, it may have been invalidated or deleted, create a new one with k0s token create", j.TokenID())
+}
+
+// Address returns the address of the join API
+func (j *JoinClient) Address() string {
+	return j.joinAddress
+}
+
+// WithAddress returns a copy of the client calling the join API at the given address, giving up on each
+// request after the timeout
+func (j *JoinClient) WithAddress(address string, timeout time.Duration) *JoinClient {
+	c := *j
+	c.joinAddress = address
+	c.httpClient.Timeout = timeout
+	return &c
+}
+
+// Handshake connects to the join API and verifies its certificate against the CA of the token, without
+// sending any request. It returns the certificate of the server.
+func (j *JoinClient) Handshake(timeout time.Duration) (*x509.Certificate, error) {
+	u, err := url.Parse(j.joinAddress)
+	if err != nil {
+		return nil, err
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "443")
+	}
+	tlsConfig := j.tlsConfig.Clone()
+	tlsConfig.ServerName = u.Hostname()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+// CheckToken calls the API server discovery with the token, which is only allowed for authenticated users
+func (j *JoinClient) CheckToken() error {
+	req, err := http.NewRequest(http.MethodGet, j.joinAddress+"/api", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", j.bearerToken))
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("the token was rejected, it might have expired or been deleted")
+	default:
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+}
+
 // GetCA calls the CA sync API
 func (j *JoinClient) GetCA() (CaResponse, error) {
 	var caData CaResponse
@@ -85,6 +176,9 @@ func (j *JoinClient) GetCA() (CaResponse, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return caData, j.rejectedError()
+	}
 	if resp.StatusCode != http.StatusOK {
 		return caData, fmt.Errorf("unexpected response status: %s", resp.Status)
 	}
@@ -103,6 +197,41 @@ func (j *JoinClient) GetCA() (CaResponse, error) {
 	return caData, nil
 }
 
+// ClockSkew estimates the clock difference between this node and the join API server using the Date header of its response.
+// The result is the smallest difference consistent with the one second resolution of the header and the request round trip,
+// zero if the clocks may well be in sync.
+func (j *JoinClient) ClockSkew() (time.Duration, error) {
+	req, err := http.NewRequest(http.MethodHead, j.joinAddress, nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+	resp.Body.Close()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, errors.Wrap(err, "join API response has no valid Date header")
+	}
+	return clockSkew(sent, received, serverTime), nil
+}
+
+// clockSkew calculates the minimal clock offset of a server whose Date header, truncated to seconds,
+// was created between sent and received
+func clockSkew(sent, received, serverTime time.Time) time.Duration {
+	if serverTime.After(received) {
+		return serverTime.Sub(received)
+	}
+	if latest := serverTime.Add(time.Second); latest.Before(sent) {
+		return latest.Sub(sent)
+	}
+	return 0
+}
+
 // JoinEtcd calls the etcd join API
 func (j *JoinClient) JoinEtcd(peerAddress string) (EtcdResponse, error) {
 	var etcdResponse EtcdResponse
@@ -130,6 +259,9 @@ func (j *JoinClient) JoinEtcd(peerAddress string) (EtcdResponse, error) {
 		return etcdResponse, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return etcdResponse, j.rejectedError()
+	}
 	if resp.StatusCode != http.StatusOK {
 		return etcdResponse, fmt.Errorf("unexpected response status when trying to join etcd cluster: %s", resp.Status)
 	}
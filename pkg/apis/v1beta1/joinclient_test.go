@@ -0,0 +1,81 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSkew(t *testing.T) {
+	sent := time.Date(2020, 11, 2, 10, 0, 0, 300000000, time.UTC)
+	received := sent.Add(200 * time.Millisecond)
+
+	tests := []struct {
+		name       string
+		serverTime time.Time
+		want       time.Duration
+	}{
+		{"in sync", time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC), 0},
+		{"server ahead", time.Date(2020, 11, 2, 10, 0, 5, 0, time.UTC), 4500 * time.Millisecond},
+		{"server behind", time.Date(2020, 11, 2, 9, 59, 55, 0, time.UTC), -4300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, clockSkew(sent, received, tt.serverTime))
+		})
+	}
+}
+
+func TestJoinClientChecks(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abcdef.0123456789abcdef" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	c := &JoinClient{
+		joinAddress: srv.URL,
+		httpClient:  *srv.Client(),
+		tlsConfig:   &tls.Config{RootCAs: roots},
+		bearerToken: "abcdef.0123456789abcdef",
+	}
+
+	cert, err := c.Handshake(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, srv.Certificate().SerialNumber, cert.SerialNumber)
+	assert.NoError(t, c.CheckToken())
+
+	c.bearerToken = "abcdef.expired"
+	assert.Error(t, c.CheckToken())
+
+	untrusted := c.WithAddress(srv.URL, time.Second)
+	untrusted.tlsConfig = &tls.Config{RootCAs: x509.NewCertPool()}
+	_, err = untrusted.Handshake(time.Second)
+	var unknownAuthority x509.UnknownAuthorityError
+	assert.True(t, errors.As(err, &unknownAuthority))
+}
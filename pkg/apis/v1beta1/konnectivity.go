@@ -0,0 +1,66 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+const (
+	// KonnectivityModeProxy tunnels the apiserver traffic to the cluster network through konnectivity
+	KonnectivityModeProxy = "proxy"
+	// KonnectivityModeDirect lets the apiserver connect directly to the cluster network, konnectivity is not deployed
+	KonnectivityModeDirect = "direct"
+)
+
+// KonnectivitySpec defines how the apiserver reaches the cluster network
+type KonnectivitySpec struct {
+	Mode string `yaml:"mode"`
+}
+
+// DefaultKonnectivitySpec creates the KonnectivitySpec with the konnectivity tunnel in use
+func DefaultKonnectivitySpec() *KonnectivitySpec {
+	return &KonnectivitySpec{
+		Mode: KonnectivityModeProxy,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (k *KonnectivitySpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*k = *DefaultKonnectivitySpec()
+
+	type ykonnectivity KonnectivitySpec
+	yk := (*ykonnectivity)(k)
+
+	if err := unmarshal(yk); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the konnectivity mode
+func (k *KonnectivitySpec) Validate() []error {
+	var errors []error
+	if k == nil {
+		return errors
+	}
+	if k.Mode != KonnectivityModeProxy && k.Mode != KonnectivityModeDirect {
+		errors = append(errors, newValidationError("spec.konnectivity.mode", ValidationErrorUnsupported, "unsupported konnectivity mode %q, must be one of %s, %s", k.Mode, KonnectivityModeProxy, KonnectivityModeDirect))
+	}
+	return errors
+}
+
+// IsDirect returns true if the apiserver connects to the cluster network without the konnectivity tunnel
+func (k *KonnectivitySpec) IsDirect() bool {
+	return k != nil && k.Mode == KonnectivityModeDirect
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKonnectivitySpec(t *testing.T) {
+	t.Run("defaults to proxy", func(t *testing.T) {
+		c, err := fromYaml(t, `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  konnectivity: {}
+`)
+		assert.NoError(t, err)
+		assert.Equal(t, KonnectivityModeProxy, c.Spec.Konnectivity.Mode)
+		assert.False(t, c.Spec.Konnectivity.IsDirect())
+	})
+
+	t.Run("direct", func(t *testing.T) {
+		c, err := fromYaml(t, `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+spec:
+  konnectivity:
+    mode: direct
+`)
+		assert.NoError(t, err)
+		assert.True(t, c.Spec.Konnectivity.IsDirect())
+		assert.Empty(t, c.Validate())
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		k := &KonnectivitySpec{Mode: "tunnel"}
+		assert.Len(t, k.Validate(), 1)
+	})
+}
@@ -0,0 +1,36 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+// KubeletCertificates defines the rotation of the kubelet certificates. The client certificates the kubelets
+// authenticate to the API server with are always rotated.
+type KubeletCertificates struct {
+	// RotateServerCertificates makes the kubelets request their serving certificates from the cluster CA, the kubelets
+	// use self-signed serving certificates otherwise
+	RotateServerCertificates bool `yaml:"rotateServerCertificates"`
+}
+
+// DefaultKubeletCertificates creates the KubeletCertificates with the self-signed kubelet serving certificates
+func DefaultKubeletCertificates() *KubeletCertificates {
+	return &KubeletCertificates{
+		RotateServerCertificates: false,
+	}
+}
+
+// ServerTLSBootstrap returns true if the kubelets should bootstrap and rotate their serving certificates
+func (k *KubeletCertificates) ServerTLSBootstrap() bool {
+	return k != nil && k.RotateServerCertificates
+}
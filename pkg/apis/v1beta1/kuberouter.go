@@ -0,0 +1,52 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+// KubeRouter defines the kube-router related config options
+type KubeRouter struct {
+	// MTU of the pod network, detected from the node interfaces if zero
+	MTU int `yaml:"mtu"`
+	// ServiceProxy makes kube-router handle the services too, replacing kube-proxy
+	ServiceProxy bool `yaml:"serviceProxy"`
+}
+
+// DefaultKubeRouter returns the kube-router config detecting the MTU and leaving the services to kube-proxy
+func DefaultKubeRouter() *KubeRouter {
+	return &KubeRouter{
+		MTU:          0,
+		ServiceProxy: false,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (k *KubeRouter) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*k = *DefaultKubeRouter()
+
+	type ykuberouter KubeRouter
+	return unmarshal((*ykuberouter)(k))
+}
+
+// Validate validates the MTU
+func (k *KubeRouter) Validate() []error {
+	var errors []error
+	if k == nil {
+		return errors
+	}
+	if k.MTU < 0 {
+		errors = append(errors, newValidationError("spec.network.kuberouter.mtu", ValidationErrorInvalid, "network.kuberouter.mtu cannot be negative, got %d", k.MTU))
+	}
+	return errors
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MaxLogVerbosity is the highest klog verbosity level
+const MaxLogVerbosity = 10
+
+// HighLogVerbosity is the verbosity above which the components log at debug level, e.g. the API requests
+const HighLogVerbosity = 4
+
+// LogVerbosity defines the log verbosity, i.e. the --v level, of the control plane components, the component default if zero
+type LogVerbosity struct {
+	APIServer         int `yaml:"apiServer"`
+	Scheduler         int `yaml:"scheduler"`
+	ControllerManager int `yaml:"controllerManager"`
+}
+
+// Validate validates the verbosity levels are within range, the result includes warnings about high verbosity
+func (l *LogVerbosity) Validate() []error {
+	if l == nil {
+		return nil
+	}
+	var errors []error
+	for _, v := range []struct {
+		field string
+		level int
+	}{
+		{"logVerbosity.apiServer", l.APIServer},
+		{"logVerbosity.scheduler", l.Scheduler},
+		{"logVerbosity.controllerManager", l.ControllerManager},
+	} {
+		if err := ValidateLogVerbosity(v.field, v.level); err != nil {
+			if ve, ok := err.(*ValidationError); ok {
+				ve.Field = "spec." + ve.Field
+			}
+			errors = append(errors, err)
+		}
+	}
+	return errors
+}
+
+// ValidateLogVerbosity validates the level is between 0 and MaxLogVerbosity, levels above HighLogVerbosity result in a ValidationWarning
+func ValidateLogVerbosity(field string, level int) error {
+	if level < 0 || level > MaxLogVerbosity {
+		return newValidationError(field, ValidationErrorInvalid, "%s must be between 0 and %d, got %d", field, MaxLogVerbosity, level)
+	}
+	if level > HighLogVerbosity {
+		return &ValidationWarning{Message: fmt.Sprintf("%s is %d, verbosity above %d logs a lot and impacts the performance, use it for debugging only", field, level, HighLogVerbosity)}
+	}
+	return nil
+}
+
+// VerbosityArg returns the --v argument value for the level, empty for the component default
+func VerbosityArg(level int) string {
+	if level <= 0 {
+		return ""
+	}
+	return strconv.Itoa(level)
+}
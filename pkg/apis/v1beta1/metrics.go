@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// MetricsSpec defines the access to the metrics of the control plane components
+type MetricsSpec struct {
+	// Readers are the service accounts, as namespace/name, allowed to scrape the metrics
+	Readers []string `yaml:"readers"`
+}
+
+// ServiceAccount is a service account granted the metrics reader role
+type ServiceAccount struct {
+	Namespace string
+	Name      string
+}
+
+// Validate validates the readers are namespace/name references of service accounts
+func (m *MetricsSpec) Validate() []error {
+	var errors []error
+	if m == nil {
+		return errors
+	}
+	for _, reader := range m.Readers {
+		parts := strings.Split(reader, "/")
+		if len(parts) != 2 {
+			errors = append(errors, newValidationError("spec.metrics.readers", ValidationErrorInvalid, "metrics.readers: %q is not a namespace/name service account reference", reader))
+			continue
+		}
+		if msgs := validation.IsDNS1123Label(parts[0]); len(msgs) > 0 {
+			errors = append(errors, newValidationError("spec.metrics.readers", ValidationErrorInvalid, "metrics.readers: invalid namespace %q: %s", parts[0], strings.Join(msgs, ", ")))
+		}
+		if msgs := validation.IsDNS1123Subdomain(parts[1]); len(msgs) > 0 {
+			errors = append(errors, newValidationError("spec.metrics.readers", ValidationErrorInvalid, "metrics.readers: invalid service account name %q: %s", parts[1], strings.Join(msgs, ", ")))
+		}
+	}
+	return errors
+}
+
+// ServiceAccounts returns the readers as service accounts, the invalid ones are skipped
+func (m *MetricsSpec) ServiceAccounts() []ServiceAccount {
+	var accounts []ServiceAccount
+	if m == nil {
+		return accounts
+	}
+	for _, reader := range m.Readers {
+		if parts := strings.Split(reader, "/"); len(parts) == 2 {
+			accounts = append(accounts, ServiceAccount{Namespace: parts[0], Name: parts[1]})
+		}
+	}
+	return accounts
+}
@@ -28,29 +28,146 @@ type Network struct {
 	ServiceCIDR string  `yaml:"serviceCIDR"`
 	Provider    string  `yaml:"provider"`
 	Calico      *Calico `yaml:"calico"`
+	// ClusterDNS is the address of the CoreDNS service, the 10th address of the service CIDR if empty
+	ClusterDNS string `yaml:"clusterDNS"`
+	// KubeRouter configures kube-router, used with the kuberouter provider
+	KubeRouter *KubeRouter `yaml:"kuberouter"`
+	// ExpectedScale is the cluster size the CIDRs are checked to be large enough for
+	ExpectedScale *ExpectedScale `yaml:"expectedScale"`
+	// DefaultNetworkPolicy are the default-deny NetworkPolicies of the workload namespaces
+	DefaultNetworkPolicy *DefaultNetworkPolicy `yaml:"defaultNetworkPolicy"`
+}
+
+// ExpectedScale describes the expected size of the cluster
+type ExpectedScale struct {
+	Nodes       int `yaml:"nodes"`
+	PodsPerNode int `yaml:"podsPerNode"`
+	Services    int `yaml:"services"`
+}
+
+// DefaultExpectedScale creates the ExpectedScale of a reasonably sized cluster
+func DefaultExpectedScale() *ExpectedScale {
+	return &ExpectedScale{
+		Nodes:       100,
+		PodsPerNode: 110,
+		Services:    1000,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (e *ExpectedScale) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*e = *DefaultExpectedScale()
+
+	type yexpectedscale ExpectedScale
+	return unmarshal((*yexpectedscale)(e))
 }
 
 // DefaultNetwork creates the Network config struct with sane default values
 func DefaultNetwork() *Network {
 	return &Network{
-		PodCIDR:     "10.244.0.0/16",
-		ServiceCIDR: "10.96.0.0/12",
-		Provider:    "calico",
-		Calico:      DefaultCalico(),
+		PodCIDR:              "10.244.0.0/16",
+		ServiceCIDR:          "10.96.0.0/12",
+		Provider:             "calico",
+		Calico:               DefaultCalico(),
+		ExpectedScale:        DefaultExpectedScale(),
+		DefaultNetworkPolicy: DefaultDefaultNetworkPolicy(),
 	}
 }
 
 // Validate validates all the settings make sense and should work
 func (n *Network) Validate() []error {
 	var errors []error
-	if n.Provider != "calico" && n.Provider != "custom" {
-		errors = append(errors, fmt.Errorf("unsupported network provider: %s", n.Provider))
+	if n.Provider != "calico" && n.Provider != "cilium" && n.Provider != "kuberouter" && n.Provider != "custom" {
+		errors = append(errors, newValidationError("spec.network.provider", ValidationErrorUnsupported, "unsupported network provider: %s", n.Provider))
+	}
+	if n.Provider == "kuberouter" {
+		errors = append(errors, n.KubeRouter.Validate()...)
+	}
+	errors = append(errors, n.validateClusterDNS()...)
+	errors = append(errors, n.DefaultNetworkPolicy.Validate()...)
+	if n.DefaultNetworkPolicy != nil && n.DefaultNetworkPolicy.Enabled && !n.EnforcesNetworkPolicy() {
+		errors = append(errors, &ValidationWarning{Message: fmt.Sprintf("network.defaultNetworkPolicy is ignored, k0s does not know whether the %s network provider enforces NetworkPolicies", n.Provider)})
 	}
 	return errors
 }
 
-// DNSAddress calculates the 10th address of configured service CIDR block.
+// validateClusterDNS checks the DNS service address override is a service address
+func (n *Network) validateClusterDNS() []error {
+	if n.ClusterDNS == "" {
+		return nil
+	}
+	ip := net.ParseIP(n.ClusterDNS)
+	if ip == nil {
+		return []error{newValidationError("spec.network.clusterDNS", ValidationErrorInvalid, "network.clusterDNS %s is not an IP address", n.ClusterDNS)}
+	}
+	_, serviceNet, err := net.ParseCIDR(n.ServiceCIDR)
+	if err != nil {
+		// the service CIDR itself is invalid, nothing to check against
+		return nil
+	}
+	if !serviceNet.Contains(ip) {
+		return []error{newValidationError("spec.network.clusterDNS", ValidationErrorInvalid, "network.clusterDNS %s is not within network.serviceCIDR %s", n.ClusterDNS, n.ServiceCIDR)}
+	}
+	if apiAddress, err := n.InternalAPIAddress(); err == nil && ip.Equal(net.ParseIP(apiAddress)) {
+		return []error{newValidationError("spec.network.clusterDNS", ValidationErrorInvalid, "network.clusterDNS %s is the address of the kubernetes API service", n.ClusterDNS)}
+	}
+	return nil
+}
+
+// EnforcesNetworkPolicy returns true if the network provider is managed by k0s and enforces NetworkPolicies
+func (n *Network) EnforcesNetworkPolicy() bool {
+	return n.Provider == "calico" || n.Provider == "cilium" || n.Provider == "kuberouter"
+}
+
+// ReplacesKubeProxy returns true if the network provider handles the services, so kube-proxy is not deployed
+func (n *Network) ReplacesKubeProxy() bool {
+	return n.Provider == "kuberouter" && n.KubeRouter != nil && n.KubeRouter.ServiceProxy
+}
+
+// ScaleWarnings warns about pod and service CIDRs too small for the expected scale of the cluster.
+// nodeMaskSize is the prefix length of the pod CIDR assigned to each node.
+func (n *Network) ScaleWarnings(nodeMaskSize int) []error {
+	var warnings []error
+	scale := n.ExpectedScale
+	if scale == nil {
+		scale = DefaultExpectedScale()
+	}
+
+	if _, podNet, err := net.ParseCIDR(n.PodCIDR); err == nil {
+		prefix, bits := podNet.Mask.Size()
+		if pods := cidrSize(bits - prefix); pods < int64(scale.Nodes)*int64(scale.PodsPerNode) {
+			warnings = append(warnings, &ValidationWarning{Message: fmt.Sprintf("network.podCIDR %s has room for %d pods, less than the expected %d nodes with %d pods each", n.PodCIDR, pods, scale.Nodes, scale.PodsPerNode)})
+		}
+		if prefix > nodeMaskSize {
+			warnings = append(warnings, &ValidationWarning{Message: fmt.Sprintf("network.podCIDR %s is smaller than the /%d pod CIDR of a single node, no node will get pod addresses", n.PodCIDR, nodeMaskSize)})
+		} else if nodes := cidrSize(nodeMaskSize - prefix); nodes < int64(scale.Nodes) {
+			warnings = append(warnings, &ValidationWarning{Message: fmt.Sprintf("network.podCIDR %s has room for the /%d pod CIDRs of %d nodes, less than the expected %d nodes", n.PodCIDR, nodeMaskSize, nodes, scale.Nodes)})
+		}
+	}
+
+	if _, serviceNet, err := net.ParseCIDR(n.ServiceCIDR); err == nil {
+		prefix, bits := serviceNet.Mask.Size()
+		if services := cidrSize(bits - prefix); services < int64(scale.Services) {
+			warnings = append(warnings, &ValidationWarning{Message: fmt.Sprintf("network.serviceCIDR %s has room for %d services, less than the expected %d", n.ServiceCIDR, services, scale.Services)})
+		}
+	}
+
+	return warnings
+}
+
+// cidrSize returns the number of addresses of a CIDR with the given number of host bits, capped to avoid overflows
+func cidrSize(hostBits int) int64 {
+	if hostBits > 62 {
+		hostBits = 62
+	}
+	return int64(1) << uint(hostBits)
+}
+
+// DNSAddress returns the ClusterDNS override if set, otherwise calculates the 10th address of configured service CIDR block.
 func (n *Network) DNSAddress() (string, error) {
+	if n.ClusterDNS != "" {
+		return n.ClusterDNS, nil
+	}
 	_, ipnet, err := net.ParseCIDR(n.ServiceCIDR)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to parse service CIDR %s: %s", n.ServiceCIDR, err.Error())
@@ -65,7 +182,7 @@ func (n *Network) DNSAddress() (string, error) {
 	}
 
 	if !ipnet.Contains(address) {
-		return "", errors.Wrapf(err, "failed to calculate a valid DNS address: %s", address.String())
+		return "", fmt.Errorf("failed to calculate a valid DNS address: %s", address.String())
 	}
 
 	return address.String(), nil
@@ -86,6 +203,8 @@ func (n *Network) InternalAPIAddress() (string, error) {
 // UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
 func (n *Network) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	n.Provider = "calico"
+	n.ExpectedScale = DefaultExpectedScale()
+	n.DefaultNetworkPolicy = DefaultDefaultNetworkPolicy()
 
 	type ynetwork Network
 	yc := (*ynetwork)(n)
@@ -97,6 +216,9 @@ func (n *Network) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if n.Provider == "calico" && n.Calico == nil {
 		n.Calico = DefaultCalico()
 	}
+	if n.Provider == "kuberouter" && n.KubeRouter == nil {
+		n.KubeRouter = DefaultKubeRouter()
+	}
 
 	return nil
 }
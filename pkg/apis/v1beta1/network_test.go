@@ -16,6 +16,7 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -46,6 +47,56 @@ func (s *NetworkSuite) TestDNSAddress() {
 
 }
 
+func (s *NetworkSuite) TestClusterDNS() {
+	n := DefaultNetwork()
+	n.ClusterDNS = "10.96.0.53"
+	dns, err := n.DNSAddress()
+	s.NoError(err)
+	s.Equal("10.96.0.53", dns)
+	s.Empty(n.Validate())
+
+	n.ClusterDNS = "10.97.0.53"
+	s.Empty(n.Validate())
+
+	n.ClusterDNS = "10.112.0.53"
+	errors := n.Validate()
+	s.Len(errors, 1)
+	s.Equal("network.clusterDNS 10.112.0.53 is not within network.serviceCIDR 10.96.0.0/12", errors[0].Error())
+
+	n.ClusterDNS = "10.96.0.1"
+	errors = n.Validate()
+	s.Len(errors, 1)
+	s.Equal("network.clusterDNS 10.96.0.1 is the address of the kubernetes API service", errors[0].Error())
+
+	n.ClusterDNS = "dns"
+	errors = n.Validate()
+	s.Len(errors, 1)
+	s.Equal("network.clusterDNS dns is not an IP address", errors[0].Error())
+}
+
+func (s *NetworkSuite) TestScaleWarnings() {
+	n := DefaultNetwork()
+	s.Empty(n.ScaleWarnings(24))
+
+	n.PodCIDR = "10.244.0.0/28"
+	n.ServiceCIDR = "10.96.0.0/24"
+	warnings := n.ScaleWarnings(24)
+	s.Len(warnings, 3)
+	s.Equal("network.podCIDR 10.244.0.0/28 has room for 16 pods, less than the expected 100 nodes with 110 pods each", warnings[0].Error())
+	s.Equal("network.podCIDR 10.244.0.0/28 is smaller than the /24 pod CIDR of a single node, no node will get pod addresses", warnings[1].Error())
+	s.Equal("network.serviceCIDR 10.96.0.0/24 has room for 256 services, less than the expected 1000", warnings[2].Error())
+
+	n.PodCIDR = "10.244.0.0/20"
+	n.ExpectedScale = &ExpectedScale{Nodes: 20, PodsPerNode: 50, Services: 100}
+	warnings = n.ScaleWarnings(24)
+	s.Len(warnings, 1)
+	s.Equal("network.podCIDR 10.244.0.0/20 has room for the /24 pod CIDRs of 16 nodes, less than the expected 20 nodes", warnings[0].Error())
+
+	errors, warnings := SplitWarnings(append(warnings, fmt.Errorf("invalid")))
+	s.Len(errors, 1)
+	s.Len(warnings, 1)
+}
+
 func (s *NetworkSuite) TestCalicoDefaults() {
 	n := DefaultNetwork()
 
@@ -79,6 +130,25 @@ spec:
 	s.Equal("vxlan", n.Calico.Mode)
 }
 
+func (s *NetworkSuite) TestDefaultNetworkPolicyValidation() {
+	n := DefaultNetwork()
+	n.DefaultNetworkPolicy.Enabled = true
+	s.Empty(n.Validate())
+
+	n.DefaultNetworkPolicy.NamespaceSelector = "team in (a,"
+	n.DefaultNetworkPolicy.Ingress = false
+	n.DefaultNetworkPolicy.Egress = false
+	s.Len(n.Validate(), 2)
+
+	n.DefaultNetworkPolicy = DefaultDefaultNetworkPolicy()
+	n.DefaultNetworkPolicy.Enabled = true
+	n.Provider = "custom"
+	errors, warnings := SplitWarnings(n.Validate())
+	s.Empty(errors)
+	s.Len(warnings, 1)
+	s.Equal("network.defaultNetworkPolicy is ignored, k0s does not know whether the custom network provider enforces NetworkPolicies", warnings[0].Error())
+}
+
 func TestNetworkSuite(t *testing.T) {
 	ns := &NetworkSuite{}
 
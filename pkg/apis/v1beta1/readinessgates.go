@@ -0,0 +1,52 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// builtinNodeConditions are the node conditions maintained by the kubelet, which can't be used as readiness gates
+var builtinNodeConditions = []string{"Ready", "MemoryPressure", "DiskPressure", "PIDPressure", "NetworkUnavailable"}
+
+// ReadinessGates are the node condition types which must be true, in addition to Ready, before pods are scheduled to
+// the embedded worker of a controller, e.g. a condition set by a DaemonSet once it has set up the node
+type ReadinessGates []string
+
+// Validate validates the gates are well-formed condition types, not maintained by the kubelet itself
+func (g ReadinessGates) Validate() []error {
+	var errors []error
+	seen := map[string]bool{}
+	for i, gate := range g {
+		if msgs := validation.IsQualifiedName(gate); len(msgs) > 0 {
+			errors = append(errors, newValidationError(fmt.Sprintf("spec.readinessGates[%d]", i), ValidationErrorInvalid, "readinessGates[%d]: invalid condition type %q: %s", i, gate, strings.Join(msgs, ", ")))
+			continue
+		}
+		for _, builtin := range builtinNodeConditions {
+			if gate == builtin {
+				errors = append(errors, newValidationError(fmt.Sprintf("spec.readinessGates[%d]", i), ValidationErrorForbidden, "readinessGates[%d]: %s is maintained by the kubelet and can't be used as a readiness gate", i, gate))
+			}
+		}
+		if seen[gate] {
+			errors = append(errors, newValidationError(fmt.Sprintf("spec.readinessGates[%d]", i), ValidationErrorDuplicate, "readinessGates[%d]: duplicate condition type %s", i, gate))
+		}
+		seen[gate] = true
+	}
+	return errors
+}
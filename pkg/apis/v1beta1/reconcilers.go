@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"strings"
+
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// KnownReconcilers lists the names of the in-cluster component reconcilers
+var KnownReconcilers = []string{
+	"default-psp",
+	"kube-proxy",
+	"coredns",
+	"calico",
+	"cilium",
+	"kuberouter",
+	"metricServer",
+	"kubeletConfig",
+	"defaultLimits",
+	"defaultNetworkPolicy",
+	"systemRBAC",
+	"systemPriority",
+	"metricsRBAC",
+}
+
+// ReconcilersSpec defines the failure policy of the in-cluster component reconcilers
+type ReconcilersSpec struct {
+	// Required are the reconcilers whose failure to initialize aborts the startup, the others are skipped with a warning
+	Required []string `yaml:"required"`
+}
+
+// DefaultReconcilersSpec creates the ReconcilersSpec requiring the CNI and CoreDNS, without them the cluster is unusable.
+// Only the reconciler of the configured network provider is created, so requiring all the CNIs is fine.
+func DefaultReconcilersSpec() *ReconcilersSpec {
+	return &ReconcilersSpec{
+		Required: []string{"calico", "cilium", "kuberouter", "coredns"},
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (r *ReconcilersSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*r = *DefaultReconcilersSpec()
+
+	type yreconcilersspec ReconcilersSpec
+	return unmarshal((*yreconcilersspec)(r))
+}
+
+// Validate validates the required reconcilers are known
+func (r *ReconcilersSpec) Validate() []error {
+	if r == nil {
+		return nil
+	}
+	var errors []error
+	for _, name := range r.Required {
+		if !util.StringSliceContains(KnownReconcilers, name) {
+			errors = append(errors, newValidationError("spec.reconcilers.required", ValidationErrorUnsupported, "unknown reconciler %q in reconcilers.required, known reconcilers are: %s", name, strings.Join(KnownReconcilers, ", ")))
+		}
+	}
+	return errors
+}
+
+// IsRequired returns true if the reconciler must initialize for k0s to start
+func (r *ReconcilersSpec) IsRequired(name string) bool {
+	return r != nil && util.StringSliceContains(r.Required, name)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import "strconv"
+
+// SafeToEvict defines the cluster-autoscaler safe-to-evict annotations of the k0s managed addon pods
+type SafeToEvict struct {
+	// Enabled makes k0s annotate the addon pods, the autoscaler decides based on its own defaults if not enabled
+	Enabled bool `yaml:"enabled"`
+	// CoreDNS is whether the CoreDNS pods may be evicted when scaling down
+	CoreDNS bool `yaml:"coreDNS"`
+	// MetricsServer is whether the metrics-server pods may be evicted when scaling down
+	MetricsServer bool `yaml:"metricsServer"`
+}
+
+// SafeToEvictAnnotation is the pod annotation the cluster-autoscaler reads
+const SafeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+// DefaultSafeToEvict creates the SafeToEvict with sane defaults, disabled but allowing the eviction of all addons once enabled
+func DefaultSafeToEvict() *SafeToEvict {
+	return &SafeToEvict{
+		Enabled:       false,
+		CoreDNS:       true,
+		MetricsServer: true,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (s *SafeToEvict) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = *DefaultSafeToEvict()
+
+	type ysafetoevict SafeToEvict
+	return unmarshal((*ysafetoevict)(s))
+}
+
+// CoreDNSValue returns the annotation value for the CoreDNS pods, empty if the pods are not to be annotated
+func (s *SafeToEvict) CoreDNSValue() string {
+	if s == nil || !s.Enabled {
+		return ""
+	}
+	return strconv.FormatBool(s.CoreDNS)
+}
+
+// MetricsServerValue returns the annotation value for the metrics-server pods, empty if the pods are not to be annotated
+func (s *SafeToEvict) MetricsServerValue() string {
+	if s == nil || !s.Enabled {
+		return ""
+	}
+	return strconv.FormatBool(s.MetricsServer)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SANList is the list of subject alternative names for the API certificates. Besides plain
+// entries, the yaml list accepts `fromFile: <path>` and `fromEnv: <VAR>` entries, which are
+// resolved when the config is loaded. The file or variable holds names separated by newlines,
+// commas or whitespace.
+type SANList []string
+
+// sanEntry is a single entry of the yaml SAN list
+type sanEntry struct {
+	Value    string
+	FromFile string `yaml:"fromFile"`
+	FromEnv  string `yaml:"fromEnv"`
+}
+
+// UnmarshalYAML accepts both plain strings and fromFile/fromEnv mappings
+func (e *sanEntry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Value); err == nil {
+		return nil
+	}
+
+	type ysanentry sanEntry
+	return unmarshal((*ysanentry)(e))
+}
+
+// UnmarshalYAML resolves the fromFile/fromEnv entries and deduplicates the resulting list
+func (s *SANList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var entries []sanEntry
+	if err := unmarshal(&entries); err != nil {
+		return err
+	}
+
+	var sans []string
+	for _, entry := range entries {
+		switch {
+		case entry.Value != "":
+			sans = append(sans, entry.Value)
+		case entry.FromFile != "":
+			data, err := ioutil.ReadFile(entry.FromFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read SANs from file")
+			}
+			resolved, err := parseSANs(string(data), "file "+entry.FromFile)
+			if err != nil {
+				return err
+			}
+			sans = append(sans, resolved...)
+		case entry.FromEnv != "":
+			value, found := os.LookupEnv(entry.FromEnv)
+			if !found {
+				return fmt.Errorf("failed to read SANs from env: %s is not set", entry.FromEnv)
+			}
+			resolved, err := parseSANs(value, "env "+entry.FromEnv)
+			if err != nil {
+				return err
+			}
+			sans = append(sans, resolved...)
+		default:
+			return fmt.Errorf("invalid SAN entry, must be a name or either of fromFile or fromEnv")
+		}
+	}
+
+	*s = dedupSANs(sans)
+	return nil
+}
+
+// parseSANs splits and validates the SANs given by a file or env source, lines starting with # are ignored
+func parseSANs(data string, source string) ([]string, error) {
+	var sans []string
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		for _, san := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' }) {
+			if err := validateSAN(san); err != nil {
+				return nil, errors.Wrapf(err, "invalid SAN from %s", source)
+			}
+			sans = append(sans, san)
+		}
+	}
+	return sans, nil
+}
+
+// validateSAN checks the SAN is either an IP address or a, possibly wildcard, DNS name
+func validateSAN(san string) error {
+	if net.ParseIP(san) != nil {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(san, "*.")); len(errs) > 0 {
+		return fmt.Errorf("%s is neither an IP address nor a DNS name: %s", san, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func dedupSANs(sans []string) SANList {
+	seen := make(map[string]bool, len(sans))
+	result := SANList{}
+	for _, san := range sans {
+		if seen[san] {
+			continue
+		}
+		seen[san] = true
+		result = append(result, san)
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSANsIndirection(t *testing.T) {
+	sanFile, err := ioutil.TempFile("", "k0s-sans-*")
+	require.NoError(t, err)
+	defer os.Remove(sanFile.Name())
+	_, err = sanFile.WriteString("# generated\nlb.example.com\n10.0.0.10, 10.0.0.11\n")
+	require.NoError(t, err)
+	require.NoError(t, sanFile.Close())
+
+	require.NoError(t, os.Setenv("K0S_TEST_SANS", "api.example.com,10.0.0.10"))
+	defer os.Unsetenv("K0S_TEST_SANS")
+
+	yamlData := fmt.Sprintf(`
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: Cluster
+metadata:
+  name: foobar
+spec:
+  api:
+    address: 10.0.0.1
+    sans:
+    - 10.0.0.1
+    - fromFile: %s
+    - fromEnv: K0S_TEST_SANS
+`, sanFile.Name())
+
+	c, err := fromYaml(t, yamlData)
+	require.NoError(t, err)
+	assert.Equal(t, SANList{"10.0.0.1", "lb.example.com", "10.0.0.10", "10.0.0.11", "api.example.com"}, c.Spec.API.SANs)
+}
+
+func TestSANsIndirectionErrors(t *testing.T) {
+	require.NoError(t, os.Setenv("K0S_TEST_SANS", "not_a_valid_name!"))
+	defer os.Unsetenv("K0S_TEST_SANS")
+
+	for _, entry := range []string{"fromEnv: K0S_TEST_SANS", "fromEnv: K0S_TEST_SANS_UNSET", "fromFile: /non/existing/file"} {
+		yamlData := fmt.Sprintf(`
+spec:
+  api:
+    sans:
+    - %s
+`, entry)
+		_, err := fromYaml(t, yamlData)
+		assert.Error(t, err, entry)
+	}
+}
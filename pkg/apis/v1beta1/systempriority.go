@@ -0,0 +1,41 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+// SystemPriority defines whether k0s allows its system components to use the critical priority classes
+type SystemPriority struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// DefaultSystemPriority creates the SystemPriority with sane defaults, enabled
+func DefaultSystemPriority() *SystemPriority {
+	return &SystemPriority{
+		Enabled: true,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (s *SystemPriority) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*s = *DefaultSystemPriority()
+
+	type ysystempriority SystemPriority
+	return unmarshal((*ysystempriority)(s))
+}
+
+// IsEnabled returns whether the system components may use the critical priority classes, they may unless disabled
+func (s *SystemPriority) IsEnabled() bool {
+	return s == nil || s.Enabled
+}
@@ -1,11 +1,30 @@
 package v1beta1
 
-import "time"
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	// TelemetryIDSourceMachineID identifies the telemetry instance by the machine id of the node
+	TelemetryIDSourceMachineID = "machineID"
+	// TelemetryIDSourceClusterID identifies the telemetry instance by the configured clusterID
+	TelemetryIDSourceClusterID = "clusterID"
+	// TelemetryIDSourceRandom identifies the telemetry instance by a random id persisted in the data directory
+	TelemetryIDSourceRandom = "random"
+)
 
 // ClusterTelemetry holds telemetry related settings
 type ClusterTelemetry struct {
 	Interval time.Duration `yaml:"interval"`
 	Enabled  bool          `yaml:"enabled"`
+	// IDSource is where the anonymous id the telemetry is sent with comes from
+	IDSource string `yaml:"idSource"`
+	// ClusterID is the id of the cluster when IDSource is clusterID, only a hash of it is sent
+	ClusterID string `yaml:"clusterID,omitempty"`
+	// Endpoint is the base URL of the Segment compatible API the telemetry is sent to, Segment itself if not set
+	Endpoint string `yaml:"endpoint,omitempty"`
 }
 
 // DefaultClusterTelemetry default settings
@@ -13,5 +32,43 @@ func DefaultClusterTelemetry() *ClusterTelemetry {
 	return &ClusterTelemetry{
 		Interval: time.Minute * 10,
 		Enabled:  true,
+		IDSource: TelemetryIDSourceMachineID,
+	}
+}
+
+// UnmarshalYAML sets in some sane defaults when unmarshaling the data from yaml
+func (t *ClusterTelemetry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*t = *DefaultClusterTelemetry()
+
+	type yclustertelemetry ClusterTelemetry
+	return unmarshal((*yclustertelemetry)(t))
+}
+
+// Validate validates the id source and that the clusterID is set for it
+func (t *ClusterTelemetry) Validate() []error {
+	var errors []error
+	if t == nil {
+		return errors
+	}
+	switch t.IDSource {
+	case TelemetryIDSourceMachineID, TelemetryIDSourceRandom:
+		if t.ClusterID != "" {
+			errors = append(errors, &ValidationWarning{Message: fmt.Sprintf("telemetry.clusterID is ignored with telemetry.idSource %s", t.IDSource)})
+		}
+	case TelemetryIDSourceClusterID:
+		if t.ClusterID == "" {
+			errors = append(errors, newValidationError("telemetry.clusterID", ValidationErrorRequired, "telemetry.clusterID is required with telemetry.idSource %s", t.IDSource))
+		}
+	default:
+		errors = append(errors, newValidationError("telemetry.idSource", ValidationErrorUnsupported, "unknown telemetry.idSource %q, expected one of %s, %s or %s", t.IDSource, TelemetryIDSourceMachineID, TelemetryIDSourceClusterID, TelemetryIDSourceRandom))
+	}
+	if t.Endpoint != "" {
+		if u, err := url.Parse(t.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, newValidationError("telemetry.endpoint", ValidationErrorInvalid, "telemetry.endpoint %q is not a http or https URL", t.Endpoint))
+		}
+	}
+	if t.Enabled && t.Interval <= 0 {
+		errors = append(errors, newValidationError("telemetry.interval", ValidationErrorInvalid, "telemetry.interval must be positive, got %s", t.Interval))
 	}
+	return errors
 }
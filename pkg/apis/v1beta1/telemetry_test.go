@@ -0,0 +1,58 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestTelemetryDefaults(t *testing.T) {
+	c := &ClusterConfig{}
+	assert.NoError(t, yaml.Unmarshal([]byte("telemetry:\n  idSource: random\n"), c))
+
+	assert.Equal(t, TelemetryIDSourceRandom, c.Telemetry.IDSource)
+	assert.Equal(t, 10*time.Minute, c.Telemetry.Interval)
+	assert.True(t, c.Telemetry.Enabled)
+	assert.Empty(t, c.Telemetry.Validate())
+}
+
+func TestTelemetryValidation(t *testing.T) {
+	noClusterID := &ClusterTelemetry{Enabled: true, Interval: time.Minute, IDSource: TelemetryIDSourceClusterID}
+	errors := noClusterID.Validate()
+	assert.Len(t, errors, 1)
+	assert.Equal(t, "telemetry.clusterID is required with telemetry.idSource clusterID", errors[0].Error())
+
+	unknown := &ClusterTelemetry{Enabled: true, Interval: time.Minute, IDSource: "hostname"}
+	errors = unknown.Validate()
+	assert.Len(t, errors, 1)
+	assert.Contains(t, errors[0].Error(), `unknown telemetry.idSource "hostname"`)
+
+	ignored := &ClusterTelemetry{Enabled: true, Interval: time.Minute, IDSource: TelemetryIDSourceMachineID, ClusterID: "prod"}
+	_, warnings := SplitWarnings(ignored.Validate())
+	assert.Len(t, warnings, 1)
+
+	invalidEndpoint := &ClusterTelemetry{Enabled: true, Interval: time.Minute, IDSource: TelemetryIDSourceMachineID, Endpoint: "collector.internal:8080"}
+	errors = invalidEndpoint.Validate()
+	assert.Len(t, errors, 1)
+	assert.Equal(t, `telemetry.endpoint "collector.internal:8080" is not a http or https URL`, errors[0].Error())
+
+	internal := &ClusterTelemetry{Enabled: true, Interval: time.Minute, IDSource: TelemetryIDSourceMachineID, Endpoint: "https://collector.internal"}
+	assert.Empty(t, internal.Validate())
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// TrustedCABundle defines additional CA certificates trusted by k0s and the components it runs,
+// given either as a path to a PEM file or inline PEM data
+type TrustedCABundle struct {
+	File   string `yaml:"file"`
+	Inline string `yaml:"inline"`
+}
+
+// PEM returns the PEM encoded certificates of the bundle
+func (t *TrustedCABundle) PEM() ([]byte, error) {
+	if t.File != "" {
+		data, err := ioutil.ReadFile(t.File)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read trusted CA bundle %s", t.File)
+		}
+		return data, nil
+	}
+	return []byte(t.Inline), nil
+}
+
+// Validate validates exactly one source is set and it holds only valid PEM certificates
+func (t *TrustedCABundle) Validate() []error {
+	var errors []error
+	if t == nil {
+		return errors
+	}
+	if (t.File == "") == (t.Inline == "") {
+		return append(errors, newValidationError("spec.trustedCABundle", ValidationErrorInvalid, "trustedCABundle must set exactly one of file or inline"))
+	}
+
+	data, err := t.PEM()
+	if err != nil {
+		return append(errors, &ValidationError{Field: "spec.trustedCABundle.file", Code: ValidationErrorInvalid, Message: err.Error()})
+	}
+	if _, err := parsePEMCertificates(data); err != nil {
+		errors = append(errors, newValidationError("spec.trustedCABundle", ValidationErrorInvalid, "invalid trustedCABundle: %v", err))
+	}
+	return errors
+}
+
+// parsePEMCertificates parses all the certificates in the PEM data, anything else than certificates is an error
+func parsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %s", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificates found")
+	}
+	return certs, nil
+}
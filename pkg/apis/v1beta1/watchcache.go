@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// WatchCacheSpec defines the sizes of the API server watch caches
+type WatchCacheSpec struct {
+	// DefaultSize is the watch cache size of the resources not listed in Sizes, the kube-apiserver default if not set
+	DefaultSize *int `yaml:"defaultSize"`
+	// Sizes are the watch cache sizes of single resources as resource[.group]#size, e.g. pods#1000
+	Sizes []string `yaml:"sizes"`
+}
+
+// Validate validates the sizes are not negative and the per-resource sizes are in the resource#size format
+func (w *WatchCacheSpec) Validate() []error {
+	var errors []error
+	if w == nil {
+		return errors
+	}
+	if w.DefaultSize != nil && *w.DefaultSize < 0 {
+		errors = append(errors, newValidationError("spec.api.watchCache.defaultSize", ValidationErrorInvalid, "api.watchCache.defaultSize cannot be negative, got %d", *w.DefaultSize))
+	}
+	seen := map[string]bool{}
+	for _, entry := range w.Sizes {
+		parts := strings.Split(entry, "#")
+		if len(parts) != 2 {
+			errors = append(errors, newValidationError("spec.api.watchCache.sizes", ValidationErrorInvalid, "api.watchCache.sizes: %q is not in the resource#size format", entry))
+			continue
+		}
+		resource, size := parts[0], parts[1]
+		if msgs := validation.IsDNS1123Subdomain(resource); len(msgs) > 0 {
+			errors = append(errors, newValidationError("spec.api.watchCache.sizes", ValidationErrorInvalid, "api.watchCache.sizes: invalid resource %q: %s", resource, strings.Join(msgs, ", ")))
+		} else if seen[resource] {
+			errors = append(errors, newValidationError("spec.api.watchCache.sizes", ValidationErrorDuplicate, "api.watchCache.sizes: duplicate resource %q", resource))
+		}
+		seen[resource] = true
+		if n, err := strconv.Atoi(size); err != nil || n < 0 {
+			errors = append(errors, newValidationError("spec.api.watchCache.sizes", ValidationErrorInvalid, "api.watchCache.sizes: size of %q must be a non-negative integer, got %q", resource, size))
+		}
+	}
+	return errors
+}
+
+// Args returns the kube-apiserver flags of the watch cache sizes, none if not configured
+func (w *WatchCacheSpec) Args() map[string]string {
+	args := map[string]string{}
+	if w == nil {
+		return args
+	}
+	if w.DefaultSize != nil {
+		args["default-watch-cache-size"] = strconv.Itoa(*w.DefaultSize)
+	}
+	if len(w.Sizes) > 0 {
+		args["watch-cache-sizes"] = strings.Join(w.Sizes, ",")
+	}
+	return args
+}
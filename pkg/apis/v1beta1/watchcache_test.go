@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestWatchCacheArgs(t *testing.T) {
+	w := &WatchCacheSpec{}
+	assert.NoError(t, yaml.Unmarshal([]byte("defaultSize: 0\nsizes: [pods#1000, deployments.apps#500]"), w))
+
+	assert.Equal(t, map[string]string{
+		"default-watch-cache-size": "0",
+		"watch-cache-sizes":        "pods#1000,deployments.apps#500",
+	}, w.Args())
+	assert.Empty(t, w.Validate())
+
+	var unset *WatchCacheSpec
+	assert.Empty(t, unset.Args())
+}
+
+func TestWatchCacheValidation(t *testing.T) {
+	negative := -1
+	w := &WatchCacheSpec{
+		DefaultSize: &negative,
+		Sizes:       []string{"pods#100", "pods#200", "secrets", "Nodes#10", "events#-5", "services#many"},
+	}
+	errors := w.Validate()
+	assert.Len(t, errors, 6)
+	assert.Equal(t, "api.watchCache.defaultSize cannot be negative, got -1", errors[0].Error())
+	assert.Equal(t, `api.watchCache.sizes: duplicate resource "pods"`, errors[1].Error())
+	assert.Equal(t, `api.watchCache.sizes: "secrets" is not in the resource#size format`, errors[2].Error())
+	assert.Contains(t, errors[3].Error(), `invalid resource "Nodes"`)
+	assert.Equal(t, `api.watchCache.sizes: size of "events" must be a non-negative integer, got "-5"`, errors[4].Error())
+	assert.Equal(t, `api.watchCache.sizes: size of "services" must be a non-negative integer, got "many"`, errors[5].Error())
+}
@@ -17,16 +17,25 @@ package v1beta1
 
 import (
 	"fmt"
+	"net"
+	"strings"
 )
 
+// defaultKubeletMaxPods is the kubelet's own default for maxPods
+const defaultKubeletMaxPods = 110
+
 // WorkerProfiles profiles collection
 type WorkerProfiles []WorkerProfile
 
 // Validate validates all profiles
 func (wps WorkerProfiles) Validate() []error {
 	var errors []error
-	for _, p := range wps {
+	for i, p := range wps {
 		if err := p.Validate(); err != nil {
+			// the profile only knows the path of the field within itself
+			if ve, ok := err.(*ValidationError); ok {
+				ve.Field = fmt.Sprintf("spec.workerProfiles[%d].%s", i, ve.Field)
+			}
 			errors = append(errors, err)
 		}
 	}
@@ -35,8 +44,12 @@ func (wps WorkerProfiles) Validate() []error {
 
 // WorkerProfile worker profile
 type WorkerProfile struct {
-	Name   string                 `yaml:"name"`
-	Values map[string]interface{} `yaml:"values"`
+	Name string `yaml:"name"`
+	// MaxPods is the maximum number of pods the kubelet runs, the kubelet default is used if not set
+	MaxPods int                    `yaml:"maxPods,omitempty"`
+	Values  map[string]interface{} `yaml:"values"`
+	// ExtraArgs are additional kubelet flags, for settings not available in the kubelet config
+	ExtraArgs map[string]string `yaml:"extraArgs,omitempty"`
 }
 
 var lockedFields = map[string]struct{}{
@@ -44,14 +57,76 @@ var lockedFields = map[string]struct{}{
 	"clusterDomain": {},
 	"apiVersion":    {},
 	"kind":          {},
+	// the kubelet takes the pod CIDR of its node from the node spec, a per profile one would be shared by all nodes
+	"podCIDR": {},
+}
+
+// lockedKubeletFlags are the kubelet flags k0s always sets, the extra args can't override them
+var lockedKubeletFlags = map[string]struct{}{
+	"config":                     {},
+	"kubeconfig":                 {},
+	"bootstrap-kubeconfig":       {},
+	"root-dir":                   {},
+	"volume-plugin-dir":          {},
+	"rotate-certificates":        {},
+	"container-runtime":          {},
+	"container-runtime-endpoint": {},
+	"kube-reserved-cgroup":       {},
+	"runtime-cgroups":            {},
+	"kubelet-cgroups":            {},
+	"cluster-dns":                {},
+	"cluster-domain":             {},
 }
 
-// Validate validates instance
+// Validate validates instance, the field paths of the returned ValidationError are relative to the profile
 func (wp *WorkerProfile) Validate() error {
 	for field := range wp.Values {
 		if _, found := lockedFields[field]; found {
-			return fmt.Errorf("field `%s` is prohibited to override in worker profile", field)
+			return newValidationError("values."+field, ValidationErrorForbidden, "field `%s` is prohibited to override in worker profile", field)
 		}
 	}
+
+	for flag := range wp.ExtraArgs {
+		if _, found := lockedKubeletFlags[strings.TrimLeft(flag, "-")]; found {
+			return newValidationError("extraArgs."+flag, ValidationErrorForbidden, "kubelet flag `%s` is managed by k0s and can't be set in the extraArgs of worker profile %s", flag, wp.Name)
+		}
+	}
+
+	if wp.MaxPods < 0 {
+		return newValidationError("maxPods", ValidationErrorInvalid, "invalid maxPods %d in worker profile %s, must be positive", wp.MaxPods, wp.Name)
+	}
+	if _, found := wp.Values["maxPods"]; found && wp.MaxPods != 0 {
+		return newValidationError("maxPods", ValidationErrorForbidden, "maxPods is set both as a field and in the values of worker profile %s", wp.Name)
+	}
 	return nil
 }
+
+// NodePodCIDRCapacity returns the number of pod IPs the node.spec.podCIDR of each node can address. The controller
+// manager assigns these with the prefix length nodeMaskSize out of the cluster pod CIDR. -1 is returned if the
+// capacity is practically unlimited or unknown, the node mask size only applies to IPv4 pod CIDRs.
+func NodePodCIDRCapacity(podCIDR string, nodeMaskSize int) int {
+	_, podNet, err := net.ParseCIDR(podCIDR)
+	if err != nil {
+		return -1
+	}
+	if _, bits := podNet.Mask.Size(); bits != 32 {
+		return -1
+	}
+	hostBits := 32 - nodeMaskSize
+	if hostBits >= 20 {
+		return -1
+	}
+	if hostBits < 2 {
+		return 0
+	}
+	// network and broadcast addresses can't be used for pods
+	return 1<<uint(hostBits) - 2
+}
+
+// EffectiveMaxPods returns the maximum number of pods the kubelet runs with this profile
+func (wp *WorkerProfile) EffectiveMaxPods() int {
+	if wp.MaxPods > 0 {
+		return wp.MaxPods
+	}
+	return defaultKubeletMaxPods
+}
@@ -73,4 +73,69 @@ func TestWorkerProfile(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("max_pods_validation", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			profile WorkerProfile
+			valid   bool
+		}{
+			{
+				name:    "Max pods",
+				profile: WorkerProfile{MaxPods: 250},
+				valid:   true,
+			},
+			{
+				name:    "Negative max pods",
+				profile: WorkerProfile{MaxPods: -1},
+				valid:   false,
+			},
+			{
+				name: "Pod CIDR in values",
+				profile: WorkerProfile{Values: map[string]interface{}{
+					"podCIDR": "10.244.1.0/24",
+				}},
+				valid: false,
+			},
+			{
+				name: "Max pods set twice",
+				profile: WorkerProfile{MaxPods: 200, Values: map[string]interface{}{
+					"maxPods": 100,
+				}},
+				valid: false,
+			},
+			{
+				name:    "Extra kubelet args",
+				profile: WorkerProfile{ExtraArgs: map[string]string{"system-reserved": "cpu=500m"}},
+				valid:   true,
+			},
+			{
+				name:    "Extra kubelet args overriding cluster DNS",
+				profile: WorkerProfile{ExtraArgs: map[string]string{"cluster-dns": "8.8.8.8"}},
+				valid:   false,
+			},
+			{
+				name:    "Extra kubelet args overriding config with dashes",
+				profile: WorkerProfile{ExtraArgs: map[string]string{"--config": "/etc/kubelet.yaml"}},
+				valid:   false,
+			},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				valid := tc.profile.Validate() == nil
+				assert.Equal(t, valid, tc.valid)
+			})
+		}
+	})
+
+	t.Run("pod_cidr_capacity", func(t *testing.T) {
+		assert.Equal(t, NodePodCIDRCapacity("10.244.0.0/16", 24), 254)
+		assert.Equal(t, NodePodCIDRCapacity("10.244.0.0/16", 25), 126)
+		assert.Equal(t, NodePodCIDRCapacity("10.244.0.0/8", 8), -1)
+		assert.Equal(t, NodePodCIDRCapacity("fd00::/48", 24), -1)
+		assert.Equal(t, NodePodCIDRCapacity("", 24), -1)
+		assert.Equal(t, (&WorkerProfile{}).EffectiveMaxPods(), 110)
+		assert.Equal(t, (&WorkerProfile{MaxPods: 50}).EffectiveMaxPods(), 50)
+	})
 }
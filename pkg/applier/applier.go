@@ -26,8 +26,10 @@ import (
 	"k8s.io/client-go/util/retry"
 
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
@@ -38,6 +40,9 @@ import (
 type Applier struct {
 	Name string
 	Dir  string
+	// ServerSideApply and ForceConflicts are passed on to the applied stack
+	ServerSideApply bool
+	ForceConflicts  bool
 
 	log             *logrus.Entry
 	client          dynamic.Interface
@@ -100,11 +105,33 @@ func (a *Applier) Apply() error {
 	if err != nil {
 		return err
 	}
+	defaultNamespaces := make(map[*unstructured.Unstructured]string)
+	namespaces, err := NamespaceDirs(a.Dir)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		files, err := filepath.Glob(path.Join(a.Dir, ns, "*.yaml"))
+		if err != nil {
+			return err
+		}
+		nsResources, err := a.parseFiles(files)
+		if err != nil {
+			return err
+		}
+		for _, resource := range nsResources {
+			defaultNamespaces[resource] = ns
+		}
+		resources = append(resources, nsResources...)
+	}
 	stack := Stack{
-		Name:      a.Name,
-		Resources: resources,
-		Client:    a.client,
-		Discovery: a.discoveryClient,
+		Name:              a.Name,
+		Resources:         resources,
+		DefaultNamespaces: defaultNamespaces,
+		Client:            a.client,
+		Discovery:         a.discoveryClient,
+		ServerSideApply:   a.ServerSideApply,
+		ForceConflicts:    a.ForceConflicts,
 	}
 	a.log.Debug("applying stack")
 	err = stack.Apply(context.Background(), true)
@@ -131,6 +158,22 @@ func (a *Applier) Delete() error {
 	return err
 }
 
+// NamespaceDirs returns the subdirectories of the stack dir which are named like namespaces. The namespaceless
+// objects within them are applied into that namespace.
+func NamespaceDirs(dir string) ([]string, error) {
+	dirs, err := util.GetAllDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, d := range dirs {
+		if len(validation.IsDNS1123Label(d)) == 0 {
+			namespaces = append(namespaces, d)
+		}
+	}
+	return namespaces, nil
+}
+
 func (a *Applier) parseFiles(files []string) ([]*unstructured.Unstructured, error) {
 	resources := []*unstructured.Unstructured{}
 	for _, file := range files {
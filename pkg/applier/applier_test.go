@@ -28,6 +28,8 @@ import (
 	discoveryfake "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/dynamic/fake"
 	kubetesting "k8s.io/client-go/testing"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -90,3 +92,59 @@ spec:
 	assert.Equal(t, "Pod", r.GetKind())
 	assert.Equal(t, "applier", r.GetLabels()["component"])
 }
+
+func TestApplierDefaultsNamespaceFromDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "applier-test-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "applier-ns"), 0700))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "Not_A_Namespace"), 0700))
+	manifests := `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: namespaceless
+---
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: explicit
+  namespace: kube-system
+---
+kind: Node
+apiVersion: v1
+metadata:
+  name: cluster-scoped
+`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "applier-ns", "test.yaml"), []byte(manifests), 0400))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Not_A_Namespace", "ignored.yaml"), []byte(manifests), 0400))
+
+	namespaces, err := NamespaceDirs(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"applier-ns"}, namespaces)
+
+	a := NewApplier(dir)
+	a.client = fake.NewSimpleDynamicClient(runtime.NewScheme())
+	fakeDiscoveryClient := &discoveryfake.FakeDiscovery{Fake: &kubetesting.Fake{}}
+	fakeDiscoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: corev1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "nodes", Namespaced: false, Kind: "Node"},
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap"},
+			},
+		},
+	}
+	a.discoveryClient = memory.NewMemCacheClient(fakeDiscoveryClient)
+	assert.NoError(t, a.Apply())
+
+	gv, _ := schema.ParseResourceArg("configmaps.v1.")
+	_, err = a.client.Resource(*gv).Namespace("applier-ns").Get(context.Background(), "namespaceless", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = a.client.Resource(*gv).Namespace("kube-system").Get(context.Background(), "explicit", metav1.GetOptions{})
+	assert.NoError(t, err)
+	nodegv, _ := schema.ParseResourceArg("nodes.v1.")
+	r, err := a.client.Resource(*nodegv).Get(context.Background(), "cluster-scoped", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", r.GetNamespace())
+}
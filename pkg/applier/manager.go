@@ -23,6 +23,7 @@ import (
 	"github.com/k0sproject/k0s/pkg/constant"
 	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
 	"github.com/k0sproject/k0s/pkg/leaderelection"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -32,6 +33,11 @@ import (
 
 // Manager is the Component interface wrapper for Applier
 type Manager struct {
+	// ServerSideApply makes the stacks be applied with server-side apply using the "k0s" field manager
+	ServerSideApply bool
+	// ForceConflicts makes server-side apply take over fields owned by other field managers
+	ForceConflicts bool
+
 	client               kubernetes.Interface
 	applier              Applier
 	cancelWatcher        context.CancelFunc
@@ -47,7 +53,7 @@ func (m *Manager) Init() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to create manifest bundle dir %s", constant.ManifestsDir)
 	}
-	m.log = logrus.WithField("component", "applier-manager")
+	m.log = logging.ForComponent("applier-manager")
 	m.stacks = make(map[string]*StackApplier)
 	m.bundlePath = constant.ManifestsDir
 
@@ -124,7 +130,7 @@ func (m *Manager) watchLeaseEvents(events *leaderelection.LeaseEvents) {
 }
 
 func (m *Manager) runWatchers(ctx context.Context) error {
-	log := logrus.WithField("component", "applier-manager")
+	log := logging.ForComponent("applier-manager")
 
 	dirs, err := util.GetAllDirs(m.bundlePath)
 	if err != nil {
@@ -188,6 +194,8 @@ func (m *Manager) createStack(name string) error {
 	if err != nil {
 		return err
 	}
+	sa.applier.ServerSideApply = m.ServerSideApply
+	sa.applier.ForceConflicts = m.ForceConflicts
 
 	go func() {
 		_ = sa.Start()
@@ -46,15 +46,25 @@ const (
 
 	// LastConfigAnnotation defines the annotation to be used for last applied configs
 	LastConfigAnnotation = "k0s.k0sproject.io/last-applied-configuration"
+
+	// FieldManager is the field manager name used for server-side apply
+	FieldManager = "k0s"
 )
 
 // Stack is a k8s resource bundle
 type Stack struct {
-	Name          string
-	Resources     []*unstructured.Unstructured
-	keepResources []string
-	Client        dynamic.Interface
-	Discovery     discovery.CachedDiscoveryInterface
+	Name      string
+	Resources []*unstructured.Unstructured
+	// DefaultNamespaces holds the namespace of the directory the resources were read from, namespaced resources
+	// without an explicit namespace are applied into it
+	DefaultNamespaces map[*unstructured.Unstructured]string
+	keepResources     []string
+	Client            dynamic.Interface
+	Discovery         discovery.CachedDiscoveryInterface
+	// ServerSideApply makes the stack use server-side apply instead of client-side patching
+	ServerSideApply bool
+	// ForceConflicts takes over fields owned by other field managers when server-side applying
+	ForceConflicts bool
 }
 
 // Apply applies stack resources by creating or updating the resources. If prune is requested,
@@ -76,11 +86,14 @@ func (s *Stack) Apply(ctx context.Context, prune bool) error {
 		}
 	}
 	for _, resource := range sortedResources {
-		s.prepareResource(resource)
 		mapping, err := mapper.RESTMapping(resource.GroupVersionKind().GroupKind(), resource.GroupVersionKind().Version)
 		if err != nil {
 			return fmt.Errorf("mapping error: %s", err)
 		}
+		if ns, ok := s.DefaultNamespaces[resource]; ok && resource.GetNamespace() == "" && mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			resource.SetNamespace(ns)
+		}
+		s.prepareResource(resource)
 		var drClient dynamic.ResourceInterface
 		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
 			drClient = s.Client.Resource(mapping.Resource).Namespace(resource.GetNamespace())
@@ -88,7 +101,16 @@ func (s *Stack) Apply(ctx context.Context, prune bool) error {
 			drClient = s.Client.Resource(mapping.Resource)
 		}
 		serverResource, err := drClient.Get(ctx, resource.GetName(), metav1.GetOptions{})
-		if apiErrors.IsNotFound(err) {
+		if s.ServerSideApply {
+			if err != nil && !apiErrors.IsNotFound(err) {
+				return fmt.Errorf("unknown api error: %s", err)
+			}
+			if err == nil && serverResource.GetAnnotations()[ChecksumAnnotation] == resource.GetAnnotations()[ChecksumAnnotation] {
+				log.Debug("resource checksums match, no need to apply")
+			} else if err := s.serverSideApply(ctx, drClient, resource); err != nil {
+				return err
+			}
+		} else if apiErrors.IsNotFound(err) {
 			_, err := drClient.Create(ctx, resource, metav1.CreateOptions{})
 			if err != nil {
 				return fmt.Errorf("cannot create resource %s: %s", resource.GetName(), err)
@@ -355,6 +377,25 @@ func (s *Stack) patchResource(ctx context.Context, drClient dynamic.ResourceInte
 	return nil
 }
 
+func (s *Stack) serverSideApply(ctx context.Context, drClient dynamic.ResourceInterface, resource *unstructured.Unstructured) error {
+	data, err := resource.MarshalJSON()
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal resource %s", resource.GetName())
+	}
+	force := s.ForceConflicts
+	_, err = drClient.Patch(ctx, resource.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if apiErrors.IsConflict(err) {
+		return fmt.Errorf("can't apply resource %s, fields are owned by other managers (enable forceConflicts to take them over): %v", resource.GetName(), err)
+	}
+	if err != nil {
+		return fmt.Errorf("can't apply resource %s: %v", resource.GetName(), err)
+	}
+	return nil
+}
+
 func (s *Stack) prepareResource(resource *unstructured.Unstructured) {
 	checksum := resourceChecksum(resource)
 	lastAppliedConfig, _ := resource.MarshalJSON()
@@ -371,7 +412,10 @@ func (s *Stack) prepareResource(resource *unstructured.Unstructured) {
 		annotations = map[string]string{}
 	}
 	annotations[ChecksumAnnotation] = checksum
-	annotations[LastConfigAnnotation] = string(lastAppliedConfig)
+	// the api server tracks the applied configuration itself with server-side apply
+	if !s.ServerSideApply {
+		annotations[LastConfigAnnotation] = string(lastAppliedConfig)
+	}
 	resource.SetAnnotations(annotations)
 }
 
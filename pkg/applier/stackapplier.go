@@ -16,11 +16,13 @@ limitations under the License.
 package applier
 
 import (
+	"path/filepath"
 	"time"
 
 	"k8s.io/client-go/util/retry"
 
 	"github.com/k0sproject/k0s/pkg/debounce"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/fsnotify.v1"
 )
@@ -46,22 +48,39 @@ func NewStackApplier(path string) (*StackApplier, error) {
 		return nil, err
 	}
 	applier := NewApplier(path)
-	log := logrus.WithField("component", "applier-"+applier.Name)
+	log := logging.ForComponent("applier-" + applier.Name)
 	log.WithField("path", path).Debug("created stack applier")
 
-	return &StackApplier{
+	sa := &StackApplier{
 		Path:      path,
 		fsWatcher: watcher,
 		applier:   applier,
 		log:       log,
 		done:      make(chan bool, 1),
-	}, nil
+	}
+	sa.watchNamespaceDirs()
+	return sa, nil
+}
+
+// watchNamespaceDirs adds the namespace subdirectories of the stack to the watcher, adding a watched dir again is a no-op
+func (s *StackApplier) watchNamespaceDirs() {
+	namespaces, err := NamespaceDirs(s.Path)
+	if err != nil {
+		s.log.WithError(err).Warn("failed to list namespace directories")
+		return
+	}
+	for _, ns := range namespaces {
+		if err := s.fsWatcher.Add(filepath.Join(s.Path, ns)); err != nil {
+			s.log.WithError(err).Warnf("failed to watch namespace directory %s", ns)
+		}
+	}
 }
 
 // Start both the initial apply and also the watch for a single stack
 func (s *StackApplier) Start() error {
 	debouncer := debounce.New(5*time.Second, s.fsWatcher.Events, func(arg fsnotify.Event) {
 		s.log.Debug("debouncer triggering, applying...")
+		s.watchNamespaceDirs()
 		err := retry.OnError(retry.DefaultRetry, func(err error) bool {
 			return true
 		}, s.applier.Apply)
@@ -91,7 +91,7 @@ func Stage(dataDir string, name string, filemode os.FileMode, group string) erro
 
 	if ExecutableIsOlder(p) {
 		logrus.Debug("Re-use existing file:", p)
-		return nil
+		return VerifyBinary(p)
 	}
 
 	gzname := "bin/" + name + ".gz"
@@ -142,5 +142,5 @@ func Stage(dataDir string, name string, filemode os.FileMode, group string) erro
 		return errors.Wrapf(err, "failed to write to %s", name)
 	}
 
-	return nil
+	return verifySum(p, BinSums[gzname])
 }
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/sirupsen/logrus"
+)
+
+type verifiedBinary struct {
+	modTime time.Time
+	size    int64
+	sum     string
+}
+
+var (
+	verifiedMu sync.Mutex
+	verified   = map[string]verifiedBinary{}
+)
+
+// VerifyBinary checks the sha256 sum of a binary in constant.BinDir against the one of the embedded
+// binary recorded at build time. Binaries outside of constant.BinDir and those not embedded, e.g.
+// when k0s is built without them, are not verified.
+func VerifyBinary(path string) error {
+	if filepath.Dir(path) != constant.BinDir {
+		return nil
+	}
+	name := filepath.Base(path)
+	expected, embedded := BinSums["bin/"+name+".gz"]
+	if !embedded {
+		logrus.Debugf("no checksum for %s, not verifying it", name)
+		return nil
+	}
+
+	return verifySum(path, expected)
+}
+
+// verifySum checks the sha256 sum of the file at path. A file verified before is hashed again only once it changed,
+// so that respawning a crashing process doesn't re-read its binary each time.
+func verifySum(path string, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	verifiedMu.Lock()
+	cached, ok := verified[path]
+	verifiedMu.Unlock()
+	if ok && cached.sum == expected && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(hash.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s. refusing to run it, remove the file to have k0s extract it again", path, expected, actual)
+	}
+	logrus.Infof("Verified %s, sha256 %s", path, actual)
+
+	verifiedMu.Lock()
+	defer verifiedMu.Unlock()
+	verified[path] = verifiedBinary{modTime: info.ModTime(), size: info.Size(), sum: actual}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package assets
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySum(t *testing.T) {
+	f, err := ioutil.TempFile("", "k0s-bin")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("foo")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	assert.NoError(t, verifySum(f.Name(), "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"))
+
+	err = verifySum(f.Name(), "0000")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestVerifySumCached(t *testing.T) {
+	f, err := ioutil.TempFile("", "k0s-bin")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("foo")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	info, err := os.Stat(f.Name())
+	require.NoError(t, err)
+
+	require.NoError(t, verifySum(f.Name(), "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"))
+
+	// not hashed again while the size and modification time are the same
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("bar"), 0644))
+	require.NoError(t, os.Chtimes(f.Name(), info.ModTime(), info.ModTime()))
+	assert.NoError(t, verifySum(f.Name(), "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"))
+
+	// but once the binary changed
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("foobar"), 0644))
+	err = verifySum(f.Name(), "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestVerifyBinaryOutsideBinDir(t *testing.T) {
+	assert.NoError(t, VerifyBinary("/usr/bin/does-not-exist"))
+}
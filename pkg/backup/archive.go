@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// names of the entries in the backup archive
+const (
+	ConfigName       = "k0s.yaml"
+	CertsDirName     = "pki"
+	EtcdSnapshotName = "etcd-snapshot.db"
+	KineDBName       = "kine-state.db"
+)
+
+// Archive writes the files of a backup into a gzipped tarball
+type Archive struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+// NewArchive creates an Archive writing to w
+func NewArchive(w io.Writer) *Archive {
+	gz := gzip.NewWriter(w)
+	return &Archive{
+		gz: gz,
+		tw: tar.NewWriter(gz),
+	}
+}
+
+// AddBytes adds a file with the given content to the archive
+func (a *Archive) AddBytes(name string, data []byte, mode os.FileMode) error {
+	hdr := &tar.Header{
+		Name: name,
+		Mode: int64(mode.Perm()),
+		Size: int64(len(data)),
+	}
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "failed to add %s to the backup", name)
+	}
+	_, err := a.tw.Write(data)
+	return errors.Wrapf(err, "failed to add %s to the backup", name)
+}
+
+// AddFile adds the file at path to the archive with the given name
+func (a *Archive) AddFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add %s to the backup", path)
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to add %s to the backup", path)
+	}
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to add %s to the backup", path)
+	}
+	hdr.Name = name
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "failed to add %s to the backup", path)
+	}
+	_, err = io.Copy(a.tw, f)
+	return errors.Wrapf(err, "failed to add %s to the backup", path)
+}
+
+// AddDir adds the regular files under dir to the archive, below the given name
+func (a *Archive) AddDir(name, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return a.AddFile(filepath.ToSlash(filepath.Join(name, rel)), path)
+	})
+}
+
+// Close flushes the archive, it does not close the underlying writer
+func (a *Archive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pki", "etcd"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pki", "ca.crt"), []byte("ca"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pki", "etcd", "ca.key"), []byte("etcd ca"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "snapshot"), []byte("snapshot"), 0600))
+
+	buf := &bytes.Buffer{}
+	archive := NewArchive(buf)
+	require.NoError(t, archive.AddFile(EtcdSnapshotName, filepath.Join(dir, "snapshot")))
+	require.NoError(t, archive.AddBytes(ConfigName, []byte("spec: {}"), 0600))
+	require.NoError(t, archive.AddDir(CertsDirName, filepath.Join(dir, "pki")))
+	require.NoError(t, archive.Close())
+
+	gz, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+
+	assert.Equal(t, map[string]string{
+		"etcd-snapshot.db": "snapshot",
+		"k0s.yaml":         "spec: {}",
+		"pki/ca.crt":       "ca",
+		"pki/etcd/ca.key":  "etcd ca",
+	}, files)
+}
+
+func TestExtract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extract")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buf := &bytes.Buffer{}
+	archive := NewArchive(buf)
+	require.NoError(t, archive.AddBytes(MetadataName, []byte("k0sVersion: v0.8.0"), 0600))
+	require.NoError(t, archive.AddBytes("pki/etcd/ca.key", []byte("etcd ca"), 0600))
+	require.NoError(t, archive.Close())
+
+	names, err := Extract(buf, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{MetadataName, "pki/etcd/ca.key"}, names)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "pki", "etcd", "ca.key"))
+	require.NoError(t, err)
+	assert.Equal(t, "etcd ca", string(data))
+
+	t.Run("paths outside the directory are rejected", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		archive := NewArchive(buf)
+		require.NoError(t, archive.AddBytes("../escape", []byte("x"), 0600))
+		require.NoError(t, archive.Close())
+
+		_, err := Extract(buf, dir)
+		assert.Error(t, err)
+		assert.False(t, util.FileExists(filepath.Join(filepath.Dir(dir), "escape")))
+	})
+}
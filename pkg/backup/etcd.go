@@ -0,0 +1,58 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/clientv3/snapshot"
+	"go.uber.org/zap"
+)
+
+// RestoreEtcdSnapshot restores the snapshot into a new etcd data directory, as etcdctl snapshot restore does. The
+// member is restored as the single member of a new cluster, the other controllers join it as new members.
+func RestoreEtcdSnapshot(snapshotPath, dataDir, name, peerURL string) error {
+	if entries, err := readDirNames(dataDir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("the etcd data directory %s is not empty", dataDir)
+	}
+	// etcd refuses to restore into an existing directory, even an empty one
+	if err := os.RemoveAll(dataDir); err != nil {
+		return err
+	}
+
+	err := snapshot.NewV3(zap.NewNop()).Restore(snapshot.RestoreConfig{
+		SnapshotPath:        snapshotPath,
+		Name:                name,
+		OutputDataDir:       dataDir,
+		OutputWALDir:        filepath.Join(dataDir, "member", "wal"),
+		PeerURLs:            []string{peerURL},
+		InitialCluster:      fmt.Sprintf("%s=%s", name, peerURL),
+		InitialClusterToken: "etcd-cluster",
+	})
+	return errors.Wrap(err, "failed to restore the etcd snapshot")
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
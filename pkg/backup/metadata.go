@@ -0,0 +1,75 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MetadataName is the name of the metadata entry in the backup archive
+const MetadataName = "metadata.yaml"
+
+// Metadata describes the k0s a backup was taken with
+type Metadata struct {
+	K0sVersion  string    `yaml:"k0sVersion"`
+	StorageType string    `yaml:"storageType"`
+	CreatedAt   time.Time `yaml:"createdAt"`
+}
+
+// IncompatibleVersionError is returned when a backup was taken with another minor or major version of k0s
+type IncompatibleVersionError struct {
+	BackupVersion  string
+	CurrentVersion string
+}
+
+func (e *IncompatibleVersionError) Error() string {
+	return fmt.Sprintf("the backup was taken with k0s %s, which is not compatible with k0s %s", e.BackupVersion, e.CurrentVersion)
+}
+
+// CheckVersion returns an IncompatibleVersionError if the backup was taken with another major.minor version of k0s
+// than the current one, the format of the state may change with every minor version before 1.0. Development builds
+// without a release version are assumed compatible.
+func (m *Metadata) CheckVersion(currentVersion string) error {
+	backupMinor, backupOK := minorVersion(m.K0sVersion)
+	currentMinor, currentOK := minorVersion(currentVersion)
+	if backupOK && currentOK && backupMinor != currentMinor {
+		return &IncompatibleVersionError{BackupVersion: m.K0sVersion, CurrentVersion: currentVersion}
+	}
+	return nil
+}
+
+// minorVersion returns the major.minor version of a version such as v0.8.1, false if the version is not a release
+// version
+func minorVersion(version string) (string, bool) {
+	version = strings.TrimPrefix(version, "v")
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return "", false
+	}
+	for _, part := range parts[:2] {
+		if part == "" {
+			return "", false
+		}
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return "", false
+			}
+		}
+	}
+	return parts[0] + "." + parts[1], true
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataCheckVersion(t *testing.T) {
+	tests := []struct {
+		backup     string
+		current    string
+		compatible bool
+	}{
+		{"v0.8.0", "v0.8.1", true},
+		{"v0.8.1-rc.1", "v0.8.0", true},
+		{"v0.7.0", "v0.8.1", false},
+		{"v0.8.0", "v1.0.0", false},
+		{"v1.2.0", "v0.9.0", false},
+		{"dev", "v1.0.0", true},
+		{"v0.8.0", "dev", true},
+	}
+	for _, tt := range tests {
+		m := &Metadata{K0sVersion: tt.backup}
+		err := m.CheckVersion(tt.current)
+		if tt.compatible {
+			assert.NoError(t, err, "%s -> %s", tt.backup, tt.current)
+		} else {
+			assert.IsType(t, &IncompatibleVersionError{}, err, "%s -> %s", tt.backup, tt.current)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Replace moves the restored file or directory staged next to dst into its place. An existing dst is kept renamed
+// with the backup suffix, as are the stale files belonging to it, e.g. the write-ahead log of a sqlite database.
+// staged has to be on the same filesystem as dst for the rename to be atomic.
+func Replace(staged, dst string, stale []string, backupSuffix string) error {
+	for _, path := range append([]string{dst}, stale...) {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(path, path+backupSuffix); err != nil {
+			return errors.Wrapf(err, "failed to move the existing %s aside", path)
+		}
+	}
+	return errors.Wrapf(os.Rename(staged, dst), "failed to move the restored %s into place", dst)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replace")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "pki")
+	staged := dst + ".restore"
+	require.NoError(t, os.MkdirAll(staged, 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(staged, "ca.crt"), []byte("restored"), 0644))
+
+	t.Run("fresh", func(t *testing.T) {
+		require.NoError(t, Replace(staged, dst, nil, ".bak"))
+		data, err := ioutil.ReadFile(filepath.Join(dst, "ca.crt"))
+		require.NoError(t, err)
+		assert.Equal(t, "restored", string(data))
+		assert.NoFileExists(t, staged)
+		assert.NoFileExists(t, dst+".bak")
+	})
+
+	t.Run("existing is kept aside", func(t *testing.T) {
+		db := filepath.Join(dir, "state.db")
+		require.NoError(t, ioutil.WriteFile(db, []byte("old"), 0600))
+		require.NoError(t, ioutil.WriteFile(db+"-wal", []byte("old wal"), 0600))
+		require.NoError(t, ioutil.WriteFile(db+".restore", []byte("restored"), 0600))
+
+		require.NoError(t, Replace(db+".restore", db, []string{db + "-wal", db + "-shm"}, ".bak"))
+		data, err := ioutil.ReadFile(db)
+		require.NoError(t, err)
+		assert.Equal(t, "restored", string(data))
+		data, err = ioutil.ReadFile(db + ".bak")
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(data))
+		assert.NoFileExists(t, db+"-wal")
+		assert.FileExists(t, db+"-wal.bak")
+		assert.NoFileExists(t, db+"-shm.bak")
+	})
+}
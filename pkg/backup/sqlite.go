@@ -0,0 +1,52 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CopySQLite writes a consistent copy of the sqlite database at dbPath to dst with the online backup of the sqlite3
+// command, which is safe while kine keeps writing to the database, unlike copying the file
+func CopySQLite(dbPath, dst string) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return fmt.Errorf("backing up the kine database needs the sqlite3 command, install it on this node")
+	}
+	// the backup overwrites the pages of an existing destination database, but it must not merge into anything else
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// sqlite3 waits for the lock held by kine instead of failing immediately
+	out, err := exec.Command("sqlite3", "-cmd", ".timeout 30000", dbPath, ".backup "+quoteDotArg(dst)).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "failed to back up the database %s: %s", dbPath, out)
+	}
+	// the sqlite3 shell reports some failures of dot commands only in its output
+	if len(strings.TrimSpace(string(out))) > 0 {
+		return fmt.Errorf("failed to back up the database %s: %s", dbPath, out)
+	}
+	return os.Chmod(dst, 0600)
+}
+
+// quoteDotArg quotes an argument of a sqlite3 shell dot command, which resolves backslash escapes in double quotes
+func quoteDotArg(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
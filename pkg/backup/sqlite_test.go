@@ -0,0 +1,52 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopySQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	dir, err := ioutil.TempDir("", "sqlite")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dbPath := filepath.Join(dir, "db")
+	out, err := exec.Command("sqlite3", dbPath, "PRAGMA journal_mode=WAL; CREATE TABLE kine (name TEXT); INSERT INTO kine VALUES ('a'), ('b');").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// a stale file in the way is replaced, and the quotes in the path are kept
+	dst := filepath.Join(dir, `kine "backup" \ db`)
+	require.NoError(t, ioutil.WriteFile(dst, []byte("garbage"), 0644))
+	require.NoError(t, CopySQLite(dbPath, dst))
+
+	out, err = exec.Command("sqlite3", dst, "SELECT count(*) FROM kine;").CombinedOutput()
+	require.NoError(t, err, string(out))
+	assert.Equal(t, "2\n", string(out))
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
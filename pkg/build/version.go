@@ -1,6 +1,30 @@
 package build
 
-// Version gets overridden at build time using -X main.Version=$VERSION
+import (
+	"fmt"
+	"runtime"
+)
+
+// Version and GitCommit get overridden at build time using -X github.com/k0sproject/k0s/pkg/build.Version=$VERSION
 var (
-	Version = "dev"
+	Version   = "dev"
+	GitCommit = "unknown"
 )
+
+// Info describes the build of the running k0s binary
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// GetInfo returns the build info of the running k0s binary
+func GetInfo() Info {
+	return Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		GoVersion: runtime.Version(),
+		Platform:  fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+	}
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// DefaultExpiryWarningWindow is how long before their expiry the certificates are warned about if not configured otherwise
+const DefaultExpiryWarningWindow = 30 * 24 * time.Hour
+
+// Info describes a certificate found in the certificate directory
+type Info struct {
+	// Name is the path of the certificate relative to the certificate directory, without the .crt extension
+	Name     string
+	Subject  string
+	IsCA     bool
+	NotAfter time.Time
+}
+
+// ExpiresWithin returns true if the certificate expires within the window from now, or has expired already
+func (i Info) ExpiresWithin(window time.Duration, now time.Time) bool {
+	return i.NotAfter.Before(now.Add(window))
+}
+
+// List returns the certificates under constant.CertRootDir, sorted by name. The trusted CA bundle is not
+// generated by k0s and left out.
+func (m *Manager) List() ([]Info, error) {
+	return listCertificates(constant.CertRootDir)
+}
+
+// Info returns the details of the certificate with the given name under constant.CertRootDir
+func (m *Manager) Info(name string) (Info, error) {
+	info, err := readCertificateInfo(filepath.Join(constant.CertRootDir, name+".crt"))
+	info.Name = name
+	return info, err
+}
+
+// MissingHostnames returns the hostnames the certificate with the given name under constant.CertRootDir isn't valid for
+func (m *Manager) MissingHostnames(name string, hostnames []string) ([]string, error) {
+	cert, err := readCertificate(filepath.Join(constant.CertRootDir, name+".crt"))
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for _, hostname := range hostnames {
+		if cert.VerifyHostname(hostname) != nil {
+			missing = append(missing, hostname)
+		}
+	}
+	return missing, nil
+}
+
+func listCertificates(dir string) ([]Info, error) {
+	var certs []Info
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || filepath.Ext(path) != ".crt" || path == constant.TrustedCABundlePath {
+			return nil
+		}
+		info, err := readCertificateInfo(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info.Name = strings.TrimSuffix(rel, ".crt")
+		certs = append(certs, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(certs, func(i, j int) bool { return certs[i].Name < certs[j].Name })
+	return certs, nil
+}
+
+// readCertificateInfo reads the first certificate of the PEM file
+func readCertificateInfo(path string) (Info, error) {
+	cert, err := readCertificate(path)
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{
+		Subject:  cert.Subject.String(),
+		IsCA:     cert.IsCA,
+		NotAfter: cert.NotAfter,
+	}, nil
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded certificate", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the certificate in %s: %s", path, err.Error())
+	}
+	return cert, nil
+}
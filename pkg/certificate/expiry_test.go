@@ -0,0 +1,71 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCertificate(t *testing.T, path, cn string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+}
+
+func TestListCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-pki")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now().Truncate(time.Second)
+	writeTestCertificate(t, filepath.Join(dir, "server.crt"), "kubernetes", now.Add(10*24*time.Hour))
+	writeTestCertificate(t, filepath.Join(dir, "etcd", "peer.crt"), "etcd-peer", now.Add(365*24*time.Hour))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "server.key"), []byte("not a certificate"), 0600))
+
+	certs, err := listCertificates(dir)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	assert.Equal(t, "etcd/peer", certs[0].Name)
+	assert.Equal(t, "server", certs[1].Name)
+	assert.Equal(t, "CN=kubernetes", certs[1].Subject)
+	assert.True(t, certs[1].NotAfter.Equal(now.Add(10*24*time.Hour)))
+
+	assert.True(t, certs[1].ExpiresWithin(DefaultExpiryWarningWindow, now))
+	assert.False(t, certs[0].ExpiresWithin(DefaultExpiryWarningWindow, now))
+}
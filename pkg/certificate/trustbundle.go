@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// systemCABundles are the locations of the system CA bundle on the common distributions
+var systemCABundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian/Ubuntu/Gentoo etc.
+	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora/RHEL 6
+	"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
+	"/etc/pki/tls/cacert.pem",                           // OpenELEC
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS/RHEL 7
+	"/etc/ssl/cert.pem",                                 // Alpine Linux
+}
+
+// InstallTrustedCABundle writes the system CA bundle together with the given CA certificates to
+// constant.TrustedCABundlePath and points SSL_CERT_FILE to it. The variable is inherited by all
+// the supervised processes, so both k0s itself and the components trust the given CAs.
+func InstallTrustedCABundle(bundle []byte) error {
+	combined := bytes.NewBuffer([]byte{})
+	for _, systemBundle := range systemCABundles {
+		if !util.FileExists(systemBundle) {
+			continue
+		}
+		data, err := ioutil.ReadFile(systemBundle)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read system CA bundle %s", systemBundle)
+		}
+		combined.Write(data)
+		combined.WriteString("\n")
+		break
+	}
+	if combined.Len() == 0 {
+		logrus.Warn("no system CA bundle found, only the configured trusted CAs are trusted")
+	}
+	combined.Write(bundle)
+
+	if err := ioutil.WriteFile(constant.TrustedCABundlePath, combined.Bytes(), constant.CertMode); err != nil {
+		return errors.Wrapf(err, "failed to write trusted CA bundle")
+	}
+	return os.Setenv("SSL_CERT_FILE", constant.TrustedCABundlePath)
+}
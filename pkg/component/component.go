@@ -22,3 +22,64 @@ type Component interface {
 	Stop() error
 	Healthy() error
 }
+
+// StatusReporter is implemented by components which can describe their current state for the status command
+type StatusReporter interface {
+	Component
+	// Status returns a short human readable description of the component state
+	Status() string
+}
+
+// VersionReporter is implemented by components running a binary, to tell which version they launched
+type VersionReporter interface {
+	Component
+	// Version returns the version of the launched binary
+	Version() string
+}
+
+// Restartable is implemented by components which can be stopped and run again
+// while the rest of the node keeps running
+type Restartable interface {
+	Component
+	// DependsOn returns the managed components that must be running for this component to work
+	DependsOn() []Component
+}
+
+// Supervised is implemented by components running a process the manager restarts, with a backoff,
+// when it exits on its own
+type Supervised interface {
+	Component
+	// Exited returns the channel receiving why the process of the component exited unexpectedly
+	Exited() <-chan error
+}
+
+// ReadinessDependent is implemented by components which must not run before other components are ready,
+// e.g. addons crash looping until the CNI works
+type ReadinessDependent interface {
+	Component
+	// ReadinessDependencies returns the names of the components which must be ready first
+	ReadinessDependencies() []string
+}
+
+// ReadinessReporter is implemented by components which can tell when their dependents can run
+type ReadinessReporter interface {
+	Component
+	// Ready returns nil once the component is ready for its dependents
+	Ready() error
+}
+
+// ProgressReporter is notified as the manager initializes and starts the components
+type ProgressReporter interface {
+	// Started is called when the stage, initializing or starting, of the named component begins
+	Started(stage, name string)
+	// Finished is called when the stage of the named component ends, with the error it failed with if any
+	Finished(stage, name string, err error)
+}
+
+// StateObserver is notified as the state of the managed components changes
+type StateObserver interface {
+	// ComponentUp is called with true when the named component was started, and with false when it stopped or exited
+	ComponentUp(name string, up bool)
+	// ComponentHealthy is called with the outcome of every health check of the named component
+	ComponentHealthy(name string, healthy bool)
+}
@@ -16,31 +16,94 @@ limitations under the License.
 package component
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
+
+	"github.com/k0sproject/k0s/pkg/logging"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+)
+
+// healthPollInterval is how often Ready checks the components still not healthy
+var healthPollInterval = time.Second
+
+// the restarts of a supervised component back off from restartDelay, doubling it on each attempt up to
+// restartMaxDelay. The component is considered running again once it didn't exit for restartStableAfter.
+var (
+	restartDelay       = time.Second
+	restartMaxDelay    = time.Minute
+	restartStableAfter = 30 * time.Second
 )
 
+// StopTimeoutMargin is how much longer than the grace period of its process between SIGTERM and SIGKILL a supervised
+// component gets to stop, so that the process is killed before the manager abandons the component
+const StopTimeoutMargin = 5 * time.Second
+
+// DefaultStopTimeout is the time a component gets to stop before the manager abandons it
+const DefaultStopTimeout = supervisor.DefaultTimeoutStop + StopTimeoutMargin
+
 // Manager manages components
 type Manager struct {
-	components []Component
-	sync       map[string]bool
+	components  []Component
+	sync        map[string]bool
+	mu          sync.Mutex
+	progress    ProgressReporter
+	observer    StateObserver
+	stopTimeout time.Duration
+	stopped     bool
+	stopping    chan struct{}
+	// exited are the supervised components waiting to be restarted
+	exited map[Component]bool
+}
+
+// RestartRejectedError is returned when a component cannot be restarted on its own
+type RestartRejectedError struct {
+	Name   string
+	Reason string
+}
+
+func (e *RestartRejectedError) Error() string {
+	return fmt.Sprintf("cannot restart %s: %s", e.Name, e.Reason)
 }
 
 // NewManager creates a manager
 func NewManager() *Manager {
 	return &Manager{
-		components: []Component{},
+		components:  []Component{},
+		stopTimeout: DefaultStopTimeout,
+		stopping:    make(chan struct{}),
 	}
 }
 
+// SetStopTimeout sets the time each component gets to stop, zero waits for the components indefinitely
+func (m *Manager) SetStopTimeout(timeout time.Duration) {
+	m.stopTimeout = timeout
+}
+
 // Add adds a component to the manager
 func (m *Manager) Add(component Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.components = append(m.components, component)
 }
 
+// AddRunning adds a component which was initialized and run already, e.g. the worker components embedded in
+// the server, and restarts it if it is supervised
+func (m *Manager) AddRunning(component Component) {
+	m.Add(component)
+	if supervised, ok := component.(Supervised); ok {
+		go m.supervise(supervised)
+	}
+}
+
 // AddSync adds a component to the manager that should be initialized synchronously
 func (m *Manager) AddSync(component Component) {
 	m.components = append(m.components, component)
@@ -51,6 +114,16 @@ func (m *Manager) AddSync(component Component) {
 	m.sync[compName] = true
 }
 
+// ReportProgress sets the reporter notified as the components are initialized and started
+func (m *Manager) ReportProgress(progress ProgressReporter) {
+	m.progress = progress
+}
+
+// ObserveState sets the observer notified as the components start, stop and are checked for health
+func (m *Manager) ObserveState(observer StateObserver) {
+	m.observer = observer
+}
+
 // Init initializes all managed components
 func (m *Manager) Init() error {
 	g := new(errgroup.Group)
@@ -59,13 +132,14 @@ func (m *Manager) Init() error {
 		compName := reflect.TypeOf(comp).Elem().Name()
 		logrus.Infof("initializing %v\n", compName)
 		c := comp
+		m.notifyUp(c, false)
 		if m.sync[compName] {
-			if err := c.Init(); err != nil {
+			if err := m.runStage("initializing", c, c.Init); err != nil {
 				return err
 			}
 		} else {
 			// init this async
-			g.Go(c.Init)
+			g.Go(func() error { return m.runStage("initializing", c, c.Init) })
 		}
 	}
 	err := g.Wait()
@@ -77,18 +151,200 @@ func (m *Manager) Start() error {
 	for _, comp := range m.components {
 		compName := reflect.TypeOf(comp).Elem().Name()
 		logrus.Infof("starting %v", compName)
-		if err := comp.Run(); err != nil {
+		if err := m.runStage("starting", comp, comp.Run); err != nil {
 			return err
 		}
+		m.notifyUp(comp, true)
+		if supervised, ok := comp.(Supervised); ok {
+			go m.supervise(supervised)
+		}
+	}
+	return nil
+}
+
+// supervise restarts the component whenever its process exits on its own, until the manager is stopped. Failing
+// restarts are retried indefinitely, backing off up to restartMaxDelay.
+func (m *Manager) supervise(comp Supervised) {
+	log := logging.ForComponent(Name(comp))
+	for {
+		select {
+		case err := <-comp.Exited():
+			log.Warnf("exited unexpectedly: %s", err)
+			m.markExited(comp)
+			m.notifyUp(comp, false)
+		case <-m.stopping:
+			return
+		}
+
+		delay, capped := restartDelay, false
+		for attempt := 1; ; attempt++ {
+			err := m.restart(comp, log)
+			if err == nil {
+				break
+			}
+			switch {
+			case delay < restartMaxDelay:
+				log.Warnf("restart attempt %d failed: %s", attempt, err)
+			case !capped:
+				// logged once, the attempts from now on are only logged at debug level
+				log.Errorf("restart attempt %d failed, retrying every %s from now on: %s", attempt, restartMaxDelay, err)
+				capped = true
+			default:
+				log.Debugf("restart attempt %d failed: %s", attempt, err)
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-m.stopping:
+				return
+			}
+			delay *= 2
+			if delay > restartMaxDelay {
+				delay = restartMaxDelay
+			}
+		}
+	}
+}
+
+// restart runs the exited component again and waits for it to keep running for restartStableAfter. It returns an
+// error if the component failed to run or exited again in the meantime.
+func (m *Manager) restart(comp Supervised, log *logrus.Entry) error {
+	m.mu.Lock()
+	// nothing to do if the manager is stopping or the component was restarted in between, e.g. by Restart
+	if m.stopped || !m.exited[comp] {
+		m.mu.Unlock()
+		return nil
+	}
+	log.Info("restarting")
+	err := comp.Run()
+	if err == nil {
+		delete(m.exited, comp)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	m.notifyUp(comp, true)
+	select {
+	case err := <-comp.Exited():
+		m.markExited(comp)
+		m.notifyUp(comp, false)
+		return fmt.Errorf("exited again within %s: %s", restartStableAfter, err)
+	case <-time.After(restartStableAfter):
+		log.Info("running again")
+	case <-m.stopping:
 	}
 	return nil
 }
 
-// Stop stops all managed components
+// Ready waits until all managed components report healthy, or returns an error naming the unhealthy ones
+// once the context is done. A single health check is not interrupted by the context.
+func (m *Manager) Ready(ctx context.Context) error {
+	m.mu.Lock()
+	pending := append([]Component(nil), m.components...)
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+	for {
+		var unhealthy []Component
+		var reasons []string
+		for _, comp := range pending {
+			err := comp.Healthy()
+			m.notifyHealthy(comp, err == nil)
+			if err != nil {
+				unhealthy = append(unhealthy, comp)
+				reasons = append(reasons, fmt.Sprintf("%s: %s", Name(comp), err.Error()))
+			}
+		}
+		if len(unhealthy) == 0 {
+			return nil
+		}
+		pending = unhealthy
+		logrus.Debugf("waiting for components to become healthy: %s", strings.Join(reasons, "; "))
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("components not healthy: %s", strings.Join(reasons, "; "))
+		}
+	}
+}
+
+// WatchHealth checks the health of all managed components every interval until the manager is stopped,
+// notifying the state observer. It does nothing if no observer is set.
+func (m *Manager) WatchHealth(interval time.Duration) {
+	if m.observer == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-m.stopping:
+			return
+		}
+		m.mu.Lock()
+		components := append([]Component(nil), m.components...)
+		m.mu.Unlock()
+		for _, comp := range components {
+			m.notifyHealthy(comp, comp.Healthy() == nil)
+		}
+	}
+}
+
+// notifyUp notifies the state observer, if there is one, the component was started or stopped
+func (m *Manager) notifyUp(comp Component, up bool) {
+	if m.observer != nil {
+		m.observer.ComponentUp(Name(comp), up)
+	}
+}
+
+// notifyHealthy notifies the state observer, if there is one, of the outcome of a health check
+func (m *Manager) notifyHealthy(comp Component, healthy bool) {
+	if m.observer != nil {
+		m.observer.ComponentHealthy(Name(comp), healthy)
+	}
+}
+
+// runStage runs the stage of the component, notifying the progress reporter if there is one
+func (m *Manager) runStage(stage string, comp Component, run func() error) error {
+	if m.progress == nil {
+		return run()
+	}
+	m.progress.Started(stage, Name(comp))
+	err := run()
+	m.progress.Finished(stage, Name(comp), err)
+	return err
+}
+
+// markExited records the supervised component is waiting to be restarted
+func (m *Manager) markExited(comp Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.exited == nil {
+		m.exited = make(map[Component]bool)
+	}
+	m.exited[comp] = true
+}
+
+// Stop stops all managed components in the reverse order they were added, so that
+// e.g. the API server is stopped before its storage backend. A component not stopping
+// within the stop timeout is abandoned and the shutdown carries on with the next one.
 func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.stopped {
+		m.stopped = true
+		close(m.stopping)
+	}
 	var ret error = nil
 	for i := len(m.components) - 1; i >= 0; i-- {
-		if err := m.components[i].Stop(); err != nil {
+		logrus.Debugf("stopping %s", Name(m.components[i]))
+		m.notifyUp(m.components[i], false)
+		m.notifyHealthy(m.components[i], false)
+		if err := m.stopComponent(m.components[i]); err != nil {
 			logrus.Errorf("failed to stop component: %s", err.Error())
 			if ret == nil {
 				ret = fmt.Errorf("failed to stop components")
@@ -97,3 +353,169 @@ func (m *Manager) Stop() error {
 	}
 	return ret
 }
+
+// stopComponent stops the component, giving up on it after the stop timeout
+func (m *Manager) stopComponent(comp Component) error {
+	if m.stopTimeout <= 0 {
+		return comp.Stop()
+	}
+	// buffered so that the goroutine of an abandoned component does not leak once its Stop returns
+	done := make(chan error, 1)
+	go func() { done <- comp.Stop() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(m.stopTimeout):
+		return fmt.Errorf("%s did not stop within %s, abandoning it", Name(comp), m.stopTimeout)
+	}
+}
+
+// Names returns the names of all managed components
+func (m *Manager) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.components))
+	for _, comp := range m.components {
+		names = append(names, Name(comp))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Statuses returns the state reported by the components implementing StatusReporter, by component name.
+// The components implementing VersionReporter are included with the version of their binary.
+func (m *Manager) Statuses() map[string]string {
+	// the version may exec the binary, which must not block starting, stopping or restarting the components
+	m.mu.Lock()
+	components := append([]Component(nil), m.components...)
+	m.mu.Unlock()
+
+	statuses := map[string]string{}
+	for _, comp := range components {
+		status, reported := "", false
+		if reporter, ok := comp.(StatusReporter); ok {
+			status, reported = reporter.Status(), true
+		}
+		if reporter, ok := comp.(VersionReporter); ok {
+			version := "version " + reporter.Version()
+			if status != "" {
+				version += ", " + status
+			}
+			status, reported = version, true
+		}
+		if reported {
+			statuses[Name(comp)] = status
+		}
+	}
+	return statuses
+}
+
+// Unhealthy checks the health of all managed components, returning the reason by name of the ones failing the
+// check or waiting to be restarted after their process exited
+func (m *Manager) Unhealthy() map[string]string {
+	m.mu.Lock()
+	components := append([]Component(nil), m.components...)
+	exited := map[Component]bool{}
+	for comp := range m.exited {
+		exited[comp] = true
+	}
+	m.mu.Unlock()
+
+	unhealthy := map[string]string{}
+	for _, comp := range components {
+		if exited[comp] {
+			unhealthy[Name(comp)] = "exited, waiting to be restarted"
+		} else if err := comp.Healthy(); err != nil {
+			unhealthy[Name(comp)] = err.Error()
+		}
+	}
+	return unhealthy
+}
+
+// Restart stops and runs again the named component in place. Components
+// depending on it are stopped before and run again after it, in the order
+// they were added to the manager.
+func (m *Manager) Restart(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var target Component
+	var names []string
+	for _, comp := range m.components {
+		if Name(comp) == name {
+			target = comp
+		}
+		names = append(names, Name(comp))
+	}
+	if target == nil {
+		sort.Strings(names)
+		return &RestartRejectedError{Name: name, Reason: fmt.Sprintf("no such component, known components are: %s", strings.Join(names, ", "))}
+	}
+
+	// collect the target and everything (transitively) depending on it, in start order
+	affected := map[Component]bool{target: true}
+	var toRestart []Component
+	for _, comp := range m.components {
+		if !affected[comp] {
+			dependent, ok := comp.(Restartable)
+			if !ok || !dependsOnAny(dependent, affected) {
+				continue
+			}
+			affected[comp] = true
+		}
+		toRestart = append(toRestart, comp)
+	}
+
+	for _, comp := range toRestart {
+		reason := m.restartBlocker(comp)
+		if reason == "" {
+			continue
+		}
+		if comp != target {
+			reason = fmt.Sprintf("dependent component %s cannot be restarted, %s", Name(comp), reason)
+		}
+		return &RestartRejectedError{Name: name, Reason: reason}
+	}
+
+	for i := len(toRestart) - 1; i >= 0; i-- {
+		logrus.Infof("stopping %s for restart", Name(toRestart[i]))
+		m.notifyUp(toRestart[i], false)
+		if err := toRestart[i].Stop(); err != nil {
+			return errors.Wrapf(err, "failed to stop %s", Name(toRestart[i]))
+		}
+	}
+	for _, comp := range toRestart {
+		logrus.Infof("starting %s", Name(comp))
+		if err := comp.Run(); err != nil {
+			return errors.Wrapf(err, "failed to start %s", Name(comp))
+		}
+		delete(m.exited, comp)
+		m.notifyUp(comp, true)
+	}
+	return nil
+}
+
+// restartBlocker returns the reason why the component cannot be restarted alone, or an empty string if it can
+func (m *Manager) restartBlocker(comp Component) string {
+	if m.sync[reflect.TypeOf(comp).Elem().Name()] {
+		return "it runs only once during startup to prepare the node"
+	}
+	if _, ok := comp.(Restartable); !ok {
+		return "it keeps in-process state that cannot be re-initialized, restart k0s instead"
+	}
+	return ""
+}
+
+func dependsOnAny(comp Restartable, components map[Component]bool) bool {
+	for _, dep := range comp.DependsOn() {
+		if components[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+// Name returns the name a component is known by, e.g. in the restart command
+func Name(comp Component) string {
+	return strings.ToLower(reflect.TypeOf(comp).Elem().Name())
+}
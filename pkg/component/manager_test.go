@@ -0,0 +1,329 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	events []string
+}
+
+type fakeComponent struct {
+	name string
+	rec  *recorder
+}
+
+func (f *fakeComponent) Init() error    { return nil }
+func (f *fakeComponent) Run() error     { f.rec.events = append(f.rec.events, "run "+f.name); return nil }
+func (f *fakeComponent) Stop() error    { f.rec.events = append(f.rec.events, "stop "+f.name); return nil }
+func (f *fakeComponent) Healthy() error { return nil }
+
+type Storage struct{ fakeComponent }
+
+func (s *Storage) DependsOn() []Component { return nil }
+
+type API struct {
+	fakeComponent
+	storage Component
+}
+
+func (a *API) DependsOn() []Component { return []Component{a.storage} }
+
+type Scheduler struct{ fakeComponent }
+
+func (s *Scheduler) DependsOn() []Component { return nil }
+
+type Certs struct{ fakeComponent }
+
+type Applier struct{ fakeComponent }
+
+func (s *Storage) Status() string { return "database size 1.0MiB" }
+
+func TestManagerRestart(t *testing.T) {
+	rec := &recorder{}
+	storage := &Storage{fakeComponent{"storage", rec}}
+	m := NewManager()
+	m.AddSync(&Certs{fakeComponent{"certs", rec}})
+	m.Add(storage)
+	m.Add(&API{fakeComponent{"api", rec}, storage})
+	m.Add(&Scheduler{fakeComponent{"scheduler", rec}})
+	m.Add(&Applier{fakeComponent{"applier", rec}})
+
+	t.Run("dependents are restarted in order", func(t *testing.T) {
+		rec.events = nil
+		require.NoError(t, m.Restart("storage"))
+		assert.Equal(t, []string{"stop api", "stop storage", "run storage", "run api"}, rec.events)
+	})
+
+	t.Run("single component", func(t *testing.T) {
+		rec.events = nil
+		require.NoError(t, m.Restart("scheduler"))
+		assert.Equal(t, []string{"stop scheduler", "run scheduler"}, rec.events)
+	})
+
+	t.Run("rejected components", func(t *testing.T) {
+		rec.events = nil
+		for _, name := range []string{"certs", "applier", "nonexisting"} {
+			err := m.Restart(name)
+			assert.IsType(t, &RestartRejectedError{}, err, name)
+		}
+		assert.Empty(t, rec.events)
+	})
+}
+
+func TestManagerStatuses(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager()
+	m.Add(&Storage{fakeComponent{"storage", rec}})
+	m.Add(&Scheduler{fakeComponent{"scheduler", rec}})
+
+	assert.Equal(t, map[string]string{"storage": "database size 1.0MiB"}, m.Statuses())
+
+	m.Add(&versioned{fakeComponent{"api", rec}})
+	assert.Equal(t, "version v1.19.4", m.Statuses()["versioned"])
+}
+
+type versioned struct{ fakeComponent }
+
+func (v *versioned) Version() string { return "v1.19.4" }
+
+// slowVersioned blocks reporting its version like a binary slow to answer --version
+type slowVersioned struct {
+	fakeComponent
+	asked   chan struct{}
+	release chan struct{}
+}
+
+func (v *slowVersioned) Version() string {
+	close(v.asked)
+	<-v.release
+	return "v1.19.4"
+}
+
+func TestManagerStatusesDoNotBlock(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager()
+	slow := &slowVersioned{fakeComponent{"slow", rec}, make(chan struct{}), make(chan struct{})}
+	m.Add(slow)
+	m.Add(&Scheduler{fakeComponent{"scheduler", rec}})
+
+	statuses := make(chan map[string]string)
+	go func() { statuses <- m.Statuses() }()
+	<-slow.asked
+
+	restarted := make(chan error)
+	go func() { restarted <- m.Restart("scheduler") }()
+	select {
+	case err := <-restarted:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Error("restart blocked by a version being reported")
+	}
+
+	close(slow.release)
+	assert.Equal(t, "version v1.19.4", (<-statuses)["slowversioned"])
+}
+
+type progressRecorder struct {
+	recorder
+}
+
+func (p *progressRecorder) Started(stage, name string) {
+	p.events = append(p.events, stage+" "+name)
+}
+
+func (p *progressRecorder) Finished(stage, name string, err error) {
+	p.events = append(p.events, stage+" "+name+" done")
+}
+
+func TestManagerProgress(t *testing.T) {
+	rec := &recorder{}
+	progress := &progressRecorder{}
+	m := NewManager()
+	m.AddSync(&Certs{fakeComponent{"certs", rec}})
+	m.AddSync(&Storage{fakeComponent{"storage", rec}})
+	m.ReportProgress(progress)
+
+	require.NoError(t, m.Init())
+	require.NoError(t, m.Start())
+	assert.Equal(t, []string{
+		"initializing certs", "initializing certs done",
+		"initializing storage", "initializing storage done",
+		"starting certs", "starting certs done",
+		"starting storage", "starting storage done",
+	}, progress.events)
+}
+
+type stateRecorder struct {
+	recorder
+}
+
+func (s *stateRecorder) ComponentUp(name string, up bool) {
+	if up {
+		s.events = append(s.events, name+" up")
+	} else {
+		s.events = append(s.events, name+" down")
+	}
+}
+
+func (s *stateRecorder) ComponentHealthy(name string, healthy bool) {
+	if healthy {
+		s.events = append(s.events, name+" healthy")
+	} else {
+		s.events = append(s.events, name+" unhealthy")
+	}
+}
+
+func TestManagerObserveState(t *testing.T) {
+	rec := &recorder{}
+	state := &stateRecorder{}
+	m := NewManager()
+	m.AddSync(&Certs{fakeComponent{"certs", rec}})
+	m.AddSync(&Storage{fakeComponent{"storage", rec}})
+	m.ObserveState(state)
+
+	require.NoError(t, m.Init())
+	require.NoError(t, m.Start())
+	require.NoError(t, m.Ready(context.Background()))
+	require.NoError(t, m.Stop())
+	assert.Equal(t, []string{
+		"certs down", "storage down",
+		"certs up", "storage up",
+		"certs healthy", "storage healthy",
+		"storage down", "storage unhealthy",
+		"certs down", "certs unhealthy",
+	}, state.events)
+}
+
+type hanging struct {
+	fakeComponent
+	release chan struct{}
+}
+
+func (h *hanging) Stop() error { <-h.release; return nil }
+
+func TestManagerStop(t *testing.T) {
+	rec := &recorder{}
+	stuck := &hanging{fakeComponent{"stuck", rec}, make(chan struct{})}
+	defer close(stuck.release)
+	m := NewManager()
+	m.SetStopTimeout(10 * time.Millisecond)
+	m.Add(&Storage{fakeComponent{"storage", rec}})
+	m.Add(stuck)
+	m.Add(&Scheduler{fakeComponent{"scheduler", rec}})
+
+	assert.Error(t, m.Stop())
+	assert.Equal(t, []string{"stop scheduler", "stop storage"}, rec.events)
+}
+
+type flaky struct {
+	fakeComponent
+	failures int
+}
+
+func (f *flaky) Healthy() error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("not yet")
+	}
+	return nil
+}
+
+func TestManagerReady(t *testing.T) {
+	healthPollInterval = time.Millisecond
+	rec := &recorder{}
+	m := NewManager()
+	m.Add(&Storage{fakeComponent{"storage", rec}})
+	m.Add(&flaky{fakeComponent{"api", rec}, 3})
+
+	require.NoError(t, m.Ready(context.Background()))
+
+	m.Add(&flaky{fakeComponent{"never", rec}, 1000000})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := m.Ready(ctx)
+	require.Error(t, err)
+	assert.Equal(t, "components not healthy: flaky: not yet", err.Error())
+}
+
+type crashing struct {
+	fakeComponent
+	exits   chan error
+	crashes int
+}
+
+func (c *crashing) Run() error {
+	c.fakeComponent.Run()
+	if c.crashes > 0 {
+		c.crashes--
+		c.exits <- errors.New("exit status 1")
+	}
+	return nil
+}
+
+func (c *crashing) Exited() <-chan error { return c.exits }
+
+func TestManagerUnhealthy(t *testing.T) {
+	rec := &recorder{}
+	m := NewManager()
+	storage := &Storage{fakeComponent{"storage", rec}}
+	m.Add(storage)
+	m.Add(&flaky{fakeComponent{"api", rec}, 1})
+
+	assert.Equal(t, map[string]string{"flaky": "not yet"}, m.Unhealthy())
+	assert.Empty(t, m.Unhealthy())
+
+	m.markExited(storage)
+	assert.Equal(t, map[string]string{"storage": "exited, waiting to be restarted"}, m.Unhealthy())
+}
+
+func TestManagerSupervise(t *testing.T) {
+	restartDelay = time.Millisecond
+	restartMaxDelay = 4 * time.Millisecond
+	restartStableAfter = 20 * time.Millisecond
+
+	t.Run("restarted after crashes", func(t *testing.T) {
+		rec := &recorder{}
+		m := NewManager()
+		m.Add(&crashing{fakeComponent{"kubelet", rec}, make(chan error, 1), 3})
+		require.NoError(t, m.Start())
+		time.Sleep(200 * time.Millisecond)
+		require.NoError(t, m.Stop())
+		assert.Equal(t, []string{"run kubelet", "run kubelet", "run kubelet", "run kubelet", "stop kubelet"}, rec.events)
+	})
+
+	t.Run("keeps restarting", func(t *testing.T) {
+		rec := &recorder{}
+		m := NewManager()
+		m.Add(&crashing{fakeComponent{"kubelet", rec}, make(chan error, 1), 1000})
+		require.NoError(t, m.Start())
+		time.Sleep(300 * time.Millisecond)
+		require.NoError(t, m.Stop())
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// far more than the restarts fitting into the backoff if it wasn't capped
+		assert.Greater(t, len(rec.events), 20)
+		assert.Equal(t, "stop kubelet", rec.events[len(rec.events)-1])
+	})
+}
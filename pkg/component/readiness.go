@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/logging"
+)
+
+// readinessPollInterval is how often the readiness of the dependencies is checked
+var readinessPollInterval = 5 * time.Second
+
+// deferredComponent runs the wrapped component in the background once its dependencies are ready
+type deferredComponent struct {
+	Component
+	name    string
+	deps    map[string]ReadinessReporter
+	timeout time.Duration
+
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// WaitForDependencies wraps the component so that its Run returns right away and the component is run in the
+// background once all dependencies are ready. The component is run anyway once the timeout has passed.
+func WaitForDependencies(name string, c Component, deps map[string]ReadinessReporter, timeout time.Duration) Component {
+	return &deferredComponent{
+		Component: c,
+		name:      name,
+		deps:      deps,
+		timeout:   timeout,
+	}
+}
+
+// Run waits for the dependencies and runs the component in the background
+func (d *deferredComponent) Run() error {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		if !d.waitForDependencies() {
+			return
+		}
+		if err := d.Component.Run(); err != nil {
+			logrus.Errorf("failed to start %s: %s", d.name, err)
+			return
+		}
+		d.started = true
+	}()
+	return nil
+}
+
+// waitForDependencies returns false if the component was stopped while waiting
+func (d *deferredComponent) waitForDependencies() bool {
+	log := logging.ForComponent(d.name)
+	deadline := time.NewTimer(d.timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	var pending []string
+	for {
+		pending = pending[:0]
+		for name, dep := range d.deps {
+			if err := dep.Ready(); err != nil {
+				log.Debugf("%s not ready: %s", name, err)
+				pending = append(pending, name)
+			}
+		}
+		if len(pending) == 0 {
+			return true
+		}
+		sort.Strings(pending)
+		log.Infof("waiting for %s to be ready before starting", strings.Join(pending, ", "))
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			log.Warnf("%s not ready after %s, starting anyway", strings.Join(pending, ", "), d.timeout)
+			return true
+		case <-d.stop:
+			return false
+		}
+	}
+}
+
+// Stop stops the waiting and the component if it was started already
+func (d *deferredComponent) Stop() error {
+	if d.stop == nil {
+		return nil
+	}
+	close(d.stop)
+	<-d.done
+	if !d.started {
+		return nil
+	}
+	return d.Component.Stop()
+}
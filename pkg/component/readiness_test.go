@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type readinessFake struct {
+	fakeComponent
+	ready chan struct{}
+}
+
+func (r *readinessFake) Ready() error {
+	select {
+	case <-r.ready:
+		return nil
+	default:
+		return fmt.Errorf("not yet")
+	}
+}
+
+func TestWaitForDependencies(t *testing.T) {
+	readinessPollInterval = 10 * time.Millisecond
+	rec := &recorder{}
+	cni := &readinessFake{fakeComponent: fakeComponent{name: "cni", rec: rec}, ready: make(chan struct{})}
+	dns := &fakeComponent{name: "dns", rec: &recorder{}}
+
+	t.Run("runs once ready", func(t *testing.T) {
+		c := WaitForDependencies("dns", dns, map[string]ReadinessReporter{"cni": cni}, time.Minute)
+		assert.NoError(t, c.Run())
+		time.Sleep(50 * time.Millisecond)
+		assert.False(t, c.(*deferredComponent).started)
+
+		close(cni.ready)
+		time.Sleep(50 * time.Millisecond)
+		assert.NoError(t, c.Stop())
+		assert.Equal(t, []string{"run dns", "stop dns"}, dns.rec.events)
+	})
+
+	t.Run("runs after the timeout", func(t *testing.T) {
+		dns.rec.events = nil
+		never := &readinessFake{ready: make(chan struct{})}
+		c := WaitForDependencies("dns", dns, map[string]ReadinessReporter{"cni": never}, 30*time.Millisecond)
+		assert.NoError(t, c.Run())
+		time.Sleep(100 * time.Millisecond)
+		assert.NoError(t, c.Stop())
+		assert.Equal(t, []string{"run dns", "stop dns"}, dns.rec.events)
+	})
+
+	t.Run("stopped while waiting", func(t *testing.T) {
+		dns.rec.events = nil
+		never := &readinessFake{ready: make(chan struct{})}
+		c := WaitForDependencies("dns", dns, map[string]ReadinessReporter{"cni": never}, time.Minute)
+		assert.NoError(t, c.Run())
+		assert.NoError(t, c.Stop())
+		assert.Empty(t, dns.rec.events)
+	})
+}
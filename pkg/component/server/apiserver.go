@@ -16,8 +16,18 @@ limitations under the License.
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -26,6 +36,7 @@ import (
 	"github.com/k0sproject/k0s/pkg/assets"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 )
@@ -34,9 +45,17 @@ import (
 type APIServer struct {
 	ClusterConfig *config.ClusterConfig
 	Storage       component.Component
-	supervisor    supervisor.Supervisor
-	uid           int
-	gid           int
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
+	supervisor supervisor.Supervisor
+	uid        int
+	gid        int
+	log        *logrus.Entry
+
+	// healthClient is reused by the health checks, it's created on the first check
+	healthClientMu sync.Mutex
+	healthClient   *http.Client
 }
 
 var apiDefaultArgs = map[string]string{
@@ -54,22 +73,54 @@ kind: EgressSelectorConfiguration
 egressSelections:
 - name: cluster
   connection:
+{{- if .Direct }}
+    proxyProtocol: Direct
+{{- else }}
     proxyProtocol: GRPC
     transport:
       uds:
         udsName: {{ .UDSName }}
+{{- end }}
+`
+
+// defaultAuditPolicy logs the metadata of all requests, which doesn't expose secrets or the object contents
+const defaultAuditPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+omitStages:
+- RequestReceived
+rules:
+- level: Metadata
 `
 
 type egressSelectorConfig struct {
 	UDSName string
+	Direct  bool
+}
+
+// prepareAudit writes the default audit policy if needed and creates the audit log directory owned by the apiserver user
+func (a *APIServer) prepareAudit(audit *config.AuditSpec) error {
+	if audit.PolicyFile == "" {
+		if err := ioutil.WriteFile(constant.AuditPolicyPath, []byte(defaultAuditPolicy), 0640); err != nil {
+			return errors.Wrap(err, "failed to write audit policy")
+		}
+		if err := os.Chown(constant.AuditPolicyPath, a.uid, a.gid); err != nil {
+			return errors.Wrap(err, "failed to set audit policy ownership")
+		}
+	}
+	logDir := filepath.Dir(audit.LogPath)
+	if err := util.InitDirectory(logDir, 0750); err != nil {
+		return errors.Wrap(err, "failed to create audit log dir")
+	}
+	return os.Chown(logDir, a.uid, a.gid)
 }
 
 // Init extracts needed binaries
 func (a *APIServer) Init() error {
+	a.log = logging.ForComponent("kube-apiserver")
 	var err error
 	a.uid, err = util.GetUID(constant.ApiserverUser)
 	if err != nil {
-		logrus.Warning(errors.Wrap(err, "Running kube-apiserver as root"))
+		a.log.Warning(errors.Wrap(err, "Running kube-apiserver as root"))
 	}
 	a.gid, _ = util.GetGID(constant.Group)
 
@@ -82,91 +133,157 @@ func (a *APIServer) Run() error {
 	if err != nil {
 		return err
 	}
-	logrus.Debug("Waiting for storage backend to report back healthy")
-	if err := a.Storage.Healthy(); err == nil {
-		logrus.Info("Starting kube-apiserver")
-		args := map[string]string{
-			"advertise-address":                a.ClusterConfig.Spec.API.Address,
-			"authorization-mode":               "Node,RBAC",
-			"client-ca-file":                   path.Join(constant.CertRootDir, "ca.crt"),
-			"enable-bootstrap-token-auth":      "true",
-			"kubelet-client-certificate":       path.Join(constant.CertRootDir, "apiserver-kubelet-client.crt"),
-			"kubelet-client-key":               path.Join(constant.CertRootDir, "apiserver-kubelet-client.key"),
-			"kubelet-preferred-address-types":  "InternalIP,ExternalIP,Hostname",
-			"proxy-client-cert-file":           path.Join(constant.CertRootDir, "front-proxy-client.crt"),
-			"proxy-client-key-file":            path.Join(constant.CertRootDir, "front-proxy-client.key"),
-			"requestheader-allowed-names":      "front-proxy-client",
-			"requestheader-client-ca-file":     path.Join(constant.CertRootDir, "front-proxy-ca.crt"),
-			"service-account-key-file":         path.Join(constant.CertRootDir, "sa.pub"),
-			"service-cluster-ip-range":         a.ClusterConfig.Spec.Network.ServiceCIDR,
-			"tls-cert-file":                    path.Join(constant.CertRootDir, "server.crt"),
-			"tls-private-key-file":             path.Join(constant.CertRootDir, "server.key"),
-			"egress-selector-config-file":      path.Join(constant.DataDir, "konnectivity.conf"),
-			"service-account-signing-key-file": path.Join(constant.CertRootDir, "sa.key"),
-			"service-account-issuer":           "api",
-			"api-audiences":                    "system:konnectivity-server",
-			"insecure-port":                    "0",
-			"profiling":                        "false",
-		}
+	a.log.Debug("Waiting for storage backend to report back healthy")
+	if err := a.storageHealthy(); err != nil {
+		return errors.Wrap(err, "storage backend did not become healthy")
+	}
+	a.log.Info("Starting kube-apiserver")
+	args := map[string]string{
+		"advertise-address":                a.ClusterConfig.Spec.API.Address,
+		"authorization-mode":               "Node,RBAC",
+		"client-ca-file":                   path.Join(constant.CertRootDir, "ca.crt"),
+		"enable-bootstrap-token-auth":      "true",
+		"kubelet-client-certificate":       path.Join(constant.CertRootDir, "apiserver-kubelet-client.crt"),
+		"kubelet-client-key":               path.Join(constant.CertRootDir, "apiserver-kubelet-client.key"),
+		"kubelet-preferred-address-types":  strings.Join(a.ClusterConfig.Spec.API.GetKubeletPreferredAddressTypes(), ","),
+		"proxy-client-cert-file":           path.Join(constant.CertRootDir, "front-proxy-client.crt"),
+		"proxy-client-key-file":            path.Join(constant.CertRootDir, "front-proxy-client.key"),
+		"requestheader-allowed-names":      "front-proxy-client",
+		"requestheader-client-ca-file":     path.Join(constant.CertRootDir, "front-proxy-ca.crt"),
+		"service-account-key-file":         path.Join(constant.CertRootDir, "sa.pub"),
+		"service-cluster-ip-range":         a.ClusterConfig.Spec.Network.ServiceCIDR,
+		"tls-cert-file":                    path.Join(constant.CertRootDir, "server.crt"),
+		"tls-private-key-file":             path.Join(constant.CertRootDir, "server.key"),
+		"egress-selector-config-file":      path.Join(constant.DataDir, "konnectivity.conf"),
+		"service-account-signing-key-file": path.Join(constant.CertRootDir, "sa.key"),
+		"service-account-issuer":           "api",
+		"api-audiences":                    "system:konnectivity-server",
+		"insecure-port":                    "0",
+		"profiling":                        "false",
+	}
 
-		for name, value := range a.ClusterConfig.Spec.API.ExtraArgs {
-			if args[name] != "" && name != "profiling" {
-				return fmt.Errorf("cannot override apiserver flag: %s", name)
-			}
-			args[name] = value
+	if a.ClusterConfig.Spec.API.EnableProfiling {
+		args["profiling"] = "true"
+	}
+	if v := a.ClusterConfig.Spec.LogVerbosity; v != nil && v.APIServer > 0 {
+		args["v"] = config.VerbosityArg(v.APIServer)
+	}
+	if audit := a.ClusterConfig.Spec.API.Audit; audit != nil && audit.Enabled {
+		if err := a.prepareAudit(audit); err != nil {
+			return err
 		}
-
-		for name, value := range apiDefaultArgs {
-			if args[name] == "" {
-				args[name] = value
-			}
+		for name, value := range audit.Args() {
+			args[name] = value
 		}
-		apiServerArgs := []string{}
-		for name, value := range args {
-			apiServerArgs = append(apiServerArgs, fmt.Sprintf("--%s=%s", name, value))
+	}
+	for name, value := range a.ClusterConfig.Spec.API.WatchCache.Args() {
+		args[name] = value
+	}
+	if a.ClusterConfig.Spec.API.BindAddress != "" {
+		args["bind-address"] = a.ClusterConfig.Spec.API.BindAddress
+		if !a.ClusterConfig.Spec.API.BindAddressReachable() {
+			a.log.Warnf("api.bindAddress %s is neither the api address nor in the SANs, clients might not be able to reach the API server", a.ClusterConfig.Spec.API.BindAddress)
 		}
+	}
 
-		a.supervisor = supervisor.Supervisor{
-			Name:    "kube-apiserver",
-			BinPath: assets.BinPath("kube-apiserver"),
-			Args:    apiServerArgs,
-			UID:     a.uid,
-			GID:     a.gid,
+	for name, value := range a.ClusterConfig.Spec.API.ExtraArgs {
+		if args[name] != "" && name != "profiling" {
+			return fmt.Errorf("cannot override apiserver flag: %s", name)
 		}
-		switch a.ClusterConfig.Spec.Storage.Type {
-		case config.KineStorageType:
-			a.supervisor.Args = append(a.supervisor.Args,
-				fmt.Sprintf("--etcd-servers=unix://%s", path.Join(constant.RunDir, "kine.sock:2379"))) // kine endpoint
-		case config.EtcdStorageType:
-			a.supervisor.Args = append(a.supervisor.Args,
-				"--etcd-servers=https://127.0.0.1:2379",
-				fmt.Sprintf("--etcd-cafile=%s", path.Join(constant.CertRootDir, "etcd/ca.crt")),
-				fmt.Sprintf("--etcd-certfile=%s", path.Join(constant.CertRootDir, "apiserver-etcd-client.crt")),
-				fmt.Sprintf("--etcd-keyfile=%s", path.Join(constant.CertRootDir, "apiserver-etcd-client.key")))
-		default:
-			return errors.New(fmt.Sprintf("invalid storage type: %s", a.ClusterConfig.Spec.Storage.Type))
+		args[name] = value
+	}
+
+	for name, value := range apiDefaultArgs {
+		if args[name] == "" {
+			args[name] = value
 		}
-		a.supervisor.Supervise()
 	}
+	// OIDC discovery only trusts the given CA file, point it to the bundle including the configured trusted CAs
+	if a.ClusterConfig.Spec.TrustedCABundle != nil && args["oidc-issuer-url"] != "" && args["oidc-ca-file"] == "" {
+		args["oidc-ca-file"] = constant.TrustedCABundlePath
+	}
+	apiServerArgs := []string{}
+	for name, value := range args {
+		apiServerArgs = append(apiServerArgs, fmt.Sprintf("--%s=%s", name, value))
+	}
+
+	a.supervisor = supervisor.Supervisor{
+		Name:        "kube-apiserver",
+		BinPath:     assets.BinPath("kube-apiserver"),
+		Args:        apiServerArgs,
+		UID:         a.uid,
+		GID:         a.gid,
+		TimeoutStop: a.StopTimeout,
+	}
+	switch a.ClusterConfig.Spec.Storage.Type {
+	case config.KineStorageType:
+		a.supervisor.Args = append(a.supervisor.Args,
+			fmt.Sprintf("--etcd-servers=unix://%s", path.Join(constant.RunDir, "kine.sock:2379"))) // kine endpoint
+	case config.EtcdStorageType:
+		a.supervisor.Args = append(a.supervisor.Args, etcdArgs(a.ClusterConfig.Spec.Storage.Etcd)...)
+	default:
+		return &config.InvalidStorageTypeError{Type: a.ClusterConfig.Spec.Storage.Type}
+	}
+	a.supervisor.Supervise()
 
 	return nil
 }
 
+// storageHealthy waits for the storage backend run by k0s, there is none for an external etcd cluster. The backends
+// may take a while to come up, so they are waited for if they support it.
+func (a *APIServer) storageHealthy() error {
+	if a.Storage == nil {
+		return nil
+	}
+	if waiter, ok := a.Storage.(interface{ WaitHealthy() error }); ok {
+		return waiter.WaitHealthy()
+	}
+	return a.Storage.Healthy()
+}
+
+// etcdArgs points kube-apiserver to the local etcd member, or to the endpoints of an external etcd cluster
+func etcdArgs(etcd *config.EtcdConfig) []string {
+	if !etcd.IsExternalClusterUsed() {
+		return []string{
+			fmt.Sprintf("--etcd-servers=%s", etcd.ClientURL()),
+			fmt.Sprintf("--etcd-cafile=%s", path.Join(constant.CertRootDir, "etcd/ca.crt")),
+			fmt.Sprintf("--etcd-certfile=%s", path.Join(constant.CertRootDir, "apiserver-etcd-client.crt")),
+			fmt.Sprintf("--etcd-keyfile=%s", path.Join(constant.CertRootDir, "apiserver-etcd-client.key")),
+		}
+	}
+	external := etcd.ExternalCluster
+	args := []string{fmt.Sprintf("--etcd-servers=%s", strings.Join(external.Endpoints, ","))}
+	if external.CAFile != "" {
+		args = append(args, fmt.Sprintf("--etcd-cafile=%s", external.CAFile))
+	}
+	if external.ClientCertFile != "" {
+		args = append(args,
+			fmt.Sprintf("--etcd-certfile=%s", external.ClientCertFile),
+			fmt.Sprintf("--etcd-keyfile=%s", external.ClientKeyFile))
+	}
+	return args
+}
+
 func (a *APIServer) writeKonnectivityConfig() error {
-	tw := util.TemplateWriter{
+	tw := a.egressSelectorConfigWriter()
+	err := tw.Write()
+	if err != nil {
+		return errors.Wrap(err, "failed to write konnectivity config")
+	}
+
+	return nil
+}
+
+func (a *APIServer) egressSelectorConfigWriter() util.TemplateWriter {
+	return util.TemplateWriter{
 		Name:     "konnectivity",
 		Template: egressSelectorConfigTemplate,
 		Data: egressSelectorConfig{
 			UDSName: path.Join(constant.RunDir, "konnectivity-server.sock"),
+			Direct:  a.ClusterConfig.Spec.Konnectivity.IsDirect(),
 		},
 		Path: path.Join(constant.DataDir, "konnectivity.conf"),
 	}
-	err := tw.Write()
-	if err != nil {
-		return errors.Wrap(err, "failed to write konnectivity config")
-	}
-
-	return nil
 }
 
 // Stop stops APIServer
@@ -174,5 +291,57 @@ func (a *APIServer) Stop() error {
 	return a.supervisor.Stop()
 }
 
-// Health-check interface
-func (a *APIServer) Healthy() error { return nil }
+// Version returns the version of the launched kube-apiserver binary
+func (a *APIServer) Version() string { return a.supervisor.Version() }
+
+// Healthy probes the readyz endpoint of the local kube-apiserver, which is served to anonymous clients
+func (a *APIServer) Healthy() error {
+	client, err := a.getHealthClient()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Get(a.ClusterConfig.Spec.API.LocalAPIAddress() + "/readyz")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// drain the body so the connection is reused by the next check
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kube-apiserver not ready, readyz returned %s", resp.Status)
+	}
+	return nil
+}
+
+// getHealthClient returns the client of the health checks, trusting the cluster CA
+func (a *APIServer) getHealthClient() (*http.Client, error) {
+	a.healthClientMu.Lock()
+	defer a.healthClientMu.Unlock()
+	if a.healthClient != nil {
+		return a.healthClient, nil
+	}
+	caCert, err := ioutil.ReadFile(path.Join(constant.CertRootDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in the cluster CA")
+	}
+	a.healthClient = &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			// the serving certificate is always valid for localhost, unlike for the bind address
+			TLSClientConfig: &tls.Config{RootCAs: rootCAs, ServerName: "localhost"},
+		},
+	}
+	return a.healthClient, nil
+}
+
+// DependsOn for the restartable interface, kube-apiserver needs its storage backend unless it is an external etcd cluster
+func (a *APIServer) DependsOn() []component.Component {
+	if a.Storage == nil {
+		return nil
+	}
+	return []component.Component{a.Storage}
+}
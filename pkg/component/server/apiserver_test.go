@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressSelectorConfig(t *testing.T) {
+	t.Run("proxy_mode_uses_konnectivity", func(t *testing.T) {
+		a := &APIServer{ClusterConfig: config.DefaultClusterConfig()}
+		buf := bytes.NewBuffer([]byte{})
+		tw := a.egressSelectorConfigWriter()
+		require.NoError(t, tw.WriteToBuffer(buf))
+		assert.Contains(t, buf.String(), "proxyProtocol: GRPC")
+		assert.Contains(t, buf.String(), "konnectivity-server.sock")
+	})
+
+	t.Run("direct_mode_skips_konnectivity", func(t *testing.T) {
+		clusterConfig := config.DefaultClusterConfig()
+		clusterConfig.Spec.Konnectivity.Mode = config.KonnectivityModeDirect
+		a := &APIServer{ClusterConfig: clusterConfig}
+		buf := bytes.NewBuffer([]byte{})
+		tw := a.egressSelectorConfigWriter()
+		require.NoError(t, tw.WriteToBuffer(buf))
+		assert.Contains(t, buf.String(), "proxyProtocol: Direct")
+		assert.NotContains(t, buf.String(), "uds")
+	})
+}
@@ -29,9 +29,11 @@ import (
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Calico is the Component interface implementation to manage Calico
@@ -40,7 +42,8 @@ type Calico struct {
 	tickerDone  chan struct{}
 	log         *logrus.Entry
 
-	saver manifestsSaver
+	saver  manifestsSaver
+	client kubernetes.Interface
 }
 
 type manifestsSaver interface {
@@ -69,19 +72,19 @@ type FsManifestsSaver struct {
 // Save saves given manifest under the given path
 func (f FsManifestsSaver) Save(dst string, content []byte) error {
 	if err := ioutil.WriteFile(filepath.Join(f.dir, dst), content, constant.ManifestsDirMode); err != nil {
-		return fmt.Errorf("can't write calico manifest configuration config map%s: %v", dst, err)
+		return fmt.Errorf("can't write manifest %s: %v", dst, err)
 	}
 	return nil
 }
 
-// NewManifestsSaver builds new filesystem manifests saver
-func NewManifestsSaver() (*FsManifestsSaver, error) {
-	calicoDir := path.Join(constant.DataDir, "manifests", "calico")
-	err := os.MkdirAll(calicoDir, constant.ManifestsDirMode)
+// NewManifestsSaver builds new filesystem manifests saver for the given stack of the manifests dir
+func NewManifestsSaver(stack string) (*FsManifestsSaver, error) {
+	stackDir := path.Join(constant.ManifestsDir, stack)
+	err := os.MkdirAll(stackDir, constant.ManifestsDirMode)
 	if err != nil {
 		return nil, err
 	}
-	return &FsManifestsSaver{dir: calicoDir}, nil
+	return &FsManifestsSaver{dir: stackDir}, nil
 }
 
 // NewCalico creates new Calico reconciler component
@@ -136,7 +139,7 @@ func (c *Calico) Run() error {
 	}
 
 	go func() {
-		ticker := time.NewTicker(10 * time.Second)
+		ticker := time.NewTicker(reconcileInterval)
 		defer ticker.Stop()
 		var previousConfig = calicoConfig{}
 		for {
@@ -160,10 +163,12 @@ func (c *Calico) processConfigChanges(previousConfig calicoConfig) *calicoConfig
 	config, err := c.getConfig()
 	if err != nil {
 		c.log.Errorf("error calculating calico configs: %s. will retry", err.Error())
+		reportReconcile("calico", reconcileInterval, err)
 		return nil
 	}
 	if config == previousConfig {
 		c.log.Infof("current config matches existing, not gonna do anything")
+		reportReconcile("calico", reconcileInterval, nil)
 		return nil
 	}
 
@@ -171,9 +176,12 @@ func (c *Calico) processConfigChanges(previousConfig calicoConfig) *calicoConfig
 
 	if err != nil {
 		c.log.Errorf("error retrieving calico manifests: %s. will retry", err.Error())
+		reportReconcile("calico", reconcileInterval, err)
 		return nil
 	}
 
+	var failed error
+
 	for _, dir := range manifestDirectories {
 		// CRDs are handled separately on boot
 		if dir == "CustomResourceDefinition" {
@@ -182,12 +190,14 @@ func (c *Calico) processConfigChanges(previousConfig calicoConfig) *calicoConfig
 		manifestPaths, err := static.AssetDir(fmt.Sprintf("manifests/calico/%s", dir))
 		if err != nil {
 			c.log.Errorf("error retrieving calico manifests: %s. will retry", err.Error())
+			reportReconcile("calico", reconcileInterval, err)
 			return nil
 		}
 
 		tryAndLog := func(name string, e error) {
 			if e != nil {
 				c.log.Errorf("failed to write manifest %s: %v, will re-try", name, e)
+				failed = e
 			}
 		}
 
@@ -197,6 +207,7 @@ func (c *Calico) processConfigChanges(previousConfig calicoConfig) *calicoConfig
 			contents, err := static.Asset(fmt.Sprintf("manifests/calico/%s/%s", dir, filename))
 
 			if err != nil {
+				reportReconcile("calico", reconcileInterval, err)
 				return nil
 			}
 
@@ -210,6 +221,11 @@ func (c *Calico) processConfigChanges(previousConfig calicoConfig) *calicoConfig
 		}
 	}
 
+	reportReconcile("calico", reconcileInterval, failed)
+	if failed != nil {
+		// the manifests are written anew on the next tick
+		return nil
+	}
 	return &config
 }
 
@@ -238,3 +254,18 @@ func (c *Calico) Stop() error {
 
 // Health-check interface
 func (c *Calico) Healthy() error { return nil }
+
+// Ready reports calico as ready for the addons once its manifests are written and a node became ready with it
+func (c *Calico) Ready() error {
+	if err := reconciled("calico"); err != nil {
+		return err
+	}
+	if c.client == nil {
+		client, err := kubeutil.Client(constant.AdminKubeconfigConfigPath)
+		if err != nil {
+			return err
+		}
+		c.client = client
+	}
+	return anyNodeReady(c.client)
+}
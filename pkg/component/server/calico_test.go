@@ -1,6 +1,7 @@
 package server
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
@@ -15,6 +16,12 @@ func (i inMemorySaver) Save(dst string, content []byte) error {
 	return nil
 }
 
+type failingSaver struct{}
+
+func (failingSaver) Save(dst string, content []byte) error {
+	return fmt.Errorf("no space left on device")
+}
+
 func TestCalicoManifests(t *testing.T) {
 
 	t.Run("must_write_crd_during_bootstrap", func(t *testing.T) {
@@ -41,6 +48,19 @@ func TestCalicoManifests(t *testing.T) {
 		}
 	})
 
+	t.Run("must_retry_failed_writes", func(t *testing.T) {
+		resetReconcileStatuses()
+		defer resetReconcileStatuses()
+		calico, err := NewCalico(v1beta1.DefaultClusterConfig(), failingSaver{})
+		require.NoError(t, err)
+
+		require.Nil(t, calico.processConfigChanges(calicoConfig{}), "a config not written must not be recorded as the previous one")
+		status, ok := ReconcilerStatusOf("calico")
+		require.True(t, ok)
+		require.Equal(t, uint64(1), status.Errors)
+		require.Contains(t, status.LastError, "no space left on device")
+	})
+
 	t.Run("must_have_wireguard_enabled_if_config_has", func(t *testing.T) {
 		cfg := v1beta1.DefaultClusterConfig()
 		cfg.Spec.Network.Calico.EnableWireguard = true
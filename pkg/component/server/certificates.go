@@ -26,13 +26,16 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/certificate"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
 
@@ -46,6 +49,9 @@ clusters:
 - cluster:
     server: {{.URL}}
     certificate-authority-data: {{.CACert}}
+{{- if .ServerName}}
+    tls-server-name: {{.ServerName}}
+{{- end}}
   name: local
 contexts:
 - context:
@@ -70,10 +76,15 @@ type Certificates struct {
 
 	CertManager certificate.Manager
 	ClusterSpec *config.ClusterSpec
+	// ExpiryWarningWindow is how long before their expiry the certificates are warned about,
+	// certificate.DefaultExpiryWarningWindow if zero
+	ExpiryWarningWindow time.Duration
+	log                 *logrus.Entry
 }
 
 // Init initializes the certificate component
 func (c *Certificates) Init() error {
+	c.log = logging.ForComponent("certificates")
 
 	eg, _ := errgroup.WithContext(context.Background())
 	// Common CA
@@ -85,7 +96,7 @@ func (c *Certificates) Init() error {
 	}
 
 	// We need CA cert loaded to generate client configs
-	logrus.Debugf("CA key and cert exists, loading")
+	c.log.Debugf("CA key and cert exists, loading")
 	cert, err := ioutil.ReadFile(caCertPath)
 	if err != nil {
 		return errors.Wrapf(err, "failed to read ca cert")
@@ -114,18 +125,11 @@ func (c *Certificates) Init() error {
 
 	eg.Go(func() error {
 		// admin cert & kubeconfig
-		adminReq := certificate.Request{
-			Name:   "admin",
-			CN:     "kubernetes-admin",
-			O:      "system:masters",
-			CACert: caCertPath,
-			CAKey:  caCertKey,
-		}
-		adminCert, err := c.CertManager.EnsureCertificate(adminReq, "root")
+		adminCert, err := c.CertManager.EnsureCertificate(adminCertRequest(), "root")
 		if err != nil {
 			return err
 		}
-		if err := kubeConfig(constant.AdminKubeconfigConfigPath, "https://localhost:6443", c.CACert, adminCert.Cert, adminCert.Key); err != nil {
+		if err := kubeConfig(constant.AdminKubeconfigConfigPath, c.ClusterSpec.API.LocalAPIAddress(), c.CACert, adminCert.Cert, adminCert.Key); err != nil {
 			return err
 		}
 
@@ -146,7 +150,7 @@ func (c *Certificates) Init() error {
 			return err
 		}
 
-		return kubeConfig(filepath.Join(constant.CertRootDir, "ccm.conf"), "https://localhost:6443", c.CACert, ccmCert.Cert, ccmCert.Key)
+		return kubeConfig(constant.ControllerManagerKubeconfigPath, c.ClusterSpec.API.LocalAPIAddress(), c.CACert, ccmCert.Cert, ccmCert.Key)
 	})
 
 	eg.Go(func() error {
@@ -162,7 +166,7 @@ func (c *Certificates) Init() error {
 			return err
 		}
 
-		return kubeConfig(filepath.Join(constant.CertRootDir, "scheduler.conf"), "https://localhost:6443", c.CACert, schedulerCert.Cert, schedulerCert.Key)
+		return kubeConfig(constant.SchedulerKubeconfigPath, c.ClusterSpec.API.LocalAPIAddress(), c.CACert, schedulerCert.Cert, schedulerCert.Key)
 	})
 
 	eg.Go(func() error {
@@ -187,8 +191,12 @@ func (c *Certificates) Init() error {
 		"localhost",
 	}
 
+	sans, err := c.ClusterSpec.API.CertificateSANs()
+	if err != nil {
+		return err
+	}
 	hostnames = append(hostnames, c.ClusterSpec.API.Address)
-	hostnames = append(hostnames, c.ClusterSpec.API.SANs...)
+	hostnames = append(hostnames, sans...)
 
 	internalAPIAddress, err := c.ClusterSpec.Network.InternalAPIAddress()
 	if err != nil {
@@ -196,6 +204,15 @@ func (c *Certificates) Init() error {
 	}
 	hostnames = append(hostnames, internalAPIAddress)
 
+	if c.ClusterSpec.API.AutoSANs {
+		// the node address might have changed since the certificates were issued
+		for _, name := range []string{"server", "k0s-api"} {
+			if err := c.reissueForHostnames(name, hostnames); err != nil {
+				return err
+			}
+		}
+	}
+
 	eg.Go(func() error {
 		serverReq := certificate.Request{
 			Name:      "server",
@@ -224,7 +241,62 @@ func (c *Certificates) Init() error {
 		return err
 	})
 
-	return eg.Wait()
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	c.warnExpiring()
+	return nil
+}
+
+// reissueForHostnames backs up the certificate with the given name if it isn't valid for all the hostnames, for it to
+// be issued anew
+func (c *Certificates) reissueForHostnames(name string, hostnames []string) error {
+	files := certFiles(name)
+	if !util.FileExists(files[0]) {
+		return nil
+	}
+	missing, err := c.CertManager.MissingHostnames(name, hostnames)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	c.log.Infof("certificate %s is not valid for %s, issuing it anew", name, strings.Join(missing, ", "))
+	backupSuffix := ".bak-" + time.Now().Format("20060102150405")
+	for _, f := range files {
+		if !util.FileExists(f) {
+			continue
+		}
+		if err := os.Rename(f, f+backupSuffix); err != nil {
+			return errors.Wrapf(err, "failed to back up %s", f)
+		}
+	}
+	return nil
+}
+
+// warnExpiring logs a warning for every certificate expiring within the warning window
+func (c *Certificates) warnExpiring() {
+	window := c.ExpiryWarningWindow
+	if window == 0 {
+		window = certificate.DefaultExpiryWarningWindow
+	}
+	certs, err := c.CertManager.List()
+	if err != nil {
+		c.log.Warnf("failed to check the expiry of the certificates: %s", err)
+		return
+	}
+	now := time.Now()
+	for _, cert := range certs {
+		if !cert.ExpiresWithin(window, now) {
+			continue
+		}
+		if cert.NotAfter.Before(now) {
+			c.log.Warnf("certificate %s expired on %s", cert.Name, cert.NotAfter.Format(time.RFC3339))
+		} else {
+			c.log.Warnf("certificate %s expires on %s, in %d days", cert.Name, cert.NotAfter.Format(time.RFC3339), int(cert.NotAfter.Sub(now).Hours()/24))
+		}
+	}
 }
 
 // Run does nothing, the cert component only needs to be initialized
@@ -237,17 +309,138 @@ func (c *Certificates) Stop() error {
 	return nil
 }
 
+func adminCertRequest() certificate.Request {
+	return certificate.Request{
+		Name:   "admin",
+		CN:     "kubernetes-admin",
+		O:      "system:masters",
+		CACert: filepath.Join(constant.CertRootDir, "ca.crt"),
+		CAKey:  filepath.Join(constant.CertRootDir, "ca.key"),
+	}
+}
+
+// RegenerateAdminKubeconfig issues a new admin client certificate and rewrites the admin kubeconfig with it, pointing
+// to the local API server of the given API config. The existing certificate, key and kubeconfig are renamed with the
+// given backup suffix first. Only the CA on disk is needed, so this works while the apiserver is down.
+func RegenerateAdminKubeconfig(certManager certificate.Manager, apiSpec *config.APISpec, backupSuffix string) error {
+	req := adminCertRequest()
+	caCert, err := ioutil.ReadFile(req.CACert)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read ca cert, is this a controller node?")
+	}
+	if !util.FileExists(req.CAKey) {
+		return fmt.Errorf("ca key %s does not exist, is this a controller node?", req.CAKey)
+	}
+
+	for _, f := range []string{
+		filepath.Join(constant.CertRootDir, "admin.crt"),
+		filepath.Join(constant.CertRootDir, "admin.key"),
+		constant.AdminKubeconfigConfigPath,
+	} {
+		if !util.FileExists(f) {
+			continue
+		}
+		if err := os.Rename(f, f+backupSuffix); err != nil {
+			return errors.Wrapf(err, "failed to back up %s", f)
+		}
+		logrus.Infof("backed up %s to %s", f, f+backupSuffix)
+	}
+
+	adminCert, err := certManager.EnsureCertificate(req, "root")
+	if err != nil {
+		return errors.Wrap(err, "failed to create admin certificate")
+	}
+	return kubeConfig(constant.AdminKubeconfigConfigPath, apiSpec.LocalAPIAddress(), string(caCert), adminCert.Cert, adminCert.Key)
+}
+
+// RenewableCertificate is a certificate issued by the Certificates component which can be renewed in place
+type RenewableCertificate struct {
+	// Name selects the certificate for renewal
+	Name string
+	// Files are the key, certificate and kubeconfig files issued anew on renewal
+	Files []string
+	// CA is the name of the CA signing the certificate
+	CA string
+	// Component is the name of the component to restart for it to load the renewed certificate, if any
+	Component string
+}
+
+// RenewableCertificates lists the certificates RenewCertificates can renew. The paths are resolved on each call, after
+// the data dir has been relocated.
+func RenewableCertificates() []RenewableCertificate {
+	return []RenewableCertificate{
+		{Name: "apiserver", Files: certFiles("server"), CA: "ca", Component: "apiserver"},
+		{Name: "admin", Files: append(certFiles("admin"), constant.AdminKubeconfigConfigPath), CA: "ca"},
+		{Name: "kubelet-client", Files: certFiles("apiserver-kubelet-client"), CA: "ca", Component: "apiserver"},
+		{Name: "front-proxy-client", Files: certFiles("front-proxy-client"), CA: "front-proxy-ca", Component: "apiserver"},
+		{Name: "controller-manager", Files: append(certFiles("ccm"), constant.ControllerManagerKubeconfigPath), CA: "ca", Component: "controllermanager"},
+		{Name: "scheduler", Files: append(certFiles("scheduler"), constant.SchedulerKubeconfigPath), CA: "ca", Component: "scheduler"},
+		{Name: "k0s-api", Files: certFiles("k0s-api"), CA: "ca", Component: "k0scontrolapi"},
+	}
+}
+
+func certFiles(name string) []string {
+	return []string{
+		filepath.Join(constant.CertRootDir, name+".crt"),
+		filepath.Join(constant.CertRootDir, name+".key"),
+	}
+}
+
+// RenewCertificates issues the given certificates anew from the existing CAs. The existing files are renamed with
+// the given backup suffix first. The CAs are not rotated, so renewing fails if a CA expires within caWindow.
+func RenewCertificates(certManager certificate.Manager, clusterSpec *config.ClusterSpec, certs []RenewableCertificate, caWindow time.Duration, backupSuffix string) error {
+	now := time.Now()
+	for _, cert := range certs {
+		ca, err := certManager.Info(cert.CA)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the %s certificate, is this a controller node?", cert.CA)
+		}
+		if ca.ExpiresWithin(caWindow, now) {
+			return fmt.Errorf("the %s certificate expires on %s, renewing the certificates it signs won't help: the CA itself needs to be rotated, which requires re-creating the certificates of the whole cluster", cert.CA, ca.NotAfter.Format(time.RFC3339))
+		}
+	}
+
+	for _, cert := range certs {
+		for _, f := range cert.Files {
+			if !util.FileExists(f) {
+				continue
+			}
+			if err := os.Rename(f, f+backupSuffix); err != nil {
+				return errors.Wrapf(err, "failed to back up %s", f)
+			}
+			logrus.Infof("backed up %s to %s", f, f+backupSuffix)
+		}
+	}
+
+	// The certificates and kubeconfigs are only created when missing, so initializing re-creates just the renewed ones
+	certificates := &Certificates{
+		CertManager:         certManager,
+		ClusterSpec:         clusterSpec,
+		ExpiryWarningWindow: caWindow,
+	}
+	return certificates.Init()
+}
+
+// kubeConfig writes the kubeconfig unless it exists already with the same server URL. It's written anew if the URL
+// changed, e.g. after api.bindAddress was set.
 func kubeConfig(dest, url, caCert, clientCert, clientKey string) error {
-	if util.FileExists(dest) {
+	if existing, err := ioutil.ReadFile(dest); err == nil && strings.Contains(string(existing), "server: "+url+"\n") {
 		return nil
 	}
+	// the serving certificate is always valid for localhost, unlike for the bind address
+	serverName := ""
+	if !strings.HasPrefix(url, "https://localhost:") {
+		serverName = "localhost"
+	}
 	data := struct {
 		URL        string
+		ServerName string
 		CACert     string
 		ClientCert string
 		ClientKey  string
 	}{
 		URL:        url,
+		ServerName: serverName,
 		CACert:     base64.StdEncoding.EncodeToString([]byte(caCert)),
 		ClientCert: base64.StdEncoding.EncodeToString([]byte(clientCert)),
 		ClientKey:  base64.StdEncoding.EncodeToString([]byte(clientKey)),
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestKubeConfigBindAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-kubeconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "ccm.conf")
+
+	readCluster := func() map[string]string {
+		data, err := ioutil.ReadFile(dest)
+		require.NoError(t, err)
+		var kubeconfig struct {
+			Clusters []struct {
+				Cluster map[string]string `yaml:"cluster"`
+			} `yaml:"clusters"`
+		}
+		require.NoError(t, yaml.Unmarshal(data, &kubeconfig))
+		require.Len(t, kubeconfig.Clusters, 1)
+		return kubeconfig.Clusters[0].Cluster
+	}
+
+	api := &config.APISpec{Address: "10.0.0.1", BindAddress: "10.0.0.2"}
+	require.NoError(t, kubeConfig(dest, api.LocalAPIAddress(), "ca", "cert", "key"))
+	cluster := readCluster()
+	assert.Equal(t, "https://10.0.0.2:6443", cluster["server"])
+	assert.Equal(t, "localhost", cluster["tls-server-name"])
+
+	// written anew once the API server listens on all interfaces again
+	api.BindAddress = ""
+	require.NoError(t, kubeConfig(dest, api.LocalAPIAddress(), "ca", "cert", "key"))
+	cluster = readCluster()
+	assert.Equal(t, "https://localhost:6443", cluster["server"])
+	assert.NotContains(t, cluster, "tls-server-name")
+}
+
+func TestRenewableCertificatesFollowDataDir(t *testing.T) {
+	constant.SetDataDir("/srv/k0s")
+	defer constant.SetDataDir(constant.DefaultDataDir)
+
+	for _, cert := range RenewableCertificates() {
+		// the certificate and its key
+		for _, f := range cert.Files[:2] {
+			assert.Equal(t, "/srv/k0s/pki", filepath.Dir(f), cert.Name)
+		}
+		// and the kubeconfig embedding them
+		switch cert.Name {
+		case "admin":
+			assert.Contains(t, cert.Files, "/srv/k0s/pki/admin.conf")
+		case "controller-manager":
+			assert.Contains(t, cert.Files, "/srv/k0s/pki/ccm.conf")
+		case "scheduler":
+			assert.Contains(t, cert.Files, "/srv/k0s/pki/scheduler.conf")
+		}
+	}
+}
@@ -0,0 +1,626 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"bytes"
+	"time"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Cilium is the Component interface implementation to manage Cilium
+type Cilium struct {
+	clusterConf *config.ClusterConfig
+	tickerDone  chan struct{}
+	log         *logrus.Entry
+
+	saver  manifestsSaver
+	client kubernetes.Interface
+}
+
+type ciliumConfig struct {
+	ClusterCIDR         string
+	ClusterCIDRMaskSize int
+
+	CiliumImage         string
+	CiliumOperatorImage string
+}
+
+// NewCilium creates new Cilium reconciler component
+func NewCilium(clusterConf *config.ClusterConfig, saver manifestsSaver) (*Cilium, error) {
+	log := logrus.WithFields(logrus.Fields{"component": "cilium"})
+	return &Cilium{
+		clusterConf: clusterConf,
+		log:         log,
+		saver:       saver,
+	}, nil
+}
+
+// Init does nothing
+func (c *Cilium) Init() error {
+	return nil
+}
+
+// Run runs the cilium reconciler
+func (c *Cilium) Run() error {
+	c.tickerDone = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		var previousConfig = ciliumConfig{}
+		for {
+			select {
+			case <-ticker.C:
+				newConfig := c.processConfigChanges(previousConfig)
+				if newConfig != nil {
+					previousConfig = *newConfig
+				}
+			case <-c.tickerDone:
+				c.log.Info("cilium reconciler done")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *Cilium) processConfigChanges(previousConfig ciliumConfig) *ciliumConfig {
+	config := c.getConfig()
+	if config == previousConfig {
+		c.log.Infof("current config matches existing, not gonna do anything")
+		reportReconcile("cilium", reconcileInterval, nil)
+		return nil
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	tw := util.TemplateWriter{
+		Name:     "cilium",
+		Template: ciliumTemplate,
+		Data:     config,
+	}
+	if err := tw.WriteToBuffer(output); err != nil {
+		c.log.Errorf("error writing cilium manifests: %s. will retry", err.Error())
+		reportReconcile("cilium", reconcileInterval, err)
+		return nil
+	}
+	if err := c.saver.Save("cilium.yaml", output.Bytes()); err != nil {
+		c.log.Errorf("error saving cilium manifests: %s. will retry", err.Error())
+		reportReconcile("cilium", reconcileInterval, errors.Wrap(err, "failed to save cilium manifests"))
+		return nil
+	}
+
+	reportReconcile("cilium", reconcileInterval, nil)
+	return &config
+}
+
+func (c *Cilium) getConfig() ciliumConfig {
+	return ciliumConfig{
+		ClusterCIDR:         c.clusterConf.Spec.Network.PodCIDR,
+		ClusterCIDRMaskSize: c.clusterConf.Spec.ControllerManager.NodeCIDRMaskSize(),
+		CiliumImage:         c.clusterConf.Images.Cilium.Agent.URI(),
+		CiliumOperatorImage: c.clusterConf.Images.Cilium.Operator.URI(),
+	}
+}
+
+// Stop stops the cilium reconciler
+func (c *Cilium) Stop() error {
+	close(c.tickerDone)
+	return nil
+}
+
+// Health-check interface
+func (c *Cilium) Healthy() error { return nil }
+
+// Ready reports cilium as ready for the addons once its manifests are written and a node became ready with it
+func (c *Cilium) Ready() error {
+	if err := reconciled("cilium"); err != nil {
+		return err
+	}
+	if c.client == nil {
+		client, err := kubeutil.Client(constant.AdminKubeconfigConfigPath)
+		if err != nil {
+			return err
+		}
+		c.client = client
+	}
+	return anyNodeReady(c.client)
+}
+
+// ciliumTemplate deploys the cilium agent and operator with the cluster-pool IPAM handing out the pod CIDR. kube-proxy
+// is managed by k0s, so cilium leaves the service handling to it.
+const ciliumTemplate = `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cilium
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cilium-operator
+  namespace: kube-system
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cilium-config
+  namespace: kube-system
+data:
+  identity-allocation-mode: crd
+  cilium-endpoint-gc-interval: "5m0s"
+  debug: "false"
+  enable-ipv4: "true"
+  enable-ipv6: "false"
+  enable-bpf-clock-probe: "true"
+  monitor-aggregation: medium
+  monitor-aggregation-interval: 5s
+  monitor-aggregation-flags: all
+  bpf-map-dynamic-size-ratio: "0.0025"
+  bpf-policy-map-max: "16384"
+  preallocate-bpf-maps: "false"
+  tunnel: vxlan
+  masquerade: "true"
+  enable-bpf-masquerade: "true"
+  enable-xt-socket-fallback: "true"
+  install-iptables-rules: "true"
+  auto-direct-node-routes: "false"
+  kube-proxy-replacement: disabled
+  enable-health-checking: "true"
+  enable-endpoint-health-checking: "true"
+  enable-well-known-identities: "false"
+  enable-remote-node-identity: "true"
+  operator-api-serve-addr: "127.0.0.1:9234"
+  ipam: cluster-pool
+  cluster-pool-ipv4-cidr: "{{ .ClusterCIDR }}"
+  cluster-pool-ipv4-mask-size: "{{ .ClusterCIDRMaskSize }}"
+  disable-cnp-status-updates: "true"
+  cluster-name: default
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: cilium
+rules:
+- apiGroups:
+  - networking.k8s.io
+  resources:
+  - networkpolicies
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - discovery.k8s.io
+  resources:
+  - endpointslices
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - namespaces
+  - services
+  - nodes
+  - endpoints
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  - pods/finalizers
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+  - delete
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  verbs:
+  - get
+  - list
+  - watch
+  - update
+- apiGroups:
+  - ""
+  resources:
+  - nodes
+  - nodes/status
+  verbs:
+  - patch
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - create
+  - list
+  - watch
+  - update
+  - get
+- apiGroups:
+  - cilium.io
+  resources:
+  - ciliumnetworkpolicies
+  - ciliumnetworkpolicies/status
+  - ciliumnetworkpolicies/finalizers
+  - ciliumclusterwidenetworkpolicies
+  - ciliumclusterwidenetworkpolicies/status
+  - ciliumclusterwidenetworkpolicies/finalizers
+  - ciliumendpoints
+  - ciliumendpoints/status
+  - ciliumendpoints/finalizers
+  - ciliumnodes
+  - ciliumnodes/status
+  - ciliumnodes/finalizers
+  - ciliumidentities
+  - ciliumidentities/finalizers
+  - ciliumlocalredirectpolicies
+  - ciliumlocalredirectpolicies/status
+  - ciliumlocalredirectpolicies/finalizers
+  verbs:
+  - '*'
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: cilium-operator
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - pods
+  verbs:
+  - get
+  - list
+  - watch
+  - delete
+- apiGroups:
+  - discovery.k8s.io
+  resources:
+  - endpointslices
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - services
+  - endpoints
+  - namespaces
+  verbs:
+  - get
+  - list
+  - watch
+- apiGroups:
+  - cilium.io
+  resources:
+  - ciliumnetworkpolicies
+  - ciliumnetworkpolicies/status
+  - ciliumnetworkpolicies/finalizers
+  - ciliumclusterwidenetworkpolicies
+  - ciliumclusterwidenetworkpolicies/status
+  - ciliumclusterwidenetworkpolicies/finalizers
+  - ciliumendpoints
+  - ciliumendpoints/status
+  - ciliumendpoints/finalizers
+  - ciliumnodes
+  - ciliumnodes/status
+  - ciliumnodes/finalizers
+  - ciliumidentities
+  - ciliumidentities/status
+  - ciliumidentities/finalizers
+  - ciliumlocalredirectpolicies
+  - ciliumlocalredirectpolicies/status
+  - ciliumlocalredirectpolicies/finalizers
+  verbs:
+  - '*'
+- apiGroups:
+  - apiextensions.k8s.io
+  resources:
+  - customresourcedefinitions
+  verbs:
+  - create
+  - get
+  - list
+  - update
+  - watch
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - create
+  - get
+  - update
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cilium
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cilium
+subjects:
+- kind: ServiceAccount
+  name: cilium
+  namespace: kube-system
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: cilium-operator
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cilium-operator
+subjects:
+- kind: ServiceAccount
+  name: cilium-operator
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: cilium
+  namespace: kube-system
+  labels:
+    k8s-app: cilium
+spec:
+  selector:
+    matchLabels:
+      k8s-app: cilium
+  updateStrategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxUnavailable: 2
+  template:
+    metadata:
+      labels:
+        k8s-app: cilium
+    spec:
+      hostNetwork: true
+      serviceAccountName: cilium
+      priorityClassName: system-node-critical
+      restartPolicy: Always
+      terminationGracePeriodSeconds: 1
+      tolerations:
+      - operator: Exists
+      initContainers:
+      - name: clean-cilium-state
+        image: {{ .CiliumImage }}
+        imagePullPolicy: IfNotPresent
+        command:
+        - /init-container.sh
+        env:
+        - name: CILIUM_ALL_STATE
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: clean-cilium-state
+              optional: true
+        - name: CILIUM_BPF_STATE
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: clean-cilium-bpf-state
+              optional: true
+        securityContext:
+          privileged: true
+        volumeMounts:
+        - name: bpf-maps
+          mountPath: /sys/fs/bpf
+          mountPropagation: HostToContainer
+        - name: cilium-run
+          mountPath: /var/run/cilium
+      containers:
+      - name: cilium-agent
+        image: {{ .CiliumImage }}
+        imagePullPolicy: IfNotPresent
+        command:
+        - cilium-agent
+        args:
+        - --config-dir=/tmp/cilium/config-map
+        env:
+        - name: K8S_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        - name: CILIUM_K8S_NAMESPACE
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: metadata.namespace
+        lifecycle:
+          postStart:
+            exec:
+              command:
+              - /cni-install.sh
+              - --enable-debug=false
+          preStop:
+            exec:
+              command:
+              - /cni-uninstall.sh
+        livenessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /healthz
+            port: 9876
+            scheme: HTTP
+            httpHeaders:
+            - name: brief
+              value: "true"
+          failureThreshold: 10
+          initialDelaySeconds: 120
+          periodSeconds: 30
+          successThreshold: 1
+          timeoutSeconds: 5
+        readinessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /healthz
+            port: 9876
+            scheme: HTTP
+            httpHeaders:
+            - name: brief
+              value: "true"
+          failureThreshold: 3
+          initialDelaySeconds: 5
+          periodSeconds: 30
+          successThreshold: 1
+          timeoutSeconds: 5
+        securityContext:
+          privileged: true
+        volumeMounts:
+        - name: bpf-maps
+          mountPath: /sys/fs/bpf
+        - name: cilium-run
+          mountPath: /var/run/cilium
+        - name: cni-path
+          mountPath: /host/opt/cni/bin
+        - name: etc-cni-netd
+          mountPath: /host/etc/cni/net.d
+        - name: cilium-config-path
+          mountPath: /tmp/cilium/config-map
+          readOnly: true
+        - name: lib-modules
+          mountPath: /lib/modules
+          readOnly: true
+        - name: xtables-lock
+          mountPath: /run/xtables.lock
+      volumes:
+      - name: cilium-run
+        hostPath:
+          path: /var/run/cilium
+          type: DirectoryOrCreate
+      - name: bpf-maps
+        hostPath:
+          path: /sys/fs/bpf
+          type: DirectoryOrCreate
+      - name: cni-path
+        hostPath:
+          path: /opt/cni/bin
+          type: DirectoryOrCreate
+      - name: etc-cni-netd
+        hostPath:
+          path: /etc/cni/net.d
+          type: DirectoryOrCreate
+      - name: lib-modules
+        hostPath:
+          path: /lib/modules
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: FileOrCreate
+      - name: cilium-config-path
+        configMap:
+          name: cilium-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cilium-operator
+  namespace: kube-system
+  labels:
+    io.cilium/app: operator
+    name: cilium-operator
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      io.cilium/app: operator
+      name: cilium-operator
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 1
+      maxUnavailable: 1
+  template:
+    metadata:
+      labels:
+        io.cilium/app: operator
+        name: cilium-operator
+    spec:
+      hostNetwork: true
+      serviceAccountName: cilium-operator
+      priorityClassName: system-cluster-critical
+      restartPolicy: Always
+      tolerations:
+      - operator: Exists
+      containers:
+      - name: cilium-operator
+        image: {{ .CiliumOperatorImage }}
+        imagePullPolicy: IfNotPresent
+        command:
+        - cilium-operator-generic
+        args:
+        - --config-dir=/tmp/cilium/config-map
+        - --debug=$(CILIUM_DEBUG)
+        env:
+        - name: K8S_NODE_NAME
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: spec.nodeName
+        - name: CILIUM_K8S_NAMESPACE
+          valueFrom:
+            fieldRef:
+              apiVersion: v1
+              fieldPath: metadata.namespace
+        - name: CILIUM_DEBUG
+          valueFrom:
+            configMapKeyRef:
+              name: cilium-config
+              key: debug
+              optional: true
+        livenessProbe:
+          httpGet:
+            host: 127.0.0.1
+            path: /healthz
+            port: 9234
+            scheme: HTTP
+          initialDelaySeconds: 60
+          periodSeconds: 10
+          timeoutSeconds: 3
+        volumeMounts:
+        - name: cilium-config-path
+          mountPath: /tmp/cilium/config-map
+          readOnly: true
+      volumes:
+      - name: cilium-config-path
+        configMap:
+          name: cilium-config
+`
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"testing"
+
+	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCiliumManifests(t *testing.T) {
+	cfg := v1beta1.DefaultClusterConfig()
+	cfg.Spec.Network.Provider = "cilium"
+	cfg.Spec.Network.PodCIDR = "10.200.0.0/16"
+	saver := inMemorySaver{}
+	cilium, err := NewCilium(cfg, saver)
+	require.NoError(t, err)
+
+	newConfig := cilium.processConfigChanges(ciliumConfig{})
+	require.NotNil(t, newConfig)
+
+	manifest, found := saver["cilium.yaml"]
+	require.True(t, found, "must write the cilium manifests")
+	assert.Contains(t, string(manifest), `cluster-pool-ipv4-cidr: "10.200.0.0/16"`)
+	assert.Contains(t, string(manifest), `cluster-pool-ipv4-mask-size: "24"`)
+	assert.Contains(t, string(manifest), "image: quay.io/cilium/cilium:")
+
+	delete(saver, "cilium.yaml")
+	assert.Nil(t, cilium.processConfigChanges(*newConfig))
+	assert.Empty(t, saver, "must not rewrite unchanged manifests")
+}
@@ -19,11 +19,14 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"path/filepath"
+	"strings"
+	"time"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
@@ -33,9 +36,14 @@ import (
 // ControllerManager implement the component interface to run kube scheduler
 type ControllerManager struct {
 	ClusterConfig *config.ClusterConfig
-	supervisor    supervisor.Supervisor
-	uid           int
-	gid           int
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
+	supervisor supervisor.Supervisor
+	args       map[string]string
+	uid        int
+	gid        int
+	log        *logrus.Entry
 }
 
 var cmDefaultArgs = map[string]string{
@@ -51,17 +59,18 @@ var cmDefaultArgs = map[string]string{
 
 // Init extracts the needed binaries
 func (a *ControllerManager) Init() error {
+	a.log = logging.ForComponent("kube-controller-manager")
 	var err error
 	a.uid, err = util.GetUID(constant.ControllerManagerUser)
 	if err != nil {
-		logrus.Warning(errors.Wrap(err, "Running kube-controller-manager as root"))
+		a.log.Warning(errors.Wrap(err, "Running kube-controller-manager as root"))
 	}
 	a.gid, _ = util.GetGID(constant.Group)
 
 	// controller manager should be the only component that needs access to
 	// ca.key so let it own it.
 	if err := os.Chown(path.Join(constant.CertRootDir, "ca.key"), a.uid, -1); err != nil {
-		logrus.Warning(errors.Wrap(err, "Can't change permissions for the ca.key"))
+		a.log.Warning(errors.Wrap(err, "Can't change permissions for the ca.key"))
 	}
 
 	return assets.Stage(constant.BinDir, "kube-controller-manager", constant.BinDirMode, constant.Group)
@@ -69,8 +78,8 @@ func (a *ControllerManager) Init() error {
 
 // Run runs kube ControllerManager
 func (a *ControllerManager) Run() error {
-	logrus.Info("Starting kube-controller-manager")
-	ccmAuthConf := filepath.Join(constant.CertRootDir, "ccm.conf")
+	a.log.Info("Starting kube-controller-manager")
+	ccmAuthConf := constant.ControllerManagerKubeconfigPath
 	args := map[string]string{
 		"authentication-kubeconfig":        ccmAuthConf,
 		"authorization-kubeconfig":         ccmAuthConf,
@@ -85,6 +94,12 @@ func (a *ControllerManager) Run() error {
 		"service-cluster-ip-range":         a.ClusterConfig.Spec.Network.ServiceCIDR,
 		"profiling":                        "false",
 	}
+	for name, value := range a.ClusterConfig.Spec.ControllerManager.Args() {
+		args[name] = value
+	}
+	if v := a.ClusterConfig.Spec.LogVerbosity; v != nil && v.ControllerManager > 0 {
+		args["v"] = config.VerbosityArg(v.ControllerManager)
+	}
 	for name, value := range a.ClusterConfig.Spec.ControllerManager.ExtraArgs {
 		if args[name] != "" && name != "profiling" {
 			return fmt.Errorf("cannot override kube-controller-manager flag: %s", name)
@@ -96,16 +111,21 @@ func (a *ControllerManager) Run() error {
 			args[name] = value
 		}
 	}
+	if !csrControllersEnabled(args["controllers"]) {
+		a.log.Warn("the csrapproving or csrsigning controller is disabled, the kubelet client certificate renewals won't be approved and signed")
+	}
 	cmArgs := []string{}
 	for name, value := range args {
 		cmArgs = append(cmArgs, fmt.Sprintf("--%s=%s", name, value))
 	}
+	a.args = args
 	a.supervisor = supervisor.Supervisor{
-		Name:    "kube-controller-manager",
-		BinPath: assets.BinPath("kube-controller-manager"),
-		Args:    cmArgs,
-		UID:     a.uid,
-		GID:     a.gid,
+		Name:        "kube-controller-manager",
+		BinPath:     assets.BinPath("kube-controller-manager"),
+		Args:        cmArgs,
+		UID:         a.uid,
+		GID:         a.gid,
+		TimeoutStop: a.StopTimeout,
 	}
 
 	a.supervisor.Supervise()
@@ -113,10 +133,36 @@ func (a *ControllerManager) Run() error {
 	return nil
 }
 
+// csrControllersEnabled returns true if the given --controllers list runs the controllers approving and signing the
+// kubelet client certificate CSRs
+func csrControllersEnabled(controllers string) bool {
+	list := strings.Split(controllers, ",")
+	for _, name := range []string{"csrapproving", "csrsigning"} {
+		switch {
+		case util.StringSliceContains(list, name):
+		case util.StringSliceContains(list, "-"+name):
+			return false
+		case !util.StringSliceContains(list, "*"):
+			return false
+		}
+	}
+	return true
+}
+
 // Stop stops ControllerManager
 func (a *ControllerManager) Stop() error {
-	return a.supervisor.Stop()
+	if err := a.supervisor.Stop(); err != nil {
+		return err
+	}
+	releaseLeaderLock("kube-controller-manager", a.args)
+	return nil
 }
 
+// Version returns the version of the launched kube-controller-manager binary
+func (a *ControllerManager) Version() string { return a.supervisor.Version() }
+
 // Health-check interface
 func (a *ControllerManager) Healthy() error { return nil }
+
+// DependsOn for the restartable interface
+func (a *ControllerManager) DependsOn() []component.Component { return nil }
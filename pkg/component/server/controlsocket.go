@@ -0,0 +1,181 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
+	"github.com/k0sproject/k0s/pkg/preflight"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// ControlSocket serves node local operations, such as restarting single components,
+// on a unix socket only accessible to root
+type ControlSocket struct {
+	ComponentManager *component.Manager
+	// ClusterConfig is the config the server runs with
+	ClusterConfig *config.ClusterConfig
+	// Role is the role of the node, controller or controller+worker
+	Role string
+	// Storage is the storage backend the server runs with, as logged on startup
+	Storage string
+
+	server *http.Server
+	log    *logrus.Entry
+}
+
+// Init removes a possibly stale socket left behind by a previous k0s process
+func (c *ControlSocket) Init() error {
+	c.log = logging.ForComponent("control-socket")
+	if err := util.InitDirectory(constant.RunDir, constant.RunDirMode); err != nil {
+		return err
+	}
+	if err := os.Remove(constant.ControlSocketPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove stale control socket")
+	}
+	return nil
+}
+
+// Run starts serving the control socket
+func (c *ControlSocket) Run() error {
+	listener, err := net.Listen("unix", constant.ControlSocketPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen on control socket")
+	}
+	if err := os.Chmod(constant.ControlSocketPath, constant.ControlSocketMode); err != nil {
+		listener.Close()
+		return errors.Wrap(err, "failed to set control socket permissions")
+	}
+
+	router := mux.NewRouter()
+	router.Path("/v1beta1/components/{name}/restart").Methods("POST").HandlerFunc(c.restartHandler)
+	router.Path("/v1beta1/status").Methods("GET").HandlerFunc(c.statusHandler)
+	router.Path("/v1beta1/config").Methods("GET").HandlerFunc(c.configHandler)
+
+	c.server = &http.Server{
+		Handler: router,
+	}
+	go func() {
+		if err := c.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			c.log.Errorf("control socket failed: %s", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops serving the control socket, waiting for in-flight operations to finish
+func (c *ControlSocket) Stop() error {
+	if c.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	return c.server.Shutdown(ctx)
+}
+
+// Healthy for health-check interface
+func (c *ControlSocket) Healthy() error { return nil }
+
+func (c *ControlSocket) restartHandler(resp http.ResponseWriter, req *http.Request) {
+	name := mux.Vars(req)["name"]
+	c.log.Infof("restart of %s requested", name)
+
+	err := c.ComponentManager.Restart(name)
+	if err == nil {
+		resp.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	code := http.StatusInternalServerError
+	if _, ok := err.(*component.RestartRejectedError); ok {
+		code = http.StatusConflict
+	}
+	c.log.Error(err)
+	resp.Header().Set("Content-Type", "text/plain")
+	resp.WriteHeader(code)
+	_, _ = resp.Write([]byte(err.Error()))
+}
+
+func (c *ControlSocket) statusHandler(resp http.ResponseWriter, req *http.Request) {
+	status := config.StatusResponse{
+		Role:            c.Role,
+		Storage:         c.Storage,
+		Components:      c.ComponentManager.Names(),
+		ComponentStatus: c.ComponentManager.Statuses(),
+		Unhealthy:       c.ComponentManager.Unhealthy(),
+	}
+	for _, p := range supervisor.Processes() {
+		status.Processes = append(status.Processes, config.ProcessStatus{
+			Name:    p.Name,
+			Command: p.Command,
+		})
+	}
+	now := time.Now()
+	for _, r := range ReconcilerStatuses() {
+		status.Reconcilers = append(status.Reconcilers, config.ReconcilerStatus{
+			Name:          r.Name,
+			Interval:      r.Interval,
+			LastSuccess:   r.LastSuccess,
+			LastError:     r.LastError,
+			LastErrorTime: r.LastErrorTime,
+			Stale:         r.Stale(now),
+		})
+	}
+
+	// missing if the server was started without preflight checks
+	results, _ := preflight.Load(constant.PreflightResultsPath)
+	for _, r := range results {
+		status.Preflight = append(status.Preflight, config.PreflightResult{
+			Name:     r.Name,
+			Passed:   r.Passed,
+			Detail:   r.Detail,
+			Severity: string(r.Severity),
+		})
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(resp).Encode(status); err != nil {
+		c.log.Errorf("failed to write status response: %s", err)
+	}
+}
+
+func (c *ControlSocket) configHandler(resp http.ResponseWriter, req *http.Request) {
+	data, err := yaml.Marshal(c.ClusterConfig)
+	if err != nil {
+		c.log.Errorf("failed to marshal the config: %s", err)
+		resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/yaml")
+	if _, err := resp.Write(data); err != nil {
+		c.log.Errorf("failed to write config response: %s", err)
+	}
+}
@@ -16,9 +16,11 @@ limitations under the License.
 package server
 
 import (
+	"html/template"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
@@ -88,6 +90,9 @@ data:
         errors
         health
         ready
+{{- if .ExtraConfig }}
+{{ .ExtraConfig }}
+{{- end }}
         kubernetes {{ .ClusterDomain }} in-addr.arpa ip6.arpa {
           pods insecure
           ttl 30
@@ -122,8 +127,15 @@ spec:
     metadata:
       labels:
         k8s-app: kube-dns
+{{- if .SafeToEvict }}
+      annotations:
+        cluster-autoscaler.kubernetes.io/safe-to-evict: "{{ .SafeToEvict }}"
+{{- end }}
     spec:
       serviceAccountName: coredns
+{{- if .PriorityClassName }}
+      priorityClassName: {{ .PriorityClassName }}
+{{- end }}
       tolerations:
         - key: "CriticalAddonsOnly"
           operator: "Exists"
@@ -235,6 +247,12 @@ type coreDNSConfig struct {
 	ClusterDNSIP  string
 	ClusterDomain string
 	Image         string
+	// SafeToEvict is the cluster-autoscaler safe-to-evict annotation value, not annotated if empty
+	SafeToEvict string
+	// PriorityClassName is the priority class of the pods, none if the system priority is disabled
+	PriorityClassName string
+	// ExtraConfig is the user provided Corefile snippet, already indented and not to be HTML escaped by the template writer
+	ExtraConfig template.HTML
 }
 
 // NewCoreDNS creates new instance of CoreDNS component
@@ -269,7 +287,7 @@ func (c *CoreDNS) Run() error {
 	// TODO calculate replicas, max-surge etc. based on amount of nodes
 
 	go func() {
-		ticker := time.NewTicker(10 * time.Second)
+		ticker := time.NewTicker(reconcileInterval)
 		defer ticker.Stop()
 		var previousConfig = coreDNSConfig{}
 		for {
@@ -278,10 +296,12 @@ func (c *CoreDNS) Run() error {
 				config, err := c.getConfig()
 				if err != nil {
 					c.log.Errorf("error calculating coredns configs: %s. will retry", err.Error())
+					reportReconcile("coredns", reconcileInterval, err)
 					continue
 				}
 				if config == previousConfig {
 					c.log.Infof("current config matches existing, not gonna do anything")
+					reportReconcile("coredns", reconcileInterval, nil)
 					continue
 				}
 				tw := util.TemplateWriter{
@@ -293,8 +313,10 @@ func (c *CoreDNS) Run() error {
 				err = tw.Write()
 				if err != nil {
 					c.log.Errorf("error writing coredns manifests: %s. will retry", err.Error())
+					reportReconcile("coredns", reconcileInterval, err)
 					continue
 				}
+				reportReconcile("coredns", reconcileInterval, nil)
 				previousConfig = config
 			case <-c.tickerDone:
 				c.log.Info("coredns reconciler done")
@@ -317,11 +339,33 @@ func (c *CoreDNS) getConfig() (coreDNSConfig, error) {
 		ClusterDomain: "cluster.local",
 		ClusterDNSIP:  dns,
 		Image:         c.clusterConfig.Images.CoreDNS.URI(),
+		SafeToEvict:   c.clusterConfig.Spec.SafeToEvict.CoreDNSValue(),
+	}
+	if c.clusterConfig.Spec.SystemPriority.IsEnabled() {
+		config.PriorityClassName = "system-cluster-critical"
+	}
+	if c.clusterConfig.Spec.CoreDNS != nil {
+		config.ExtraConfig = template.HTML(indentCorefileSnippet(c.clusterConfig.Spec.CoreDNS.ExtraConfig))
 	}
 
 	return config, nil
 }
 
+// indentCorefileSnippet indents the snippet to the level of the plugins within the Corefile server block
+func indentCorefileSnippet(snippet string) string {
+	snippet = strings.TrimRight(snippet, " \n")
+	if strings.TrimSpace(snippet) == "" {
+		return ""
+	}
+	lines := strings.Split(snippet, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = "        " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Stop stops the CoreDNS reconciler
 func (c *CoreDNS) Stop() error {
 	close(c.tickerDone)
@@ -330,3 +374,6 @@ func (c *CoreDNS) Stop() error {
 
 // Health-check interface
 func (c *CoreDNS) Healthy() error { return nil }
+
+// ReadinessDependencies makes the reconciler wait for the CNI, the pods would crash loop without pod networking
+func (c *CoreDNS) ReadinessDependencies() []string { return []string{"calico", "cilium", "kuberouter"} }
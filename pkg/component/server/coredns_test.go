@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func renderCoreDNS(t *testing.T, clusterConfig *config.ClusterConfig) string {
+	c := &CoreDNS{clusterConfig: clusterConfig}
+	cfg, err := c.getConfig()
+	require.NoError(t, err)
+
+	buf := bytes.NewBuffer([]byte{})
+	tw := util.TemplateWriter{
+		Name:     "coredns",
+		Template: coreDNSTemplate,
+		Data:     cfg,
+	}
+	require.NoError(t, tw.WriteToBuffer(buf))
+	return buf.String()
+}
+
+func TestCoreDNSExtraConfig(t *testing.T) {
+	t.Run("default_corefile", func(t *testing.T) {
+		manifest := renderCoreDNS(t, config.DefaultClusterConfig())
+		assert.Contains(t, manifest, "        ready\n        kubernetes cluster.local")
+	})
+
+	t.Run("extra_config_before_kubernetes", func(t *testing.T) {
+		clusterConfig := config.DefaultClusterConfig()
+		clusterConfig.Spec.CoreDNS.ExtraConfig = "rewrite name \"foo.example.com\" foo.default.svc.cluster.local\nhosts {\n  10.0.0.1 legacy.example.com\n  fallthrough\n}\n"
+		manifest := renderCoreDNS(t, clusterConfig)
+		assert.Contains(t, manifest, `        ready
+        rewrite name "foo.example.com" foo.default.svc.cluster.local
+        hosts {
+          10.0.0.1 legacy.example.com
+          fallthrough
+        }
+        kubernetes cluster.local`)
+	})
+}
+
+func TestCoreDNSSafeToEvict(t *testing.T) {
+	clusterConfig := config.DefaultClusterConfig()
+	assert.NotContains(t, renderCoreDNS(t, clusterConfig), config.SafeToEvictAnnotation)
+
+	clusterConfig.Spec.SafeToEvict.Enabled = true
+	assert.Contains(t, renderCoreDNS(t, clusterConfig), `
+      annotations:
+        cluster-autoscaler.kubernetes.io/safe-to-evict: "true"
+    spec:`)
+}
+
+func TestCoreDNSPriorityClass(t *testing.T) {
+	clusterConfig := config.DefaultClusterConfig()
+	assert.Contains(t, renderCoreDNS(t, clusterConfig), "      priorityClassName: system-cluster-critical\n")
+
+	clusterConfig.Spec.SystemPriority.Enabled = false
+	assert.NotContains(t, renderCoreDNS(t, clusterConfig), "priorityClassName")
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+const defaultLimitsTemplate = `
+{{- range .Namespaces }}
+---
+apiVersion: v1
+kind: LimitRange
+metadata:
+  name: k0s-default-limits
+  namespace: {{ . }}
+spec:
+  limits:
+  - type: Container
+{{- if $.DefaultRequest }}
+    defaultRequest:
+{{- range $name, $value := $.DefaultRequest }}
+      {{ $name }}: "{{ $value }}"
+{{- end }}
+{{- end }}
+{{- if $.Default }}
+    default:
+{{- range $name, $value := $.Default }}
+      {{ $name }}: "{{ $value }}"
+{{- end }}
+{{- end }}
+{{- if $.Quota }}
+---
+apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: k0s-default-quota
+  namespace: {{ . }}
+spec:
+  hard:
+{{- range $name, $value := $.Quota }}
+    {{ $name }}: "{{ $value }}"
+{{- end }}
+{{- end }}
+{{- end }}
+`
+
+// DefaultLimits is the reconciler for the default LimitRange and ResourceQuota of namespaces
+type DefaultLimits struct {
+	clusterSpec *config.ClusterSpec
+	client      kubernetes.Interface
+	log         *logrus.Entry
+	tickerDone  chan struct{}
+}
+
+// NewDefaultLimits creates new DefaultLimits reconciler
+func NewDefaultLimits(clusterSpec *config.ClusterSpec) (*DefaultLimits, error) {
+	log := logrus.WithFields(logrus.Fields{"component": "defaultLimits"})
+	return &DefaultLimits{
+		log:         log,
+		clusterSpec: clusterSpec,
+	}, nil
+}
+
+// Init does nothing
+func (d *DefaultLimits) Init() error {
+	return nil
+}
+
+// Run runs the default limits reconciler, or removes the manifests if the default limits are disabled
+func (d *DefaultLimits) Run() error {
+	limitsDir := path.Join(constant.ManifestsDir, "defaultlimits")
+	if d.clusterSpec.DefaultLimits == nil || !d.clusterSpec.DefaultLimits.Enabled {
+		// removing the manifests makes the applier delete the objects written while enabled
+		err := os.RemoveAll(limitsDir)
+		reportReconcile("defaultLimits", 0, err)
+		return err
+	}
+
+	d.tickerDone = make(chan struct{})
+	err := os.MkdirAll(limitsDir, constant.ManifestsDirMode)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				namespaces, err := d.targetNamespaces()
+				if err != nil {
+					d.log.Warnf("failed to list namespaces: %s. will retry", err.Error())
+					reportReconcile("defaultLimits", reconcileInterval, err)
+					continue
+				}
+				tw := util.TemplateWriter{
+					Name:     "defaultLimits",
+					Template: defaultLimitsTemplate,
+					Data: struct {
+						Namespaces     []string
+						DefaultRequest map[string]string
+						Default        map[string]string
+						Quota          map[string]string
+					}{
+						Namespaces:     namespaces,
+						DefaultRequest: d.clusterSpec.DefaultLimits.DefaultRequest,
+						Default:        d.clusterSpec.DefaultLimits.Default,
+						Quota:          d.clusterSpec.DefaultLimits.Quota,
+					},
+					Path: filepath.Join(limitsDir, "default-limits.yaml"),
+				}
+				if err := tw.Write(); err != nil {
+					d.log.Errorf("error writing default limits manifests: %s. will retry", err.Error())
+					reportReconcile("defaultLimits", reconcileInterval, err)
+					continue
+				}
+				reportReconcile("defaultLimits", reconcileInterval, nil)
+			case <-d.tickerDone:
+				d.log.Info("default limits reconciler done")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// targetNamespaces returns the configured namespaces which exist and did not opt out via label
+func (d *DefaultLimits) targetNamespaces() ([]string, error) {
+	if d.client == nil {
+		client, err := kubeutil.Client(constant.AdminKubeconfigConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		d.client = client
+	}
+
+	var namespaces []string
+	for _, name := range d.clusterSpec.DefaultLimits.Namespaces {
+		ns, err := d.client.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			d.log.Debugf("skipping non-existing namespace %s", name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ns.Labels[config.DefaultLimitsOptOutLabel] == "disabled" {
+			continue
+		}
+		namespaces = append(namespaces, name)
+	}
+	return namespaces, nil
+}
+
+// Stop stops the reconciler
+func (d *DefaultLimits) Stop() error {
+	if d.tickerDone != nil {
+		close(d.tickerDone)
+	}
+	return nil
+}
+
+// Health-check interface
+func (d *DefaultLimits) Healthy() error { return nil }
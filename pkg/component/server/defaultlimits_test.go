@@ -0,0 +1,51 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestDefaultLimitsDisabledRemovesManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-manifests")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	constant.SetDataDir(dir)
+	defer constant.SetDataDir(constant.DefaultDataDir)
+
+	limitsDir := filepath.Join(constant.ManifestsDir, "defaultlimits")
+	require.NoError(t, os.MkdirAll(limitsDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(limitsDir, "default-limits.yaml"), []byte("kind: LimitRange"), 0644))
+
+	clusterSpec := config.DefaultClusterSpec()
+	clusterSpec.DefaultLimits.Enabled = false
+	d, err := NewDefaultLimits(clusterSpec)
+	require.NoError(t, err)
+	require.NoError(t, d.Run())
+	require.NoError(t, d.Stop())
+
+	_, err = os.Stat(limitsDir)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+const defaultNetworkPolicyTemplate = `
+{{- range .Namespaces }}
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: k0s-default-deny
+  namespace: {{ . }}
+spec:
+  podSelector: {}
+  policyTypes:
+{{- if $.Ingress }}
+  - Ingress
+{{- end }}
+{{- if $.Egress }}
+  - Egress
+{{- if $.AllowDNS }}
+  egress:
+  - to:
+    - namespaceSelector: {}
+      podSelector:
+        matchLabels:
+          k8s-app: kube-dns
+    ports:
+    - protocol: UDP
+      port: 53
+    - protocol: TCP
+      port: 53
+{{- end }}
+{{- end }}
+{{- end }}
+`
+
+// DefaultNetworkPolicy is the reconciler for the default-deny NetworkPolicies of the workload namespaces
+type DefaultNetworkPolicy struct {
+	clusterSpec *config.ClusterSpec
+	client      kubernetes.Interface
+	log         *logrus.Entry
+	tickerDone  chan struct{}
+}
+
+// NewDefaultNetworkPolicy creates new DefaultNetworkPolicy reconciler
+func NewDefaultNetworkPolicy(clusterSpec *config.ClusterSpec) (*DefaultNetworkPolicy, error) {
+	log := logrus.WithFields(logrus.Fields{"component": "defaultNetworkPolicy"})
+	return &DefaultNetworkPolicy{
+		log:         log,
+		clusterSpec: clusterSpec,
+	}, nil
+}
+
+// Init does nothing
+func (d *DefaultNetworkPolicy) Init() error {
+	return nil
+}
+
+// Run runs the default network policy reconciler, or removes the manifests if the policies are disabled. The policies
+// of all the target namespaces are written into a single manifest on each round, so namespaces created, relabeled or
+// deleted in between are picked up by the applier.
+func (d *DefaultNetworkPolicy) Run() error {
+	policyDir := path.Join(constant.ManifestsDir, "defaultnetworkpolicy")
+	if !d.enabled() {
+		// removing the manifests makes the applier delete the policies written while enabled
+		err := os.RemoveAll(policyDir)
+		reportReconcile("defaultNetworkPolicy", 0, err)
+		return err
+	}
+
+	d.tickerDone = make(chan struct{})
+	err := os.MkdirAll(policyDir, constant.ManifestsDirMode)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				namespaces, err := d.targetNamespaces()
+				if err != nil {
+					d.log.Warnf("failed to list namespaces: %s. will retry", err.Error())
+					reportReconcile("defaultNetworkPolicy", reconcileInterval, err)
+					continue
+				}
+				policy := d.clusterSpec.Network.DefaultNetworkPolicy
+				tw := util.TemplateWriter{
+					Name:     "defaultNetworkPolicy",
+					Template: defaultNetworkPolicyTemplate,
+					Data: struct {
+						Namespaces []string
+						Ingress    bool
+						Egress     bool
+						AllowDNS   bool
+					}{
+						Namespaces: namespaces,
+						Ingress:    policy.Ingress,
+						Egress:     policy.Egress,
+						AllowDNS:   policy.AllowDNS,
+					},
+					Path: filepath.Join(policyDir, "default-network-policy.yaml"),
+				}
+				if err := tw.Write(); err != nil {
+					d.log.Errorf("error writing default network policy manifests: %s. will retry", err.Error())
+					reportReconcile("defaultNetworkPolicy", reconcileInterval, err)
+					continue
+				}
+				reportReconcile("defaultNetworkPolicy", reconcileInterval, nil)
+			case <-d.tickerDone:
+				d.log.Info("default network policy reconciler done")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// targetNamespaces returns the namespaces matching the selector, except the system and the excluded ones
+func (d *DefaultNetworkPolicy) targetNamespaces() ([]string, error) {
+	if d.client == nil {
+		client, err := kubeutil.Client(constant.AdminKubeconfigConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		d.client = client
+	}
+
+	policy := d.clusterSpec.Network.DefaultNetworkPolicy
+	list, err := d.client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: policy.NamespaceSelector})
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []string
+	for _, ns := range list.Items {
+		if util.StringSliceContains(config.SystemNamespaces, ns.Name) || util.StringSliceContains(policy.ExcludedNamespaces, ns.Name) {
+			continue
+		}
+		namespaces = append(namespaces, ns.Name)
+	}
+	return namespaces, nil
+}
+
+// Stop stops the reconciler
+func (d *DefaultNetworkPolicy) Stop() error {
+	if d.tickerDone != nil {
+		close(d.tickerDone)
+	}
+	return nil
+}
+
+// enabled tells whether the policies are enabled, they are validated to be ignored, with a warning, if the CNI is not
+// known to enforce them
+func (d *DefaultNetworkPolicy) enabled() bool {
+	policy := d.clusterSpec.Network.DefaultNetworkPolicy
+	return policy != nil && policy.Enabled && d.clusterSpec.Network.EnforcesNetworkPolicy()
+}
+
+// Health-check interface
+func (d *DefaultNetworkPolicy) Healthy() error { return nil }
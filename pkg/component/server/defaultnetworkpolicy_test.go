@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestDefaultNetworkPolicyTargetNamespaces(t *testing.T) {
+	clusterSpec := config.DefaultClusterSpec()
+	clusterSpec.Network.DefaultNetworkPolicy.Enabled = true
+	clusterSpec.Network.DefaultNetworkPolicy.ExcludedNamespaces = []string{"monitoring"}
+	d, err := NewDefaultNetworkPolicy(clusterSpec)
+	require.NoError(t, err)
+	d.client = fake.NewSimpleClientset(
+		namespace("default", nil),
+		namespace("kube-system", nil),
+		namespace("kube-public", nil),
+		namespace("monitoring", nil),
+		namespace("shop", map[string]string{"team": "shop"}),
+	)
+
+	namespaces, err := d.targetNamespaces()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "shop"}, namespaces)
+
+	clusterSpec.Network.DefaultNetworkPolicy.NamespaceSelector = "team=shop"
+	namespaces, err = d.targetNamespaces()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop"}, namespaces)
+}
+
+func TestDefaultNetworkPolicyDisabledRemovesManifests(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-manifests")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	constant.SetDataDir(dir)
+	defer constant.SetDataDir(constant.DefaultDataDir)
+
+	policyDir := filepath.Join(constant.ManifestsDir, "defaultnetworkpolicy")
+	require.NoError(t, os.MkdirAll(policyDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(policyDir, "default-network-policy.yaml"), []byte("kind: NetworkPolicy"), 0644))
+
+	clusterSpec := config.DefaultClusterSpec()
+	clusterSpec.Network.DefaultNetworkPolicy.Enabled = false
+	d, err := NewDefaultNetworkPolicy(clusterSpec)
+	require.NoError(t, err)
+	require.NoError(t, d.Run())
+	require.NoError(t, d.Stop())
+
+	_, err = os.Stat(policyDir)
+	assert.True(t, os.IsNotExist(err))
+}
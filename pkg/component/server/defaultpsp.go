@@ -49,9 +49,10 @@ func (d *DefaultPSP) Init() error {
 }
 
 // Run reconciles the k0s default PSP rules
-func (d *DefaultPSP) Run() error {
+func (d *DefaultPSP) Run() (err error) {
+	defer func() { reportReconcile("default-psp", 0, err) }()
 	pspDir := path.Join(constant.ManifestsDir, "defaultpsp")
-	err := os.MkdirAll(pspDir, constant.ManifestsDirMode)
+	err = os.MkdirAll(pspDir, constant.ManifestsDirMode)
 	if err != nil {
 		return err
 	}
@@ -19,9 +19,11 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,22 +31,20 @@ import (
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/assets"
 	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/etcd"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
-var (
-	etcdCaCert     = filepath.Join(constant.EtcdCertDir, "ca.crt")
-	etcdCaCertKey  = filepath.Join(constant.EtcdCertDir, "ca.key")
-	etcdServerCert = filepath.Join(constant.EtcdCertDir, "server.crt")
-	etcdServerKey  = filepath.Join(constant.EtcdCertDir, "server.key")
-	etcdPeerCert   = filepath.Join(constant.EtcdCertDir, "peer.crt")
-	etcdPeerKey    = filepath.Join(constant.EtcdCertDir, "peer.key")
-)
+// etcdCertPath returns the path of an etcd certificate file in the current data dir
+func etcdCertPath(name string) string {
+	return filepath.Join(constant.EtcdCertDir, name)
+}
 
 // Etcd implement the component interface to run etcd
 type Etcd struct {
@@ -52,24 +52,35 @@ type Etcd struct {
 	Join        bool
 	JoinClient  *v1beta1.JoinClient
 	CertManager certificate.Manager
+	// ForceNewCluster makes etcd discard the cluster membership and start as a single member cluster from its data
+	ForceNewCluster bool
+
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
 
 	supervisor supervisor.Supervisor
 	uid        int
 	gid        int
+	log        *logrus.Entry
 }
 
 // Init extracts the needed binaries
 func (e *Etcd) Init() error {
+	e.log = logging.ForComponent("etcd")
 	var err error
 	e.uid, err = util.GetUID(constant.EtcdUser)
 	if err != nil {
-		logrus.Warning(errors.Wrap(err, "Running etcd as root"))
+		e.log.Warning(errors.Wrap(err, "Running etcd as root"))
 	}
 	e.gid, _ = util.GetGID(constant.Group)
 
-	err = util.InitDirectory(constant.EtcdDataDir, constant.EtcdDataDirMode) // https://docs.datadoghq.com/security_monitoring/default_rules/cis-kubernetes-1.5.1-1.1.11/
+	dataDir := e.Config.GetDataDir()
+	err = util.InitDirectory(dataDir, constant.EtcdDataDirMode) // https://docs.datadoghq.com/security_monitoring/default_rules/cis-kubernetes-1.5.1-1.1.11/
 	if err != nil {
-		return errors.Wrapf(err, "failed to create %s", constant.EtcdDataDir)
+		return errors.Wrapf(err, "failed to create %s", dataDir)
+	}
+	if err := util.CheckWritable(dataDir); err != nil {
+		return err
 	}
 
 	err = util.InitDirectory(constant.EtcdCertDir, constant.EtcdCertDirMode) // https://docs.datadoghq.com/security_monitoring/default_rules/cis-kubernetes-1.5.1-4.1.7/
@@ -77,7 +88,7 @@ func (e *Etcd) Init() error {
 		return errors.Wrapf(err, "failed to create etcd cert dir")
 	}
 
-	for _, f := range []string{constant.EtcdDataDir, constant.EtcdCertDir} {
+	for _, f := range []string{dataDir, constant.EtcdCertDir} {
 		err = os.Chown(f, e.uid, e.gid)
 		if err != nil {
 			return err
@@ -91,7 +102,7 @@ func (e *Etcd) Init() error {
 	} {
 		if err := os.Chown(path.Join(constant.EtcdCertDir, f), e.uid, e.gid); err != nil {
 			// TODO: directory may not yet exist. log it and wait for retry for now
-			logrus.Errorf("failed to chown %s: %s", f, err)
+			e.log.Errorf("failed to chown %s: %s", f, err)
 		}
 	}
 
@@ -100,58 +111,69 @@ func (e *Etcd) Init() error {
 
 // Run runs etcd
 func (e *Etcd) Run() error {
-	logrus.Info("Starting etcd")
+	e.log.Info("Starting etcd")
 
 	name, err := os.Hostname()
 	if err != nil {
 		return err
 	}
 
-	peerURL := fmt.Sprintf("https://%s:2380", e.Config.PeerAddress)
+	peerURL := e.Config.PeerURL()
+	if bindIP := net.ParseIP(e.Config.GetBindAddress()); !bindIP.IsUnspecified() && !bindIP.Equal(net.ParseIP(e.Config.PeerAddress)) {
+		e.log.Warnf("etcd listens on %s but advertises %s to its peers, other members might not be able to reach it", e.Config.GetBindAddress(), e.Config.PeerAddress)
+	}
 	args := []string{
-		fmt.Sprintf("--data-dir=%s", constant.EtcdDataDir),
-		"--listen-client-urls=https://127.0.0.1:2379",
-		"--advertise-client-urls=https://127.0.0.1:2379",
+		fmt.Sprintf("--data-dir=%s", e.Config.GetDataDir()),
+		fmt.Sprintf("--listen-client-urls=%s", e.Config.ClientURL()),
+		fmt.Sprintf("--advertise-client-urls=%s", e.Config.ClientURL()),
 		"--client-cert-auth=true",
-		fmt.Sprintf("--listen-peer-urls=%s", peerURL),
+		fmt.Sprintf("--listen-peer-urls=https://%s", net.JoinHostPort(e.Config.GetBindAddress(), strconv.Itoa(e.Config.GetPeerPort()))),
 		fmt.Sprintf("--initial-advertise-peer-urls=%s", peerURL),
 		fmt.Sprintf("--name=%s", name),
-		fmt.Sprintf("--trusted-ca-file=%s", etcdCaCert),
-		fmt.Sprintf("--cert-file=%s", etcdServerCert),
-		fmt.Sprintf("--key-file=%s", etcdServerKey),
-		fmt.Sprintf("--peer-trusted-ca-file=%s", etcdCaCert),
-		fmt.Sprintf("--peer-key-file=%s", etcdPeerKey),
-		fmt.Sprintf("--peer-cert-file=%s", etcdPeerCert),
+		fmt.Sprintf("--trusted-ca-file=%s", etcdCertPath("ca.crt")),
+		fmt.Sprintf("--cert-file=%s", etcdCertPath("server.crt")),
+		fmt.Sprintf("--key-file=%s", etcdCertPath("server.key")),
+		fmt.Sprintf("--peer-trusted-ca-file=%s", etcdCertPath("ca.crt")),
+		fmt.Sprintf("--peer-key-file=%s", etcdCertPath("peer.key")),
+		fmt.Sprintf("--peer-cert-file=%s", etcdCertPath("peer.crt")),
 		"--peer-client-cert-auth=true",
 		"--enable-pprof=false",
 	}
+	args = append(args, e.Config.AutoCompactionArgs()...)
+	if e.ForceNewCluster {
+		e.log.Warn("forcing a new single member etcd cluster")
+		args = append(args, "--force-new-cluster")
+	}
 
-	if util.FileExists(filepath.Join(constant.EtcdDataDir, "member", "snap", "db")) {
-		logrus.Warnf("etcd db file(s) already exist, not gonna run join process")
+	if util.FileExists(filepath.Join(e.Config.GetDataDir(), "member", "snap", "db")) {
+		e.log.Warnf("etcd db file(s) already exist, not gonna run join process")
 		e.Join = false
 	}
 
 	if e.Join {
-		logrus.Infof("starting to sync etcd config")
+		if err := e.checkClockSkew(); err != nil {
+			return err
+		}
+		e.log.Infof("starting to sync etcd config")
 		etcdResponse, err := e.JoinClient.JoinEtcd(peerURL)
 		if err != nil {
 			return err
 		}
-		logrus.Infof("got cluster info: %v", etcdResponse.InitialCluster)
+		e.log.Infof("got cluster info: %v", etcdResponse.InitialCluster)
 		// Write etcd ca cert&key
-		if util.FileExists(etcdCaCert) && util.FileExists(etcdCaCertKey) {
-			logrus.Warnf("etcd ca certs already exists, not gonna overwrite. If you wish to re-sync them, delete the existing ones.")
+		if util.FileExists(etcdCertPath("ca.crt")) && util.FileExists(etcdCertPath("ca.key")) {
+			e.log.Warnf("etcd ca certs already exists, not gonna overwrite. If you wish to re-sync them, delete the existing ones.")
 		} else {
-			err = ioutil.WriteFile(etcdCaCertKey, etcdResponse.CA.Key, constant.CertSecureMode)
+			err = ioutil.WriteFile(etcdCertPath("ca.key"), etcdResponse.CA.Key, constant.CertSecureMode)
 			if err != nil {
 				return err
 			}
 
-			err = ioutil.WriteFile(etcdCaCert, etcdResponse.CA.Cert, constant.CertSecureMode)
+			err = ioutil.WriteFile(etcdCertPath("ca.crt"), etcdResponse.CA.Cert, constant.CertSecureMode)
 			if err != nil {
 				return err
 			}
-			for _, f := range []string{filepath.Dir(etcdCaCertKey), etcdCaCertKey, etcdCaCert} {
+			for _, f := range []string{filepath.Dir(etcdCertPath("ca.key")), etcdCertPath("ca.key"), etcdCertPath("ca.crt")} {
 				if err := os.Chown(f, e.uid, e.gid); err != nil {
 					return err
 				}
@@ -166,15 +188,16 @@ func (e *Etcd) Run() error {
 		return errors.Wrap(err, "failed to create etcd certs")
 	}
 
-	logrus.Infof("starting etcd with args: %v", args)
+	e.log.Infof("starting etcd with args: %v", args)
 
 	e.supervisor = supervisor.Supervisor{
-		Name:    "etcd",
-		BinPath: assets.BinPath("etcd"),
-		Dir:     constant.DataDir,
-		Args:    args,
-		UID:     e.uid,
-		GID:     e.gid,
+		Name:        "etcd",
+		BinPath:     assets.BinPath("etcd"),
+		Dir:         constant.DataDir,
+		Args:        args,
+		UID:         e.uid,
+		GID:         e.gid,
+		TimeoutStop: e.StopTimeout,
 	}
 
 	e.supervisor.Supervise()
@@ -187,6 +210,30 @@ func (e *Etcd) Stop() error {
 	return e.supervisor.Stop()
 }
 
+// Version returns the version of the launched etcd binary
+func (e *Etcd) Version() string { return e.supervisor.Version() }
+
+// checkClockSkew refuses to join when the clock of this node is too far off from the existing controllers,
+// etcd members with skewed clocks cause leader elections and lease expiry issues
+func (e *Etcd) checkClockSkew() error {
+	maxSkew := e.Config.MaxClockSkew
+	if maxSkew <= 0 {
+		maxSkew = config.DefaultEtcdMaxClockSkew
+	}
+	skew, err := e.JoinClient.ClockSkew()
+	if err != nil {
+		return errors.Wrap(err, "failed to check clock skew against the existing controllers")
+	}
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return fmt.Errorf("clock of this node differs by at least %s from the existing controllers (max %s), refusing to join etcd. Make sure all controllers synchronize their time, e.g. using NTP", skew, maxSkew)
+	}
+	e.log.Debugf("clock skew to the existing controllers: %s", skew)
+	return nil
+}
+
 func (e *Etcd) setupCerts() error {
 	if err := e.CertManager.EnsureCA("etcd/ca", "etcd-ca"); err != nil {
 		return errors.Wrap(err, "failed to create etcd ca")
@@ -197,8 +244,8 @@ func (e *Etcd) setupCerts() error {
 		Name:   "apiserver-etcd-client",
 		CN:     "apiserver-etcd-client",
 		O:      "apiserver-etcd-client",
-		CACert: etcdCaCert,
-		CAKey:  etcdCaCertKey,
+		CACert: etcdCertPath("ca.crt"),
+		CAKey:  etcdCertPath("ca.key"),
 		Hostnames: []string{
 			"127.0.0.1",
 			"localhost",
@@ -212,8 +259,8 @@ func (e *Etcd) setupCerts() error {
 		Name:   filepath.Join("etcd", "server"),
 		CN:     "etcd-server",
 		O:      "etcd-server",
-		CACert: etcdCaCert,
-		CAKey:  etcdCaCertKey,
+		CACert: etcdCertPath("ca.crt"),
+		CAKey:  etcdCertPath("ca.key"),
 		Hostnames: []string{
 			"127.0.0.1",
 			"localhost",
@@ -227,8 +274,8 @@ func (e *Etcd) setupCerts() error {
 		Name:   filepath.Join("etcd", "peer"),
 		CN:     e.Config.PeerAddress,
 		O:      "etcd-peer",
-		CACert: etcdCaCert,
-		CAKey:  etcdCaCertKey,
+		CACert: etcdCertPath("ca.crt"),
+		CAKey:  etcdCertPath("ca.key"),
 		Hostnames: []string{
 			e.Config.PeerAddress,
 		},
@@ -240,17 +287,22 @@ func (e *Etcd) setupCerts() error {
 	return nil
 }
 
-// Health-check interface
+// Healthy checks the etcd member once, for the health-check interface
 func (e *Etcd) Healthy() error {
-	if err := waitForHealthy(); err != nil {
-		return err
-	}
-	return nil
+	return etcd.CheckEtcdReady(e.Config.ClientURL())
 }
 
-// waitForHealthy waits until etcd is healthy and returns true upon success. If a timeout occurs, it returns false
-func waitForHealthy() error {
-	log := logrus.WithField("component", "etcd")
+// WaitHealthy waits for up to 2 minutes until the etcd member is healthy, e.g. after starting it
+func (e *Etcd) WaitHealthy() error {
+	return waitForHealthy(e.Config.ClientURL())
+}
+
+// DependsOn for the restartable interface
+func (e *Etcd) DependsOn() []component.Component { return nil }
+
+// waitForHealthy waits until the etcd member at endpoint is healthy and returns true upon success. If a timeout occurs, it returns false
+func waitForHealthy(endpoint string) error {
+	log := logging.ForComponent("etcd")
 	ctx, cancelFunction := context.WithTimeout(context.Background(), 2*time.Minute)
 
 	// clear up context after timeout
@@ -262,7 +314,7 @@ func waitForHealthy() error {
 		select {
 		case <-ticker.C:
 			log.Debug("checking etcd endpoint for health")
-			err := etcd.CheckEtcdReady()
+			err := etcd.CheckEtcdReady(endpoint)
 			if err != nil {
 				log.Errorf("health-check: etcd might be down: %v", err)
 			} else {
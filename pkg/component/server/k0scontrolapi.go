@@ -18,9 +18,13 @@ package server
 import (
 	"fmt"
 	"os"
+	"time"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/supervisor"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/sirupsen/logrus"
 )
 
 // K0SControlAPI implements the k0s control API component
@@ -28,25 +32,44 @@ type K0SControlAPI struct {
 	ConfigPath    string
 	ClusterConfig *config.ClusterConfig
 
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
 	supervisor supervisor.Supervisor
+	nodeID     string
 }
 
-// Init does currently nothing
+// Init determines the node id the api reports
 func (m *K0SControlAPI) Init() error {
 	// We need to create a serving cert for the api
+	nodeID, err := util.NodeID(m.ClusterConfig.Spec.API.Address)
+	if err != nil {
+		return err
+	}
+	m.nodeID = nodeID
+	logrus.WithField("nodeID", nodeID).Info("k0s control api node id determined")
 	return nil
 }
 
+// NodeID returns the id of the node, stable across restarts
+func (m *K0SControlAPI) NodeID() string {
+	return m.nodeID
+}
+
 // Run runs k0s control api as separate process
 func (m *K0SControlAPI) Run() error {
 	// TODO: Make the api process to use some other user
+	args := []string{"api"}
+	// without a config file the api falls back to the defaults as the server did
+	if m.ConfigPath != "" {
+		args = append(args, fmt.Sprintf("--config=%s", m.ConfigPath))
+	}
+	args = append(args, fmt.Sprintf("--node-id=%s", m.nodeID))
 	m.supervisor = supervisor.Supervisor{
-		Name:    "k0s-control-api",
-		BinPath: os.Args[0],
-		Args: []string{
-			"api",
-			fmt.Sprintf("--config=%s", m.ConfigPath),
-		},
+		Name:        "k0s-control-api",
+		BinPath:     os.Args[0],
+		Args:        args,
+		TimeoutStop: m.StopTimeout,
 	}
 
 	m.supervisor.Supervise()
@@ -60,3 +83,6 @@ func (m *K0SControlAPI) Stop() error {
 
 // Healthy for health-check interface
 func (m *K0SControlAPI) Healthy() error { return nil }
+
+// DependsOn for the restartable interface
+func (m *K0SControlAPI) DependsOn() []component.Component { return nil }
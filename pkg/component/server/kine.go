@@ -17,13 +17,20 @@ package server
 
 import (
 	"fmt"
-	"net/url"
+	"net"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/avast/retry-go"
 
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
@@ -32,40 +39,57 @@ import (
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 )
 
+const (
+	// kineWatchInterval is how often the size of the sqlite database is checked
+	kineWatchInterval = time.Minute
+	// kineDBDialTimeout limits connecting to the database server in the health check
+	kineDBDialTimeout = 5 * time.Second
+	// kineWaitAttempts is how often WaitHealthy checks the database server, backing off from kineWaitDelay up to
+	// kineWaitMaxDelay between the attempts
+	kineWaitAttempts = 8
+	kineWaitDelay    = 500 * time.Millisecond
+	kineWaitMaxDelay = 10 * time.Second
+)
+
 // Kine implement the component interface to run kine
 type Kine struct {
-	Config     *config.KineConfig
+	Config *config.KineConfig
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
 	supervisor supervisor.Supervisor
 	uid        int
 	gid        int
+	dbSize     int64
+	watchDone  chan struct{}
+	log        *logrus.Entry
 }
 
 // Init extracts the needed binaries
 func (k *Kine) Init() error {
+	k.log = logging.ForComponent("kine")
 	var err error
 	k.uid, err = util.GetUID(constant.KineUser)
 	if err != nil {
-		logrus.Warning(errors.Wrap(err, "Running kine as root"))
+		k.log.Warning(errors.Wrap(err, "Running kine as root"))
 	}
 
 	k.gid, _ = util.GetGID(constant.Group)
 
-	dsURL, err := url.Parse(k.Config.DataSource)
-	if err != nil {
-		return err
-	}
-	if dsURL.Scheme == "sqlite" {
+	// a networked database needs no local directory
+	if k.Config.DataSourceScheme() == config.KineSQLiteScheme {
+		dbPath := k.Config.SQLitePath()
 		// Make sure the db basedir exists
-		err = util.InitDirectory(filepath.Dir(dsURL.Path), 0750)
+		err = util.InitDirectory(filepath.Dir(dbPath), 0750)
 		if err != nil {
-			return errors.Wrapf(err, "failed to create dir %s", filepath.Dir(dsURL.Path))
+			return errors.Wrapf(err, "failed to create dir %s", filepath.Dir(dbPath))
 		}
-		err = os.Chown(filepath.Dir(dsURL.Path), k.uid, k.gid)
+		err = os.Chown(filepath.Dir(dbPath), k.uid, k.gid)
 		if err != nil {
-			return errors.Wrapf(err, "failed to chown dir %s", filepath.Dir(dsURL.Path))
+			return errors.Wrapf(err, "failed to chown dir %s", filepath.Dir(dbPath))
 		}
-		if err := os.Chown(dsURL.Path, k.uid, k.gid); err != nil {
-			logrus.Warningf("datasource file %s does not exist", dsURL.Path)
+		if err := os.Chown(dbPath, k.uid, k.gid); err != nil {
+			k.log.Warningf("datasource file %s does not exist", dbPath)
 		}
 	}
 	return assets.Stage(constant.BinDir, "kine", constant.BinDirMode, constant.Group)
@@ -73,30 +97,157 @@ func (k *Kine) Init() error {
 
 // Run runs kine
 func (k *Kine) Run() error {
-	logrus.Info("Starting kine")
-	logrus.Debugf("datasource: %s", k.Config.DataSource)
+	k.log.Info("Starting kine")
+	k.log.Debugf("datasource scheme: %s", k.Config.DataSourceScheme())
 
+	args := []string{
+		fmt.Sprintf("--endpoint=%s", k.Config.DataSource),
+		fmt.Sprintf("--listen-address=unix://%s", path.Join(constant.RunDir, "kine.sock:2379")),
+	}
+	if k.Config.IsTLSConfigured() {
+		// kine connects to the database with mutual TLS
+		args = append(args,
+			fmt.Sprintf("--ca-file=%s", k.Config.CAFile),
+			fmt.Sprintf("--cert-file=%s", k.Config.CertFile),
+			fmt.Sprintf("--key-file=%s", k.Config.KeyFile),
+		)
+	}
 	k.supervisor = supervisor.Supervisor{
-		Name:    "kine",
-		BinPath: assets.BinPath("kine"),
-		Dir:     constant.DataDir,
-		Args: []string{
-			fmt.Sprintf("--endpoint=%s", k.Config.DataSource),
-			fmt.Sprintf("--listen-address=unix://%s", path.Join(constant.RunDir, "kine.sock:2379")),
-		},
-		UID: k.uid,
-		GID: k.gid,
+		Name:        "kine",
+		BinPath:     assets.BinPath("kine"),
+		Dir:         constant.DataDir,
+		Args:        args,
+		UID:         k.uid,
+		GID:         k.gid,
+		TimeoutStop: k.StopTimeout,
 	}
 
 	k.supervisor.Supervise()
 
+	if k.Config.DataSourceScheme() == config.KineSQLiteScheme {
+		thresholds, err := k.Config.DBSizeWarnThresholdBytes()
+		if err != nil {
+			return err
+		}
+		k.watchDone = make(chan struct{})
+		go k.watchDB(k.Config.SQLitePath(), thresholds)
+	}
+
 	return nil
 }
 
+// watchDB keeps track of the sqlite database size, warns when it grows past the thresholds and vacuums it if configured
+func (k *Kine) watchDB(dbPath string, thresholds []int64) {
+	log := logging.ForComponent("kine")
+
+	var vacuum <-chan time.Time
+	if k.Config.VacuumInterval > 0 {
+		if _, err := exec.LookPath("sqlite3"); err != nil {
+			log.Warnf("vacuumInterval is set but sqlite3 command is not available, not vacuuming %s", dbPath)
+		} else {
+			vacuumTicker := time.NewTicker(k.Config.VacuumInterval)
+			defer vacuumTicker.Stop()
+			vacuum = vacuumTicker.C
+		}
+	}
+	ticker := time.NewTicker(kineWatchInterval)
+	defer ticker.Stop()
+
+	crossed := 0
+	for {
+		size := sqliteDBSize(dbPath)
+		atomic.StoreInt64(&k.dbSize, size)
+		reached := 0
+		for _, t := range thresholds {
+			if size >= t {
+				reached++
+			}
+		}
+		if reached > crossed {
+			log.Warnf("kine database %s has grown to %s, past the warning threshold of %s", dbPath, formatBytes(size), formatBytes(thresholds[reached-1]))
+		}
+		crossed = reached
+
+		select {
+		case <-ticker.C:
+		case <-vacuum:
+			log.Infof("vacuuming kine database %s", dbPath)
+			// sqlite3 waits for the lock held by kine instead of failing immediately
+			out, err := exec.Command("sqlite3", "-cmd", ".timeout 30000", dbPath, "VACUUM;").CombinedOutput()
+			if err != nil {
+				log.Warnf("failed to vacuum kine database: %s: %s", err, out)
+			}
+		case <-k.watchDone:
+			return
+		}
+	}
+}
+
+// sqliteDBSize returns the size of the database including its write-ahead log
+func sqliteDBSize(dbPath string) int64 {
+	var size int64
+	for _, f := range []string{dbPath, dbPath + "-wal"} {
+		if fi, err := os.Stat(f); err == nil {
+			size += fi.Size()
+		}
+	}
+	return size
+}
+
+func formatBytes(size int64) string {
+	return fmt.Sprintf("%.1fMiB", float64(size)/(1024*1024))
+}
+
 // Stop stops kine
 func (k *Kine) Stop() error {
+	if k.watchDone != nil {
+		close(k.watchDone)
+		k.watchDone = nil
+	}
 	return k.supervisor.Stop()
 }
 
-// Health-check interface
-func (k *Kine) Healthy() error { return nil }
+// Version returns the version of the launched kine binary
+func (k *Kine) Version() string { return k.supervisor.Version() }
+
+// Status reports the size of the sqlite database
+func (k *Kine) Status() string {
+	size := atomic.LoadInt64(&k.dbSize)
+	if size == 0 {
+		return ""
+	}
+	return fmt.Sprintf("database size %s", formatBytes(size))
+}
+
+// Healthy checks the database server of a networked datasource is reachable, a sqlite database is always local
+func (k *Kine) Healthy() error {
+	if !k.Config.IsNetworked() {
+		return nil
+	}
+	network, address, err := k.Config.DataSourceAddress()
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout(network, address, kineDBDialTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "kine %s database is not reachable", k.Config.DataSourceScheme())
+	}
+	return conn.Close()
+}
+
+// WaitHealthy retries the health check with a bounded backoff, e.g. while the database server is starting up along
+// with k0s
+func (k *Kine) WaitHealthy() error {
+	return retry.Do(k.Healthy,
+		retry.Attempts(kineWaitAttempts),
+		retry.Delay(kineWaitDelay),
+		retry.MaxDelay(kineWaitMaxDelay),
+		retry.DelayType(retry.BackOffDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			k.log.Warnf("health check attempt %d failed: %s", n+1, err)
+		}))
+}
+
+// DependsOn for the restartable interface
+func (k *Kine) DependsOn() []component.Component { return nil }
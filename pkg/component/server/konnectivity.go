@@ -19,13 +19,16 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 )
@@ -33,17 +36,22 @@ import (
 // Konnectivity implement the component interface of konnectivity server
 type Konnectivity struct {
 	ClusterConfig *config.ClusterConfig
-	supervisor    supervisor.Supervisor
-	uid           int
-	gid           int
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
+	supervisor supervisor.Supervisor
+	uid        int
+	gid        int
+	log        *logrus.Entry
 }
 
 // Init ...
 func (k *Konnectivity) Init() error {
+	k.log = logging.ForComponent("konnectivity")
 	var err error
 	k.uid, err = util.GetUID(constant.KonnectivityServerUser)
 	if err != nil {
-		logrus.Warning(errors.Wrap(err, "Running konnectivity as root"))
+		k.log.Warning(errors.Wrap(err, "Running konnectivity as root"))
 	}
 
 	k.gid, _ = util.GetGID(constant.Group)
@@ -53,7 +61,13 @@ func (k *Konnectivity) Init() error {
 
 // Run ..
 func (k *Konnectivity) Run() error {
-	logrus.Info("Starting konnectivity")
+	if k.ClusterConfig.Spec.Konnectivity.IsDirect() {
+		k.log.Info("Konnectivity mode is direct, not starting konnectivity")
+		// removing the manifests makes the applier delete the agents deployed in proxy mode
+		return os.RemoveAll(path.Join(constant.ManifestsDir, "konnectivity"))
+	}
+
+	k.log.Info("Starting konnectivity")
 	k.supervisor = supervisor.Supervisor{
 		Name:    "konnectivity",
 		BinPath: assets.BinPath("konnectivity-server"),
@@ -75,8 +89,9 @@ func (k *Konnectivity) Run() error {
 			"-v=2",
 			"--enable-profiling=false",
 		},
-		UID: k.uid,
-		GID: k.gid,
+		UID:         k.uid,
+		GID:         k.gid,
+		TimeoutStop: k.StopTimeout,
 	}
 
 	k.supervisor.Supervise()
@@ -89,6 +104,9 @@ func (k *Konnectivity) Stop() error {
 	return k.supervisor.Stop()
 }
 
+// Version returns the version of the launched konnectivity-server binary
+func (k *Konnectivity) Version() string { return k.supervisor.Version() }
+
 type konnectivityAgentConfig struct {
 	APIAddress string
 	Image      string
@@ -201,3 +219,6 @@ spec:
 
 // Health-check interface
 func (k *Konnectivity) Healthy() error { return nil }
+
+// DependsOn for the restartable interface
+func (k *Konnectivity) DependsOn() []component.Component { return nil }
@@ -59,7 +59,8 @@ func (k *KubeletConfig) Stop() error {
 }
 
 // Run dumps the needed manifest objects
-func (k *KubeletConfig) Run() error {
+func (k *KubeletConfig) Run() (err error) {
+	defer func() { reportReconcile("kubeletConfig", 0, err) }()
 	dnsAddress, err := k.clusterSpec.Network.DNSAddress()
 	if err != nil {
 		return fmt.Errorf("failed to get DNS address for kubelet config: %v", err)
@@ -79,14 +80,22 @@ func (k *KubeletConfig) Run() error {
 
 func (k *KubeletConfig) run(dnsAddress string) (*bytes.Buffer, error) {
 	manifest := bytes.NewBuffer([]byte{})
-	defaultProfile := getDefaultProfile(dnsAddress)
+	defaultProfile := k.getDefaultProfile(dnsAddress)
 
-	if err := k.writeConfigMapWithProfile(manifest, "default", defaultProfile); err != nil {
+	if err := k.writeConfigMapWithProfile(manifest, "default", defaultProfile, nil); err != nil {
 		return nil, fmt.Errorf("can't write manifest for default profile config map: %v", err)
 	}
 	configMapNames := []string{formatProfileName("default")}
+	nodePodCapacity := config.NodePodCIDRCapacity(k.clusterSpec.Network.PodCIDR, k.clusterSpec.ControllerManager.NodeCIDRMaskSize())
 	for _, profile := range k.clusterSpec.WorkerProfiles {
-		profileConfig := getDefaultProfile(dnsAddress)
+		profileConfig := k.getDefaultProfile(dnsAddress)
+		if profile.MaxPods > 0 {
+			profileConfig["maxPods"] = profile.MaxPods
+		}
+		// the kubelet uses the pod CIDR the controller manager assigns to its node
+		if nodePodCapacity >= 0 && profile.EffectiveMaxPods() > nodePodCapacity {
+			k.log.Warnf("worker profile %s allows %d pods but the /%d pod CIDR of each node can only address %d pods", profile.Name, profile.EffectiveMaxPods(), k.clusterSpec.ControllerManager.NodeCIDRMaskSize(), nodePodCapacity)
+		}
 		merged, err := mergeProfiles(&profileConfig, profile.Values)
 		if err != nil {
 			return nil, fmt.Errorf("can't merge profile `%s` with default profile: %v", profile.Name, err)
@@ -94,7 +103,8 @@ func (k *KubeletConfig) run(dnsAddress string) (*bytes.Buffer, error) {
 
 		if err := k.writeConfigMapWithProfile(manifest,
 			profile.Name,
-			merged); err != nil {
+			merged,
+			profile.ExtraArgs); err != nil {
 			return nil, fmt.Errorf("can't write manifest for profile config map: %v", err)
 		}
 		configMapNames = append(configMapNames, formatProfileName(profile.Name))
@@ -121,20 +131,28 @@ func (k *KubeletConfig) save(data []byte) error {
 
 type unstructuredYamlObject map[string]interface{}
 
-func (k *KubeletConfig) writeConfigMapWithProfile(w io.Writer, name string, profile unstructuredYamlObject) error {
+func (k *KubeletConfig) writeConfigMapWithProfile(w io.Writer, name string, profile unstructuredYamlObject, extraArgs map[string]string) error {
 	profileYaml, err := yaml.Marshal(profile)
 	if err != nil {
 		return err
 	}
+	var extraArgsYaml []byte
+	if len(extraArgs) > 0 {
+		if extraArgsYaml, err = yaml.Marshal(extraArgs); err != nil {
+			return err
+		}
+	}
 	tw := util.TemplateWriter{
 		Name:     "kubelet-config",
 		Template: kubeletConfigsManifestTemplate,
 		Data: struct {
 			Name              string
 			KubeletConfigYAML string
+			ExtraArgsYAML     string
 		}{
 			Name:              formatProfileName(name),
 			KubeletConfigYAML: string(profileYaml),
+			ExtraArgsYAML:     string(extraArgsYaml),
 		},
 	}
 	return tw.WriteToBuffer(w)
@@ -158,6 +176,15 @@ func (k *KubeletConfig) writeRbacRoleBindings(w io.Writer, configMapNames []stri
 	return tw.WriteToBuffer(w)
 }
 
+// getDefaultProfile returns the default profile with the cluster wide kubelet settings of the config applied
+func (k *KubeletConfig) getDefaultProfile(dnsAddress string) unstructuredYamlObject {
+	profile := getDefaultProfile(dnsAddress)
+	if k.clusterSpec.KubeletCertificates.ServerTLSBootstrap() {
+		profile["serverTLSBootstrap"] = true
+	}
+	return profile
+}
+
 func getDefaultProfile(dnsAddress string) unstructuredYamlObject {
 	// the motivation to keep it like this instead of the yaml template:
 	// - it's easier to merge programatically defined structure
@@ -175,7 +202,7 @@ func getDefaultProfile(dnsAddress string) unstructuredYamlObject {
 				"enabled":  true,
 			},
 			"x509": map[string]interface{}{
-				"clientCAFile": "/var/lib/k0s/pki/ca.crt",
+				"clientCAFile": filepath.Join(constant.CertRootDir, "ca.crt"),
 			},
 		},
 		"authorization": map[string]interface{}{
@@ -211,6 +238,10 @@ metadata:
 data:
   kubelet: | 
 {{ .KubeletConfigYAML | nindent 4 }}
+{{- if .ExtraArgsYAML }}
+  extraArgs: |
+{{ .ExtraArgsYAML | nindent 4 }}
+{{- end }}
 `
 
 const rbacRoleAndBindingsManifestTemplate = `---
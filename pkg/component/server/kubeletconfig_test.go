@@ -39,6 +39,39 @@ func Test_KubeletConfig(t *testing.T) {
 			assertRoleBinding(t, manifestYamls[2])
 		})
 	})
+	t.Run("with_server_certificate_rotation", func(t *testing.T) {
+		k := defaultConfigWithUserProvidedProfiles(t)
+		k.clusterSpec.KubeletCertificates.RotateServerCertificates = true
+		buf, err := k.run(dnsAddr)
+		assert.NoError(t, err)
+		manifestYamls := strings.Split(strings.TrimSuffix(buf.String(), "---"), "---")[1:]
+		for _, manifest := range manifestYamls[:3] {
+			profile := struct {
+				Data map[string]string `yaml:"data"`
+			}{}
+			assert.NoError(t, yaml.Unmarshal([]byte(manifest), &profile))
+			kubeletConfig := map[string]interface{}{}
+			assert.NoError(t, yaml.Unmarshal([]byte(profile.Data["kubelet"]), &kubeletConfig))
+			assert.Equal(t, true, kubeletConfig["serverTLSBootstrap"])
+		}
+	})
+	t.Run("with_extra_args", func(t *testing.T) {
+		k := defaultConfigWithUserProvidedProfiles(t)
+		k.clusterSpec.WorkerProfiles[0].ExtraArgs = map[string]string{"system-reserved": "cpu=500m,memory=1Gi"}
+		buf, err := k.run(dnsAddr)
+		assert.NoError(t, err)
+		manifestYamls := strings.Split(strings.TrimSuffix(buf.String(), "---"), "---")[1:]
+		profiles := make([]struct {
+			Data map[string]string `yaml:"data"`
+		}, 2)
+		for i := range profiles {
+			assert.NoError(t, yaml.Unmarshal([]byte(manifestYamls[i]), &profiles[i]))
+		}
+		assert.NotContains(t, profiles[0].Data, "extraArgs", "the default profile has no extra args")
+		extraArgs := map[string]string{}
+		assert.NoError(t, yaml.Unmarshal([]byte(profiles[1].Data["extraArgs"]), &extraArgs))
+		assert.Equal(t, map[string]string{"system-reserved": "cpu=500m,memory=1Gi"}, extraArgs)
+	})
 	t.Run("with_user_provided_profiles", func(t *testing.T) {
 		k := defaultConfigWithUserProvidedProfiles(t)
 		buf, err := k.run(dnsAddr)
@@ -62,7 +62,7 @@ func (k *KubeProxy) Run() error {
 	}
 
 	go func() {
-		ticker := time.NewTicker(10 * time.Second)
+		ticker := time.NewTicker(reconcileInterval)
 		defer ticker.Stop()
 		var previousConfig = proxyConfig{}
 		for {
@@ -71,10 +71,12 @@ func (k *KubeProxy) Run() error {
 				config, err := k.getConfig()
 				if err != nil {
 					k.log.Errorf("error calculating proxy configs: %s. will retry", err.Error())
+					reportReconcile("kube-proxy", reconcileInterval, err)
 					continue
 				}
 				if config == previousConfig {
 					k.log.Infof("current config matches existing, not gonna do anything")
+					reportReconcile("kube-proxy", reconcileInterval, nil)
 					continue
 				}
 				tw := util.TemplateWriter{
@@ -86,8 +88,10 @@ func (k *KubeProxy) Run() error {
 				err = tw.Write()
 				if err != nil {
 					k.log.Errorf("error writing kube-proxy manifests: %s. will retry", err.Error())
+					reportReconcile("kube-proxy", reconcileInterval, err)
 					continue
 				}
+				reportReconcile("kube-proxy", reconcileInterval, nil)
 				previousConfig = config
 			case <-k.tickerDone:
 				k.log.Info("proxy reconciler done")
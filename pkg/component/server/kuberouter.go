@@ -0,0 +1,365 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"bytes"
+	"time"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
+// KubeRouter is the Component interface implementation to manage kube-router
+type KubeRouter struct {
+	clusterConf *config.ClusterConfig
+	tickerDone  chan struct{}
+	log         *logrus.Entry
+
+	saver  manifestsSaver
+	client kubernetes.Interface
+}
+
+type kubeRouterConfig struct {
+	ClusterCIDR  string
+	MTU          int
+	ServiceProxy bool
+	APIAddress   string
+
+	CNIImage          string
+	CNIInstallerImage string
+}
+
+// NewKubeRouter creates new KubeRouter reconciler component
+func NewKubeRouter(clusterConf *config.ClusterConfig, saver manifestsSaver) (*KubeRouter, error) {
+	log := logrus.WithFields(logrus.Fields{"component": "kuberouter"})
+	return &KubeRouter{
+		clusterConf: clusterConf,
+		log:         log,
+		saver:       saver,
+	}, nil
+}
+
+// Init does nothing
+func (k *KubeRouter) Init() error {
+	return nil
+}
+
+// Run runs the kube-router reconciler
+func (k *KubeRouter) Run() error {
+	k.tickerDone = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(reconcileInterval)
+		defer ticker.Stop()
+		var previousConfig = kubeRouterConfig{}
+		for {
+			select {
+			case <-ticker.C:
+				newConfig := k.processConfigChanges(previousConfig)
+				if newConfig != nil {
+					previousConfig = *newConfig
+				}
+			case <-k.tickerDone:
+				k.log.Info("kube-router reconciler done")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (k *KubeRouter) processConfigChanges(previousConfig kubeRouterConfig) *kubeRouterConfig {
+	config := k.getConfig()
+	if config == previousConfig {
+		k.log.Infof("current config matches existing, not gonna do anything")
+		reportReconcile("kuberouter", reconcileInterval, nil)
+		return nil
+	}
+
+	output := bytes.NewBuffer([]byte{})
+	tw := util.TemplateWriter{
+		Name:     "kube-router",
+		Template: kubeRouterTemplate,
+		Data:     config,
+	}
+	if err := tw.WriteToBuffer(output); err != nil {
+		k.log.Errorf("error writing kube-router manifests: %s. will retry", err.Error())
+		reportReconcile("kuberouter", reconcileInterval, err)
+		return nil
+	}
+	if err := k.saver.Save("kube-router.yaml", output.Bytes()); err != nil {
+		k.log.Errorf("error saving kube-router manifests: %s. will retry", err.Error())
+		reportReconcile("kuberouter", reconcileInterval, errors.Wrap(err, "failed to save kube-router manifests"))
+		return nil
+	}
+
+	reportReconcile("kuberouter", reconcileInterval, nil)
+	return &config
+}
+
+func (k *KubeRouter) getConfig() kubeRouterConfig {
+	kubeRouter := k.clusterConf.Spec.Network.KubeRouter
+	if kubeRouter == nil {
+		kubeRouter = config.DefaultKubeRouter()
+	}
+	return kubeRouterConfig{
+		ClusterCIDR:       k.clusterConf.Spec.Network.PodCIDR,
+		MTU:               kubeRouter.MTU,
+		ServiceProxy:      kubeRouter.ServiceProxy,
+		APIAddress:        k.clusterConf.Spec.API.Address,
+		CNIImage:          k.clusterConf.Images.KubeRouter.CNI.URI(),
+		CNIInstallerImage: k.clusterConf.Images.KubeRouter.CNIInstaller.URI(),
+	}
+}
+
+// Stop stops the kube-router reconciler
+func (k *KubeRouter) Stop() error {
+	close(k.tickerDone)
+	return nil
+}
+
+// Health-check interface
+func (k *KubeRouter) Healthy() error { return nil }
+
+// Ready reports kube-router as ready for the addons once its manifests are written and a node became ready with it
+func (k *KubeRouter) Ready() error {
+	if err := reconciled("kuberouter"); err != nil {
+		return err
+	}
+	if k.client == nil {
+		client, err := kubeutil.Client(constant.AdminKubeconfigConfigPath)
+		if err != nil {
+			return err
+		}
+		k.client = client
+	}
+	return anyNodeReady(k.client)
+}
+
+// kubeRouterTemplate deploys kube-router routing the pod CIDRs the controller manager assigns to the nodes and
+// enforcing the NetworkPolicies. The CNI plugins and config are installed on the nodes by init containers, rewriting
+// the config on every start of the pods. Handling the services, kube-router can't rely on the kubernetes service, so it
+// talks to the API server directly.
+const kubeRouterTemplate = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kube-router-cfg
+  namespace: kube-system
+  labels:
+    tier: node
+    k8s-app: kube-router
+data:
+  cni-conf.json: |
+    {
+      "cniVersion": "0.3.1",
+      "name": "kubernetes",
+      "plugins": [
+        {
+          "name": "kubernetes",
+          "type": "bridge",
+          "bridge": "kube-bridge",
+          "isDefaultGateway": true,
+{{- if .MTU }}
+          "mtu": {{ .MTU }},
+{{- end }}
+          "ipam": {
+            "type": "host-local"
+          }
+        },
+        {
+          "type": "portmap",
+          "snat": true,
+          "capabilities": {
+            "portMappings": true
+          }
+        }
+      ]
+    }
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: kube-router
+  namespace: kube-system
+---
+kind: ClusterRole
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: kube-router
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - namespaces
+  - pods
+  - services
+  - nodes
+  - endpoints
+  verbs:
+  - list
+  - get
+  - watch
+- apiGroups:
+  - networking.k8s.io
+  resources:
+  - networkpolicies
+  verbs:
+  - list
+  - get
+  - watch
+- apiGroups:
+  - extensions
+  resources:
+  - networkpolicies
+  verbs:
+  - get
+  - list
+  - watch
+---
+kind: ClusterRoleBinding
+apiVersion: rbac.authorization.k8s.io/v1
+metadata:
+  name: kube-router
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: kube-router
+subjects:
+- kind: ServiceAccount
+  name: kube-router
+  namespace: kube-system
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: kube-router
+  namespace: kube-system
+  labels:
+    k8s-app: kube-router
+spec:
+  selector:
+    matchLabels:
+      k8s-app: kube-router
+  template:
+    metadata:
+      labels:
+        k8s-app: kube-router
+    spec:
+      priorityClassName: system-node-critical
+      serviceAccountName: kube-router
+      hostNetwork: true
+      tolerations:
+      - operator: Exists
+      initContainers:
+      - name: install-cni-bins
+        image: {{ .CNIInstallerImage }}
+        imagePullPolicy: IfNotPresent
+        volumeMounts:
+        - name: cni-bin
+          mountPath: /host/opt/cni/bin
+      - name: install-cniconf
+        image: {{ .CNIImage }}
+        imagePullPolicy: IfNotPresent
+        command:
+        - /bin/sh
+        - -c
+        - set -e -x;
+          rm -f /etc/cni/net.d/*.conf;
+          TMP=/etc/cni/net.d/.tmp-kuberouter-cfg;
+          cp /etc/kube-router/cni-conf.json ${TMP};
+          mv ${TMP} /etc/cni/net.d/10-kuberouter.conflist
+        volumeMounts:
+        - name: cni-conf-dir
+          mountPath: /etc/cni/net.d
+        - name: kube-router-cfg
+          mountPath: /etc/kube-router
+      containers:
+      - name: kube-router
+        image: {{ .CNIImage }}
+        imagePullPolicy: IfNotPresent
+        args:
+        - --run-router=true
+        - --run-firewall=true
+        - --run-service-proxy={{ .ServiceProxy }}
+        - --bgp-graceful-restart=true
+        - --cluster-cidr={{ .ClusterCIDR }}
+{{- if not .MTU }}
+        - --auto-mtu=true
+{{- else }}
+        - --auto-mtu=false
+{{- end }}
+        env:
+        - name: NODE_NAME
+          valueFrom:
+            fieldRef:
+              fieldPath: spec.nodeName
+        - name: KUBE_ROUTER_CNI_CONF_FILE
+          value: /etc/cni/net.d/10-kuberouter.conflist
+{{- if .ServiceProxy }}
+        - name: KUBERNETES_SERVICE_HOST
+          value: "{{ .APIAddress }}"
+        - name: KUBERNETES_SERVICE_PORT
+          value: "6443"
+{{- end }}
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 20244
+          initialDelaySeconds: 10
+          periodSeconds: 3
+        resources:
+          requests:
+            cpu: 250m
+            memory: 16Mi
+        securityContext:
+          privileged: true
+        volumeMounts:
+        - name: lib-modules
+          mountPath: /lib/modules
+          readOnly: true
+        - name: cni-conf-dir
+          mountPath: /etc/cni/net.d
+        - name: xtables-lock
+          mountPath: /run/xtables.lock
+          readOnly: false
+      volumes:
+      - name: lib-modules
+        hostPath:
+          path: /lib/modules
+      - name: cni-bin
+        hostPath:
+          path: /opt/cni/bin
+          type: DirectoryOrCreate
+      - name: cni-conf-dir
+        hostPath:
+          path: /etc/cni/net.d
+          type: DirectoryOrCreate
+      - name: kube-router-cfg
+        configMap:
+          name: kube-router-cfg
+      - name: xtables-lock
+        hostPath:
+          path: /run/xtables.lock
+          type: FileOrCreate
+`
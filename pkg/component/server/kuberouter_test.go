@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"testing"
+
+	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubeRouterManifests(t *testing.T) {
+	t.Run("defaults_leave_services_to_kube_proxy", func(t *testing.T) {
+		cfg := v1beta1.DefaultClusterConfig()
+		cfg.Spec.Network.Provider = "kuberouter"
+		saver := inMemorySaver{}
+		kubeRouter, err := NewKubeRouter(cfg, saver)
+		require.NoError(t, err)
+		require.NotNil(t, kubeRouter.processConfigChanges(kubeRouterConfig{}))
+
+		manifest := string(saver["kube-router.yaml"])
+		assert.Contains(t, manifest, "--cluster-cidr=10.244.0.0/16")
+		assert.Contains(t, manifest, "--run-service-proxy=false")
+		assert.Contains(t, manifest, "--auto-mtu=true")
+		assert.NotContains(t, manifest, `"mtu"`)
+		assert.NotContains(t, manifest, "KUBERNETES_SERVICE_HOST")
+	})
+
+	t.Run("service_proxy_and_mtu", func(t *testing.T) {
+		cfg := v1beta1.DefaultClusterConfig()
+		cfg.Spec.Network.Provider = "kuberouter"
+		cfg.Spec.Network.KubeRouter = &v1beta1.KubeRouter{MTU: 1400, ServiceProxy: true}
+		cfg.Spec.API.Address = "10.0.0.10"
+		saver := inMemorySaver{}
+		kubeRouter, err := NewKubeRouter(cfg, saver)
+		require.NoError(t, err)
+		require.NotNil(t, kubeRouter.processConfigChanges(kubeRouterConfig{}))
+
+		manifest := string(saver["kube-router.yaml"])
+		assert.Contains(t, manifest, "--run-service-proxy=true")
+		assert.Contains(t, manifest, "--auto-mtu=false")
+		assert.Contains(t, manifest, `"mtu": 1400,`)
+		assert.Contains(t, manifest, `value: "10.0.0.10"`)
+	})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/leaderelection"
+	"github.com/k0sproject/k0s/pkg/logging"
+)
+
+// leaderLockReleaseTimeout bounds the delay of the shutdown if the API isn't reachable anymore
+const leaderLockReleaseTimeout = 5 * time.Second
+
+// releaseLeaderLock releases the leader election lock held by the stopped kube component on this node,
+// so that the component on another controller takes over without waiting for the lease to expire
+func releaseLeaderLock(component string, args map[string]string) {
+	if args["leader-elect"] != "true" {
+		return
+	}
+	log := logging.ForComponent(component)
+
+	lockType := args["leader-elect-resource-lock"]
+	if lockType == "" {
+		lockType = resourcelock.EndpointsLeasesResourceLock
+	}
+	namespace := args["leader-elect-resource-namespace"]
+	if namespace == "" {
+		namespace = "kube-system"
+	}
+	name := args["leader-elect-resource-name"]
+	if name == "" {
+		name = component
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Warnf("failed to release leader lock: %s", err.Error())
+		return
+	}
+	client, err := kubeutil.Client(constant.AdminKubeconfigConfigPath)
+	if err != nil {
+		log.Warnf("failed to release leader lock: %s", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderLockReleaseTimeout)
+	defer cancel()
+	released, err := leaderelection.ReleaseLock(ctx, client, lockType, namespace, name, hostname+"_")
+	if err != nil {
+		log.Warnf("failed to release leader lock, another controller takes over once it expires: %s", err.Error())
+		return
+	}
+	if released {
+		log.Info("released leader lock")
+	}
+}
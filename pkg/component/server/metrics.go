@@ -0,0 +1,149 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/logging"
+)
+
+// MetricsHealthInterval is how often the health of the components is checked for the metrics
+const MetricsHealthInterval = 15 * time.Second
+
+// Metrics serves the state of the k0s components and reconcilers, and the startup checkpoint durations,
+// for Prometheus. It observes the component manager so it has to be created with NewMetrics before the
+// components are initialized.
+type Metrics struct {
+	Address string
+
+	registry    *prometheus.Registry
+	up          *prometheus.GaugeVec
+	healthy     *prometheus.GaugeVec
+	checkpoints *prometheus.HistogramVec
+	server      *http.Server
+	log         *logrus.Entry
+}
+
+// NewMetrics creates the metrics endpoint listening on the given address
+func NewMetrics(address string) *Metrics {
+	m := &Metrics{
+		Address:  address,
+		registry: prometheus.NewRegistry(),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "k0s_component_up",
+			Help: "Whether the k0s component is running.",
+		}, []string{"component"}),
+		healthy: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "k0s_component_ready",
+			Help: "Whether the latest health check of the k0s component succeeded.",
+		}, []string{"component"}),
+		checkpoints: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "k0s_startup_checkpoint_seconds",
+			Help:    "Time from the k0s start until the startup checkpoint was reached.",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+		}, []string{"checkpoint"}),
+		log: logging.ForComponent("metrics"),
+	}
+	m.registry.MustRegister(m.up, m.healthy, m.checkpoints, reconcilerCollector{})
+	return m
+}
+
+// ComponentUp records whether the named component is running
+func (m *Metrics) ComponentUp(name string, up bool) {
+	m.up.WithLabelValues(name).Set(gaugeValue(up))
+}
+
+// ComponentHealthy records the outcome of the latest health check of the named component
+func (m *Metrics) ComponentHealthy(name string, healthy bool) {
+	m.healthy.WithLabelValues(name).Set(gaugeValue(healthy))
+}
+
+// Checkpoint records the time it took to reach a startup checkpoint
+func (m *Metrics) Checkpoint(name string, duration time.Duration) {
+	m.checkpoints.WithLabelValues(name).Observe(duration.Seconds())
+}
+
+// Init does nothing
+func (m *Metrics) Init() error {
+	return nil
+}
+
+// Run starts serving the metrics
+func (m *Metrics) Run() error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	m.server = &http.Server{
+		Addr:    m.Address,
+		Handler: mux,
+	}
+	go func() {
+		m.log.Infof("serving the k0s metrics on http://%s/metrics", m.Address)
+		err := m.server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			m.log.Errorf("metrics endpoint failed: %s", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops serving the metrics
+func (m *Metrics) Stop() error {
+	if m.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return m.server.Shutdown(ctx)
+}
+
+// Healthy for health-check interface
+func (m *Metrics) Healthy() error { return nil }
+
+func gaugeValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var (
+	reconcilerRunsDesc = prometheus.NewDesc("k0s_reconciler_runs_total",
+		"Number of reconcile runs of the k0s in-cluster reconciler.", []string{"reconciler"}, nil)
+	reconcilerErrorsDesc = prometheus.NewDesc("k0s_reconciler_errors_total",
+		"Number of failed reconcile runs of the k0s in-cluster reconciler.", []string{"reconciler"}, nil)
+)
+
+// reconcilerCollector exports the run counts kept in the reconciler statuses
+type reconcilerCollector struct{}
+
+func (reconcilerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- reconcilerRunsDesc
+	ch <- reconcilerErrorsDesc
+}
+
+func (reconcilerCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range ReconcilerStatuses() {
+		ch <- prometheus.MustNewConstMetric(reconcilerRunsDesc, prometheus.CounterValue, float64(s.Runs), s.Name)
+		ch <- prometheus.MustNewConstMetric(reconcilerErrorsDesc, prometheus.CounterValue, float64(s.Errors), s.Name)
+	}
+}
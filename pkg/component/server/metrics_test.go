@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	// the reconciler metrics are collected from the shared statuses, each run has to count from zero
+	resetReconcileStatuses()
+	defer resetReconcileStatuses()
+
+	m := NewMetrics("127.0.0.1:0")
+	m.ComponentUp("etcd", true)
+	m.ComponentHealthy("etcd", false)
+	m.Checkpoint("started-reconcilers", 3*time.Second)
+	reportReconcile("metrics-test", reconcileInterval, nil)
+	reportReconcile("metrics-test", reconcileInterval, errors.New("boom"))
+
+	families, err := m.registry.Gather()
+	require.NoError(t, err)
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			name := f.GetName()
+			for _, label := range metric.GetLabel() {
+				name += " " + label.GetValue()
+			}
+			switch {
+			case metric.Gauge != nil:
+				values[name] = metric.GetGauge().GetValue()
+			case metric.Counter != nil:
+				values[name] = metric.GetCounter().GetValue()
+			case metric.Histogram != nil:
+				values[name] = metric.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+
+	assert.Equal(t, float64(1), values["k0s_component_up etcd"])
+	assert.Equal(t, float64(0), values["k0s_component_ready etcd"])
+	assert.Equal(t, float64(3), values["k0s_startup_checkpoint_seconds started-reconcilers"])
+	assert.Equal(t, float64(2), values["k0s_reconciler_runs_total metrics-test"])
+	assert.Equal(t, float64(1), values["k0s_reconciler_errors_total metrics-test"])
+}
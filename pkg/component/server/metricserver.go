@@ -19,6 +19,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
@@ -139,6 +140,10 @@ spec:
       name: metrics-server
       labels:
         k8s-app: metrics-server
+{{- if .SafeToEvict }}
+      annotations:
+        cluster-autoscaler.kubernetes.io/safe-to-evict: "{{ .SafeToEvict }}"
+{{- end }}
     spec:
       serviceAccountName: metrics-server
       volumes:
@@ -153,7 +158,7 @@ spec:
         args:
           - --cert-dir=/tmp
           - --secure-port=4443
-          - --kubelet-preferred-address-types=InternalIP,ExternalIP,Hostname
+          - --kubelet-preferred-address-types={{ .KubeletPreferredAddressTypes }}
          # Until we have proper serving cert (signed by cluster CA & proper IP sans etc.) on kubelet, not much else we can do
           - --kubelet-insecure-tls
         ports:
@@ -227,7 +232,7 @@ func (m *MetricServer) Run() error {
 	}
 
 	go func() {
-		ticker := time.NewTicker(10 * time.Second)
+		ticker := time.NewTicker(reconcileInterval)
 		defer ticker.Stop()
 		for {
 			select {
@@ -235,14 +240,24 @@ func (m *MetricServer) Run() error {
 				tw := util.TemplateWriter{
 					Name:     "metricServer",
 					Template: metricServerTemplate,
-					Data:     struct{ Image string }{Image: m.clusterConfig.Images.MetricsServer.URI()},
-					Path:     filepath.Join(msDir, "metric_server.yaml"),
+					Data: struct {
+						Image                        string
+						KubeletPreferredAddressTypes string
+						SafeToEvict                  string
+					}{
+						Image:                        m.clusterConfig.Images.MetricsServer.URI(),
+						KubeletPreferredAddressTypes: strings.Join(m.clusterConfig.Spec.API.GetKubeletPreferredAddressTypes(), ","),
+						SafeToEvict:                  m.clusterConfig.Spec.SafeToEvict.MetricsServerValue(),
+					},
+					Path: filepath.Join(msDir, "metric_server.yaml"),
 				}
 				err := tw.Write()
 				if err != nil {
 					m.log.Errorf("error writing metric server manifests: %s. will retry", err.Error())
+					reportReconcile("metricServer", reconcileInterval, err)
 					continue
 				}
+				reportReconcile("metricServer", reconcileInterval, nil)
 			case <-m.tickerDone:
 				m.log.Info("metric server reconciler done")
 				return
@@ -261,3 +276,8 @@ func (m *MetricServer) Stop() error {
 
 // Health-check interface
 func (m *MetricServer) Healthy() error { return nil }
+
+// ReadinessDependencies makes the reconciler wait for the CNI, the pods would crash loop without pod networking
+func (m *MetricServer) ReadinessDependencies() []string {
+	return []string{"calico", "cilium", "kuberouter"}
+}
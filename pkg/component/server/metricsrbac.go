@@ -0,0 +1,106 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// MetricsRBAC implements the reconciler for the role allowing to scrape the metrics of the control plane components
+type MetricsRBAC struct {
+	clusterSpec *config.ClusterSpec
+}
+
+// NewMetricsRBAC creates new metrics RBAC reconciler
+func NewMetricsRBAC(clusterSpec *config.ClusterSpec) (*MetricsRBAC, error) {
+	return &MetricsRBAC{clusterSpec: clusterSpec}, nil
+}
+
+// Init does nothing
+func (m *MetricsRBAC) Init() error {
+	return nil
+}
+
+// Run writes the metrics reader role, bound to the configured readers. kube-scheduler and kube-controller-manager
+// authorize the scrapes against the API server, so the role is all the readers need.
+func (m *MetricsRBAC) Run() (err error) {
+	defer func() { reportReconcile("metricsRBAC", 0, err) }()
+	rbacDir := path.Join(constant.ManifestsDir, "metricsrbac")
+	err = os.MkdirAll(rbacDir, constant.ManifestsDirMode)
+	if err != nil {
+		return err
+	}
+	tw := util.TemplateWriter{
+		Name:     "metrics-rbac",
+		Template: metricsRBACTemplate,
+		Data: struct {
+			Readers []config.ServiceAccount
+		}{
+			Readers: m.clusterSpec.Metrics.ServiceAccounts(),
+		},
+		Path: filepath.Join(rbacDir, "metrics-rbac.yaml"),
+	}
+	err = tw.Write()
+	if err != nil {
+		return errors.Wrap(err, "error writing metrics-rbac manifests, will NOT retry")
+	}
+	return nil
+}
+
+// Stop does currently nothing
+func (m *MetricsRBAC) Stop() error {
+	return nil
+}
+
+const metricsRBACTemplate = `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: k0s:metrics-reader
+rules:
+- nonResourceURLs:
+  - /metrics
+  verbs:
+  - get
+{{- if .Readers }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: k0s:metrics-reader
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: k0s:metrics-reader
+subjects:
+{{- range .Readers }}
+- kind: ServiceAccount
+  namespace: {{ .Namespace }}
+  name: {{ .Name }}
+{{- end }}
+{{- end }}
+`
+
+// Health-check interface
+func (m *MetricsRBAC) Healthy() error { return nil }
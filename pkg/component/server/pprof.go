@@ -0,0 +1,123 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/pprof"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
+)
+
+// DefaultPprofAddress is the address the k0s pprof endpoint listens on if not configured otherwise
+const DefaultPprofAddress = "127.0.0.1:6060"
+
+// Pprof serves the runtime profiles of the k0s process itself. The endpoint requires TLS
+// client certificates signed by the cluster CA of the system:masters group, e.g. the one of the admin kubeconfig.
+type Pprof struct {
+	Address string
+
+	server *http.Server
+	log    *logrus.Entry
+}
+
+// Init does nothing
+func (p *Pprof) Init() error {
+	p.log = logging.ForComponent("pprof")
+	return nil
+}
+
+// Run starts serving the profiles
+func (p *Pprof) Run() error {
+	caCert, err := ioutil.ReadFile(filepath.Join(constant.CertRootDir, "ca.crt"))
+	if err != nil {
+		return errors.Wrap(err, "failed to read the CA certificate for pprof client authentication")
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caCert) {
+		return fmt.Errorf("failed to parse the CA certificate for pprof client authentication")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	p.server = &http.Server{
+		Addr:    p.Address,
+		Handler: requireMasters(mux),
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		},
+	}
+	go func() {
+		p.log.Warnf("serving the k0s pprof endpoint on https://%s/debug/pprof/", p.Address)
+		err := p.server.ListenAndServeTLS(filepath.Join(constant.CertRootDir, "server.crt"), filepath.Join(constant.CertRootDir, "server.key"))
+		if err != nil && err != http.ErrServerClosed {
+			p.log.Errorf("pprof endpoint failed: %s", err)
+		}
+	}()
+	return nil
+}
+
+// pprofGroup is the organization a client certificate needs to read the profiles, the nodes and the other
+// components have certificates signed by the same CA
+const pprofGroup = "system:masters"
+
+// requireMasters rejects the clients whose certificate is not of the system:masters group
+func requireMasters(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			http.Error(w, "client certificate required", http.StatusUnauthorized)
+			return
+		}
+		for _, org := range r.TLS.PeerCertificates[0].Subject.Organization {
+			if org == pprofGroup {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		http.Error(w, fmt.Sprintf("the client certificate is not of the %s group", pprofGroup), http.StatusForbidden)
+	})
+}
+
+// Stop stops serving the profiles
+func (p *Pprof) Stop() error {
+	if p.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return p.server.Shutdown(ctx)
+}
+
+// Healthy for health-check interface
+func (p *Pprof) Healthy() error { return nil }
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPprofRequireMasters(t *testing.T) {
+	handler := requireMasters(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	tests := []struct {
+		name   string
+		certs  []*x509.Certificate
+		status int
+	}{
+		{"no certificate", nil, http.StatusUnauthorized},
+		{"kubelet", []*x509.Certificate{{Subject: pkix.Name{CommonName: "system:node:worker-1", Organization: []string{"system:nodes"}}}}, http.StatusForbidden},
+		{"admin", []*x509.Certificate{{Subject: pkix.Name{CommonName: "kubernetes-admin", Organization: []string{"system:masters"}}}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+			req.TLS = &tls.ConnectionState{PeerCertificates: tt.certs}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
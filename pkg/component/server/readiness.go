@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ReconcilerReadinessTimeout is how long a reconciler waits for its readiness dependencies before starting anyway
+const ReconcilerReadinessTimeout = 5 * time.Minute
+
+// reconciled returns an error unless the named reconciler succeeded at least once
+func reconciled(name string) error {
+	reconcileStatusMu.Lock()
+	defer reconcileStatusMu.Unlock()
+
+	if status, ok := reconcileStatuses[name]; ok && !status.LastSuccess.IsZero() {
+		return nil
+	}
+	return fmt.Errorf("%s manifests not written yet", name)
+}
+
+// anyNodeReady returns an error unless at least one node is ready, i.e. the CNI works on it so pods can be scheduled
+func anyNodeReady(client kubernetes.Interface) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes.Items {
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("none of the %d nodes is ready", len(nodes.Items))
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// reconcileInterval is how often the periodic reconcilers run
+const reconcileInterval = 10 * time.Second
+
+// ReconcilerStatus holds the outcome of the latest reconcile runs of a reconciler
+type ReconcilerStatus struct {
+	Name string
+	// Interval is how often the reconciler runs, zero for reconcilers running only once on startup
+	Interval      time.Duration
+	LastSuccess   time.Time
+	LastError     string
+	LastErrorTime time.Time
+	// Runs and Errors count the reconcile runs and the failed ones since k0s started
+	Runs   uint64
+	Errors uint64
+}
+
+// Stale returns true if the reconciler did not succeed within a few of its intervals
+func (s ReconcilerStatus) Stale(now time.Time) bool {
+	if s.LastSuccess.IsZero() {
+		return true
+	}
+	return s.Interval > 0 && now.Sub(s.LastSuccess) > 3*s.Interval
+}
+
+var (
+	reconcileStatusMu sync.Mutex
+	reconcileStatuses = map[string]*ReconcilerStatus{}
+)
+
+// reportReconcile records the outcome of a single reconcile run
+func reportReconcile(name string, interval time.Duration, err error) {
+	reconcileStatusMu.Lock()
+	defer reconcileStatusMu.Unlock()
+
+	status, ok := reconcileStatuses[name]
+	if !ok {
+		status = &ReconcilerStatus{Name: name}
+		reconcileStatuses[name] = status
+	}
+	status.Interval = interval
+	status.Runs++
+	if err != nil {
+		status.Errors++
+		status.LastError = err.Error()
+		status.LastErrorTime = time.Now()
+	} else {
+		status.LastSuccess = time.Now()
+	}
+}
+
+// ReconcilerStatusOf returns the status of the named reconciler, false if it did not run yet
+func ReconcilerStatusOf(name string) (ReconcilerStatus, bool) {
+	reconcileStatusMu.Lock()
+	defer reconcileStatusMu.Unlock()
+
+	status, ok := reconcileStatuses[name]
+	if !ok {
+		return ReconcilerStatus{}, false
+	}
+	return *status, true
+}
+
+// ReconcilerStatuses returns the status of all reconcilers which have run at least once, sorted by name
+func ReconcilerStatuses() []ReconcilerStatus {
+	reconcileStatusMu.Lock()
+	defer reconcileStatusMu.Unlock()
+
+	statuses := make([]ReconcilerStatus, 0, len(reconcileStatuses))
+	for _, status := range reconcileStatuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcilerStatusStale(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		status ReconcilerStatus
+		stale  bool
+	}{
+		{"never succeeded", ReconcilerStatus{Interval: reconcileInterval}, true},
+		{"recent success", ReconcilerStatus{Interval: reconcileInterval, LastSuccess: now.Add(-reconcileInterval)}, false},
+		{"old success", ReconcilerStatus{Interval: reconcileInterval, LastSuccess: now.Add(-4 * reconcileInterval)}, true},
+		{"one-shot success", ReconcilerStatus{LastSuccess: now.Add(-time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.stale, tt.status.Stale(now))
+		})
+	}
+}
+
+// resetReconcileStatuses forgets the reconcile runs recorded by other tests, or by other runs of the same test
+func resetReconcileStatuses() {
+	reconcileStatusMu.Lock()
+	defer reconcileStatusMu.Unlock()
+	reconcileStatuses = map[string]*ReconcilerStatus{}
+}
+
+func TestReportReconcile(t *testing.T) {
+	resetReconcileStatuses()
+	defer resetReconcileStatuses()
+
+	reportReconcile("test-reconciler", reconcileInterval, nil)
+	reportReconcile("test-reconciler", reconcileInterval, fmt.Errorf("boom"))
+
+	var found bool
+	for _, s := range ReconcilerStatuses() {
+		if s.Name != "test-reconciler" {
+			continue
+		}
+		found = true
+		assert.False(t, s.LastSuccess.IsZero())
+		assert.Equal(t, "boom", s.LastError)
+		assert.False(t, s.LastErrorTime.Before(s.LastSuccess))
+		assert.Equal(t, uint64(2), s.Runs)
+		assert.Equal(t, uint64(1), s.Errors)
+	}
+	assert.True(t, found)
+
+	status, ok := ReconcilerStatusOf("test-reconciler")
+	assert.True(t, ok)
+	assert.Equal(t, "boom", status.LastError)
+	_, ok = ReconcilerStatusOf("nonexisting")
+	assert.False(t, ok)
+}
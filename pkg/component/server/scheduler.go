@@ -17,11 +17,13 @@ package server
 
 import (
 	"fmt"
-	"path/filepath"
+	"time"
 
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
@@ -31,17 +33,23 @@ import (
 // Scheduler implement the component interface to run kube scheduler
 type Scheduler struct {
 	ClusterConfig *config.ClusterConfig
-	supervisor    supervisor.Supervisor
-	uid           int
-	gid           int
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
+	supervisor supervisor.Supervisor
+	args       map[string]string
+	uid        int
+	gid        int
+	log        *logrus.Entry
 }
 
 // Init extracts the needed binaries
 func (a *Scheduler) Init() error {
+	a.log = logging.ForComponent("kube-scheduler")
 	var err error
 	a.uid, err = util.GetUID(constant.SchedulerUser)
 	if err != nil {
-		logrus.Warning(errors.Wrap(err, "Running kube-scheduler as root"))
+		a.log.Warning(errors.Wrap(err, "Running kube-scheduler as root"))
 	}
 	a.gid, _ = util.GetGID(constant.Group)
 
@@ -50,8 +58,8 @@ func (a *Scheduler) Init() error {
 
 // Run runs kube scheduler
 func (a *Scheduler) Run() error {
-	logrus.Info("Starting kube-scheduler")
-	schedulerAuthConf := filepath.Join(constant.CertRootDir, "scheduler.conf")
+	a.log.Info("Starting kube-scheduler")
+	schedulerAuthConf := constant.SchedulerKubeconfigPath
 	args := map[string]string{
 		"authentication-kubeconfig": schedulerAuthConf,
 		"authorization-kubeconfig":  schedulerAuthConf,
@@ -60,6 +68,12 @@ func (a *Scheduler) Run() error {
 		"leader-elect":              "true",
 		"profiling":                 "false",
 	}
+	if a.ClusterConfig.Spec.Scheduler.BindAddress != "" {
+		args["bind-address"] = a.ClusterConfig.Spec.Scheduler.BindAddress
+	}
+	if v := a.ClusterConfig.Spec.LogVerbosity; v != nil && v.Scheduler > 0 {
+		args["v"] = config.VerbosityArg(v.Scheduler)
+	}
 	for name, value := range a.ClusterConfig.Spec.Scheduler.ExtraArgs {
 		if args[name] != "" && name != "profiling" {
 			return fmt.Errorf("cannot override kube-scheduler flag: %s", name)
@@ -70,12 +84,14 @@ func (a *Scheduler) Run() error {
 	for name, value := range args {
 		schedulerArgs = append(schedulerArgs, fmt.Sprintf("--%s=%s", name, value))
 	}
+	a.args = args
 	a.supervisor = supervisor.Supervisor{
-		Name:    "kube-scheduler",
-		BinPath: assets.BinPath("kube-scheduler"),
-		Args:    schedulerArgs,
-		UID:     a.uid,
-		GID:     a.gid,
+		Name:        "kube-scheduler",
+		BinPath:     assets.BinPath("kube-scheduler"),
+		Args:        schedulerArgs,
+		UID:         a.uid,
+		GID:         a.gid,
+		TimeoutStop: a.StopTimeout,
 	}
 	// TODO We need to dump the config file suited for k0s use
 
@@ -86,8 +102,18 @@ func (a *Scheduler) Run() error {
 
 // Stop stops Scheduler
 func (a *Scheduler) Stop() error {
-	return a.supervisor.Stop()
+	if err := a.supervisor.Stop(); err != nil {
+		return err
+	}
+	releaseLeaderLock("kube-scheduler", a.args)
+	return nil
 }
 
+// Version returns the version of the launched kube-scheduler binary
+func (a *Scheduler) Version() string { return a.supervisor.Version() }
+
 // Health-check interface
 func (a *Scheduler) Healthy() error { return nil }
+
+// DependsOn for the restartable interface
+func (a *Scheduler) DependsOn() []component.Component { return nil }
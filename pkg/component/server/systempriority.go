@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// SystemPriority implements the reconciler allowing the k0s system components to use the critical priority classes.
+/* The built-in system-cluster-critical and system-node-critical priority classes keep the addons and the
+worker components from being evicted or preempted under resource pressure. Clusters which limit the
+consumption of these classes with the LimitedResources admission config need a ResourceQuota covering
+them in kube-system, which this reconciler installs.
+*/
+type SystemPriority struct {
+	clusterSpec *config.ClusterSpec
+}
+
+// NewSystemPriority creates new system priority reconciler
+func NewSystemPriority(clusterSpec *config.ClusterSpec) (*SystemPriority, error) {
+	return &SystemPriority{
+		clusterSpec: clusterSpec,
+	}, nil
+}
+
+// Init does nothing
+func (s *SystemPriority) Init() error {
+	return nil
+}
+
+// Run writes the system priority manifests, or removes them if disabled
+func (s *SystemPriority) Run() (err error) {
+	defer func() { reportReconcile("systemPriority", 0, err) }()
+	priorityDir := path.Join(constant.ManifestsDir, "systempriority")
+	if !s.clusterSpec.SystemPriority.IsEnabled() {
+		return os.RemoveAll(priorityDir)
+	}
+
+	err = os.MkdirAll(priorityDir, constant.ManifestsDirMode)
+	if err != nil {
+		return err
+	}
+	tw := util.TemplateWriter{
+		Name:     "system-priority",
+		Template: systemPriorityTemplate,
+		Data:     struct{}{},
+		Path:     filepath.Join(priorityDir, "system-priority.yaml"),
+	}
+	err = tw.Write()
+	if err != nil {
+		return errors.Wrap(err, "failed to write the system priority manifests, restart k0s to retry")
+	}
+	return nil
+}
+
+// Stop does currently nothing
+func (s *SystemPriority) Stop() error {
+	return nil
+}
+
+// Healthy is the health-check interface
+func (s *SystemPriority) Healthy() error { return nil }
+
+const systemPriorityTemplate = `
+apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: k0s-system-critical-pods
+  namespace: kube-system
+spec:
+  hard:
+    pods: "1000"
+  scopeSelector:
+    matchExpressions:
+    - operator: In
+      scopeName: PriorityClass
+      values:
+      - system-cluster-critical
+      - system-node-critical
+`
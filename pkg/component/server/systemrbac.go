@@ -42,9 +42,10 @@ func (s *SystemRBAC) Init() error {
 }
 
 // Run reconciles the k0s related system RBAC rules
-func (s *SystemRBAC) Run() error {
+func (s *SystemRBAC) Run() (err error) {
+	defer func() { reportReconcile("systemRBAC", 0, err) }()
 	rbacDir := path.Join(constant.ManifestsDir, "bootstraprbac")
-	err := os.MkdirAll(rbacDir, constant.ManifestsDirMode)
+	err = os.MkdirAll(rbacDir, constant.ManifestsDirMode)
 	if err != nil {
 		return err
 	}
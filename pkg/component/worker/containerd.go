@@ -16,23 +16,80 @@ limitations under the License.
 package worker
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"path"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
+	"github.com/k0sproject/k0s/pkg/util"
 )
 
 // ContainerD implement the component interface to manage containerd as k0s component
 type ContainerD struct {
+	// MaxConcurrentDownloads limits the number of layers pulled in parallel per image
+	MaxConcurrentDownloads int
+	// ImagePullTimeout is the time an image pull may go without progress before it is cancelled
+	ImagePullTimeout time.Duration
+	// CgroupParent is the cgroup path containerd is placed under, matching the kubelet's, the default if empty
+	CgroupParent string
+	// Config is the path of a TOML drop-in merged into the generated config, none if empty
+	Config string
+
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
 	supervisor supervisor.Supervisor
+	exits      chan error
+	log        *logrus.Entry
+}
+
+const containerdConfigTemplate = `# Generated by k0s, do not edit. Use {{ .UserConfigPath }} for custom settings.
+version = 2
+{{- if .ImportUserConfig }}
+imports = ["{{ .UserConfigPath }}"]
+{{- end }}
+{{- if .CgroupPath }}
+
+[cgroup]
+  path = "{{ .CgroupPath }}"
+{{- end }}
+
+[plugins."io.containerd.grpc.v1.cri"]
+{{- if .MaxConcurrentDownloads }}
+  max_concurrent_downloads = {{ .MaxConcurrentDownloads }}
+{{- end }}
+{{- if .ImagePullTimeout }}
+  image_pull_progress_timeout = "{{ .ImagePullTimeout }}"
+{{- end }}
+`
+
+// managedContainerdKeys are the settings k0s needs to control, a drop-in config may not change them
+var managedContainerdKeys = []string{"version", "root", "state", "imports", "grpc.address"}
+
+type containerdConfig struct {
+	UserConfigPath         string
+	ImportUserConfig       bool
+	MaxConcurrentDownloads int
+	ImagePullTimeout       string
+	CgroupPath             string
 }
 
 // Init extracts the needed binaries
 func (c *ContainerD) Init() error {
+	c.log = logging.ForComponent("containerd")
 	for _, bin := range []string{"containerd", "containerd-shim", "containerd-shim-runc-v1", "containerd-shim-runc-v2", "runc"} {
 		// unfortunately, this cannot be parallelized – it will result in a fork/exec error
 		err := assets.Stage(constant.BinDir, bin, constant.BinDirMode, constant.Group)
@@ -41,12 +98,133 @@ func (c *ContainerD) Init() error {
 		}
 	}
 
+	return c.writeConfig()
+}
+
+// writeConfig renders the k0s managed containerd config. The user provided config, if any, is imported so that
+// its settings take precedence over the generated ones.
+func (c *ContainerD) writeConfig() error {
+	if c.MaxConcurrentDownloads < 0 {
+		return fmt.Errorf("invalid image pull max concurrent downloads %d, must be positive", c.MaxConcurrentDownloads)
+	}
+	if c.ImagePullTimeout < 0 {
+		return fmt.Errorf("invalid image pull timeout %s, must be positive", c.ImagePullTimeout)
+	}
+
+	config := containerdConfig{
+		UserConfigPath:         constant.ContainerdConfigPath,
+		ImportUserConfig:       util.FileExists(constant.ContainerdConfigPath),
+		MaxConcurrentDownloads: c.MaxConcurrentDownloads,
+	}
+	if c.ImagePullTimeout > 0 {
+		config.ImagePullTimeout = c.ImagePullTimeout.String()
+	}
+	if c.CgroupParent != "" {
+		// the same cgroup the kubelet expects the runtime in
+		config.CgroupPath = path.Join(util.SystemCgroupPath(c.CgroupParent), "containerd")
+	}
+
+	tw := util.TemplateWriter{
+		Name:     "containerd-config",
+		Template: containerdConfigTemplate,
+		Data:     config,
+		Path:     constant.ContainerdGeneratedConfigPath,
+	}
+	if c.Config == "" {
+		if err := tw.Write(); err != nil {
+			return errors.Wrap(err, "failed to write containerd config")
+		}
+		return nil
+	}
+
+	var generated bytes.Buffer
+	if err := tw.WriteToBuffer(&generated); err != nil {
+		return errors.Wrap(err, "failed to generate containerd config")
+	}
+	dropIn, err := ioutil.ReadFile(c.Config)
+	if err != nil {
+		return errors.Wrap(err, "failed to read containerd drop-in config")
+	}
+	managed := managedContainerdKeys
+	if config.CgroupPath != "" {
+		managed = append(managed, "cgroup.path")
+	}
+	merged, err := mergeContainerdConfig(generated.String(), string(dropIn), managed)
+	if err != nil {
+		return errors.Wrapf(err, "failed to merge containerd drop-in config %s", c.Config)
+	}
+	if err := ioutil.WriteFile(constant.ContainerdGeneratedConfigPath, merged, constant.CertMode); err != nil {
+		return errors.Wrap(err, "failed to write containerd config")
+	}
+	c.log.Infof("merged %s into the containerd config", c.Config)
+
 	return nil
 }
 
+// mergeContainerdConfig merges the drop-in config into the generated one, the drop-in taking precedence. Tables
+// are merged key by key, so e.g. a registry mirror can be added without repeating the generated CRI settings.
+// The drop-in may not change any of the managed keys, given as dotted paths.
+func mergeContainerdConfig(generated, dropIn string, managed []string) ([]byte, error) {
+	base := map[string]interface{}{}
+	if _, err := toml.Decode(generated, &base); err != nil {
+		return nil, err
+	}
+	override := map[string]interface{}{}
+	if _, err := toml.Decode(dropIn, &override); err != nil {
+		return nil, err
+	}
+	for _, key := range managed {
+		path := strings.Split(key, ".")
+		value, set := lookupTOML(override, path)
+		if !set {
+			continue
+		}
+		if generatedValue, _ := lookupTOML(base, path); !reflect.DeepEqual(value, generatedValue) {
+			return nil, fmt.Errorf("%s is managed by k0s and can't be changed", key)
+		}
+	}
+	mergeTables(base, override)
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("# Generated by k0s, do not edit. Use %s for custom settings.\n", constant.ContainerdConfigPath))
+	if err := toml.NewEncoder(&buf).Encode(base); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lookupTOML returns the value at the path of nested tables, and whether it is set
+func lookupTOML(table map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := table[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupTOML(nested, path[1:])
+}
+
+// mergeTables sets the values of override in base, recursing into the tables present in both
+func mergeTables(base, override map[string]interface{}) {
+	for key, value := range override {
+		if table, ok := value.(map[string]interface{}); ok {
+			if baseTable, ok := base[key].(map[string]interface{}); ok {
+				mergeTables(baseTable, table)
+				continue
+			}
+		}
+		base[key] = value
+	}
+}
+
 // Run runs containerD
 func (c *ContainerD) Run() error {
-	logrus.Info("Starting containerD")
+	c.log.Info("Starting containerD")
+	if c.exits == nil {
+		c.exits = make(chan error, 1)
+	}
 	c.supervisor = supervisor.Supervisor{
 		Name:    "containerd",
 		BinPath: assets.BinPath("containerd"),
@@ -54,10 +232,11 @@ func (c *ContainerD) Run() error {
 			fmt.Sprintf("--root=%s", filepath.Join(constant.DataDir, "containerd")),
 			fmt.Sprintf("--state=%s", filepath.Join(constant.RunDir, "containerd")),
 			fmt.Sprintf("--address=%s", filepath.Join(constant.RunDir, "containerd.sock")),
-			"--config=/etc/k0s/containerd.toml",
+			fmt.Sprintf("--config=%s", constant.ContainerdGeneratedConfigPath),
 		},
+		Exits:       c.exits,
+		TimeoutStop: c.StopTimeout,
 	}
-	// TODO We need to dump the config file suited for k0s use
 
 	c.supervisor.Supervise()
 
@@ -69,5 +248,14 @@ func (c *ContainerD) Stop() error {
 	return c.supervisor.Stop()
 }
 
+// Exited returns the channel receiving why containerd exited, the component manager restarts it
+func (c *ContainerD) Exited() <-chan error { return c.exits }
+
+// Version returns the version of the launched containerd binary
+func (c *ContainerD) Version() string { return c.supervisor.Version() }
+
 // Health-check interface
 func (c *ContainerD) Healthy() error { return nil }
+
+// DependsOn for the restartable interface
+func (c *ContainerD) DependsOn() []component.Component { return nil }
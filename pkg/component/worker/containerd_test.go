@@ -0,0 +1,84 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package worker
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGeneratedContainerdConfig = `version = 2
+
+[plugins."io.containerd.grpc.v1.cri"]
+  max_concurrent_downloads = 3
+`
+
+const testContainerdDropIn = `version = 2
+
+[plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+  endpoint = ["https://registry.example.com"]
+
+[plugins."io.containerd.grpc.v1.cri".registry.configs."registry.example.com".tls]
+  ca_file = "/etc/k0s/registry-ca.crt"
+`
+
+func TestMergeContainerdConfig(t *testing.T) {
+	merged, err := mergeContainerdConfig(testGeneratedContainerdConfig, testContainerdDropIn, managedContainerdKeys)
+	require.NoError(t, err)
+
+	var config struct {
+		Version int `toml:"version"`
+		Plugins struct {
+			CRI struct {
+				MaxConcurrentDownloads int `toml:"max_concurrent_downloads"`
+				Registry               struct {
+					Mirrors map[string]struct {
+						Endpoint []string `toml:"endpoint"`
+					} `toml:"mirrors"`
+					Configs map[string]struct {
+						TLS struct {
+							CAFile string `toml:"ca_file"`
+						} `toml:"tls"`
+					} `toml:"configs"`
+				} `toml:"registry"`
+			} `toml:"io.containerd.grpc.v1.cri"`
+		} `toml:"plugins"`
+	}
+	_, err = toml.Decode(string(merged), &config)
+	require.NoError(t, err)
+	assert.Equal(t, 2, config.Version)
+	assert.Equal(t, 3, config.Plugins.CRI.MaxConcurrentDownloads, "the generated settings are kept")
+	assert.Equal(t, []string{"https://registry.example.com"}, config.Plugins.CRI.Registry.Mirrors["docker.io"].Endpoint)
+	assert.Equal(t, "/etc/k0s/registry-ca.crt", config.Plugins.CRI.Registry.Configs["registry.example.com"].TLS.CAFile)
+
+	merged, err = mergeContainerdConfig(testGeneratedContainerdConfig, "[plugins.\"io.containerd.grpc.v1.cri\"]\n  max_concurrent_downloads = 10\n", managedContainerdKeys)
+	require.NoError(t, err)
+	assert.Contains(t, string(merged), "max_concurrent_downloads = 10")
+}
+
+func TestMergeContainerdConfigManagedKeys(t *testing.T) {
+	_, err := mergeContainerdConfig(testGeneratedContainerdConfig, "root = \"/data/containerd\"\n", managedContainerdKeys)
+	assert.EqualError(t, err, "root is managed by k0s and can't be changed")
+
+	_, err = mergeContainerdConfig(testGeneratedContainerdConfig, "[grpc]\n  address = \"/run/containerd.sock\"\n", managedContainerdKeys)
+	assert.EqualError(t, err, "grpc.address is managed by k0s and can't be changed")
+
+	_, err = mergeContainerdConfig(testGeneratedContainerdConfig, "version = 1\n", managedContainerdKeys)
+	assert.EqualError(t, err, "version is managed by k0s and can't be changed")
+}
@@ -20,3 +20,6 @@ package worker
 
 // KernelSetup comment
 func KernelSetup() {}
+
+// KernelCheck comment
+func KernelCheck() {}
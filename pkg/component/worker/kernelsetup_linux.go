@@ -19,6 +19,7 @@ limitations under the License.
 package worker
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os/exec"
 	"path"
@@ -58,6 +59,16 @@ func enableSysCtl(entry string) {
 	}
 }
 
+// sysctls are the kernel settings the worker needs enabled
+var sysctls = []string{
+	"net/ipv4/conf/all/forwarding",
+	"net/ipv4/conf/default/forwarding",
+	"net/ipv6/conf/all/forwarding",
+	"net/ipv6/conf/default/forwarding",
+	"net/bridge/bridge-nf-call-iptables",
+	"net/bridge/bridge-nf-call-ip6tables",
+}
+
 // KernelSetup sets the needed kernel tuning params. If setting the options fails, it only logs
 // a warning but does not prevent the starting of worker
 func KernelSetup() {
@@ -70,10 +81,30 @@ func KernelSetup() {
 	if !util.FileExists("/proc/sys/net/bridge/bridge-nf-call-iptables") {
 		modprobe("br_netfilter")
 	}
-	enableSysCtl("net/ipv4/conf/all/forwarding")
-	enableSysCtl("net/ipv4/conf/default/forwarding")
-	enableSysCtl("net/ipv6/conf/all/forwarding")
-	enableSysCtl("net/ipv6/conf/default/forwarding")
-	enableSysCtl("net/bridge/bridge-nf-call-iptables")
-	enableSysCtl("net/bridge/bridge-nf-call-ip6tables")
+	for _, entry := range sysctls {
+		enableSysCtl(entry)
+	}
+}
+
+// KernelCheck verifies the kernel prerequisites KernelSetup would set up, without changing anything.
+// Prerequisites which are not met are logged as assumed to be provided by the host.
+func KernelCheck() {
+	assumed := func(prerequisite string) {
+		logrus.Warnf("kernel setup skipped: %s not detected, assuming the host provides it", prerequisite)
+	}
+	if !hasFilesystem("overlay") {
+		assumed("overlay filesystem")
+	}
+	if !util.FileExists("/proc/net/nf_conntrack") {
+		assumed("nf_conntrack kernel module")
+	}
+	if !util.FileExists("/proc/sys/net/bridge/bridge-nf-call-iptables") {
+		assumed("br_netfilter kernel module")
+	}
+	for _, entry := range sysctls {
+		data, err := ioutil.ReadFile(path.Join("/proc", "sys", entry))
+		if err != nil || strings.TrimSpace(string(data)) != "1" {
+			assumed(fmt.Sprintf("enabled sysctl %s", strings.ReplaceAll(entry, "/", ".")))
+		}
+	}
 }
@@ -18,13 +18,19 @@ package worker
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/avast/retry-go"
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/util"
 	"github.com/pkg/errors"
@@ -36,8 +42,19 @@ type Kubelet struct {
 	KubeletConfigClient *KubeletConfigClient
 	Profile             string
 	CRISocket           string
-	supervisor          supervisor.Supervisor
-	dataDir             string
+	// LogVerbosity is the --v level of the kubelet, the kubelet default if zero
+	LogVerbosity int
+	// CgroupParent is the cgroup path the kubelet, containerd and the pods are placed under, the defaults if empty
+	CgroupParent string
+	// Taints are registered with the node, in the key=value:effect format
+	Taints []string
+	// StopTimeout is the grace period of the process between SIGTERM and SIGKILL, supervisor.DefaultTimeoutStop if zero
+	StopTimeout time.Duration
+
+	supervisor supervisor.Supervisor
+	exits      chan error
+	dataDir    string
+	log        *logrus.Entry
 }
 
 // KubeletConfig defines the kubelet related config options
@@ -48,6 +65,7 @@ type KubeletConfig struct {
 
 // Init extracts the needed binaries
 func (k *Kubelet) Init() error {
+	k.log = logging.ForComponent("kubelet")
 	err := assets.Stage(constant.BinDir, "kubelet", constant.BinDirMode, constant.Group)
 	if err != nil {
 		return err
@@ -69,7 +87,7 @@ func (k *Kubelet) Init() error {
 
 // Run runs kubelet
 func (k *Kubelet) Run() error {
-	logrus.Info("Starting kubelet")
+	k.log.Info("Starting kubelet")
 	kubeletConfigPath := filepath.Join(constant.DataDir, "kubelet-config.yaml")
 	args := []string{
 		fmt.Sprintf("--root-dir=%s", k.dataDir),
@@ -78,9 +96,32 @@ func (k *Kubelet) Run() error {
 		fmt.Sprintf("--config=%s", kubeletConfigPath),
 		fmt.Sprintf("--bootstrap-kubeconfig=%s", constant.KubeletBootstrapConfigPath),
 		fmt.Sprintf("--kubeconfig=%s", constant.KubeletAuthConfigPath),
-		"--kube-reserved-cgroup=system.slice",
-		"--runtime-cgroups=/system.slice/containerd.service",
-		"--kubelet-cgroups=/system.slice/containerd.service",
+		// the renewal CSRs are approved by the controller manager, see the systemRBAC reconciler
+		"--rotate-certificates",
+	}
+	if k.CgroupParent != "" {
+		// the pods go below the parent, the kubelet and containerd into its system cgroup reserved for them
+		systemCgroup := util.SystemCgroupPath(k.CgroupParent)
+		args = append(args,
+			fmt.Sprintf("--cgroup-root=%s", k.CgroupParent),
+			fmt.Sprintf("--kube-reserved-cgroup=%s", systemCgroup),
+			fmt.Sprintf("--runtime-cgroups=%s", path.Join(systemCgroup, "containerd")),
+			fmt.Sprintf("--kubelet-cgroups=%s", path.Join(systemCgroup, "kubelet")),
+		)
+	} else {
+		args = append(args,
+			"--kube-reserved-cgroup=system.slice",
+			"--runtime-cgroups=/system.slice/containerd.service",
+			"--kubelet-cgroups=/system.slice/containerd.service",
+		)
+	}
+
+	if len(k.Taints) > 0 {
+		args = append(args, fmt.Sprintf("--register-with-taints=%s", strings.Join(k.Taints, ",")))
+	}
+
+	if k.LogVerbosity > 0 {
+		args = append(args, fmt.Sprintf("--v=%d", k.LogVerbosity))
 	}
 
 	if k.CRISocket != "" {
@@ -102,14 +143,9 @@ func (k *Kubelet) Run() error {
 		args = append(args, fmt.Sprintf("--container-runtime-endpoint=unix://%s", path.Join(constant.RunDir, "containerd.sock")))
 	}
 
-	k.supervisor = supervisor.Supervisor{
-		Name:    "kubelet",
-		BinPath: assets.BinPath("kubelet"),
-		Args:    args,
-	}
-
+	var extraArgs map[string]string
 	err := retry.Do(func() error {
-		kubeletconfig, err := k.KubeletConfigClient.Get(k.Profile)
+		kubeletconfig, profileArgs, err := k.KubeletConfigClient.GetWithExtraArgs(k.Profile)
 		if err != nil {
 			return err
 		}
@@ -118,12 +154,28 @@ func (k *Kubelet) Run() error {
 		if err != nil {
 			return errors.Wrap(err, "failed to write kubelet config to disk")
 		}
+		extraArgs = profileArgs
 
 		return nil
 	})
 	if err != nil {
 		return err
 	}
+	args, err = appendExtraArgs(args, extraArgs)
+	if err != nil {
+		return errors.Wrapf(err, "invalid extraArgs in worker profile %s", k.Profile)
+	}
+
+	if k.exits == nil {
+		k.exits = make(chan error, 1)
+	}
+	k.supervisor = supervisor.Supervisor{
+		Name:        "kubelet",
+		BinPath:     assets.BinPath("kubelet"),
+		Args:        args,
+		Exits:       k.exits,
+		TimeoutStop: k.StopTimeout,
+	}
 
 	k.supervisor.Supervise()
 
@@ -135,9 +187,40 @@ func (k *Kubelet) Stop() error {
 	return k.supervisor.Stop()
 }
 
+// Exited returns the channel receiving why kubelet exited, the component manager restarts it
+func (k *Kubelet) Exited() <-chan error { return k.exits }
+
+// Version returns the version of the launched kubelet binary
+func (k *Kubelet) Version() string { return k.supervisor.Version() }
+
 // Health-check interface
 func (k *Kubelet) Healthy() error { return nil }
 
+// DependsOn for the restartable interface
+func (k *Kubelet) DependsOn() []component.Component { return nil }
+
+// appendExtraArgs appends the extra flags of the worker profile, sorted by name, to the flags set by k0s.
+// The flags set by k0s can't be overridden, as k0s relies on them.
+func appendExtraArgs(args []string, extraArgs map[string]string) ([]string, error) {
+	managed := map[string]bool{"cluster-dns": true, "cluster-domain": true}
+	for _, arg := range args {
+		managed[strings.SplitN(strings.TrimPrefix(arg, "--"), "=", 2)[0]] = true
+	}
+	names := make([]string, 0, len(extraArgs))
+	for name := range extraArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := strings.TrimLeft(name, "-")
+		if managed[flag] {
+			return nil, fmt.Errorf("cannot override kubelet flag: %s", flag)
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", flag, extraArgs[name]))
+	}
+	return args, nil
+}
+
 func splitRuntimeConfig(rtConfig string) (string, string, error) {
 	runtimeConfig := strings.SplitN(rtConfig, ":", 2)
 	if len(runtimeConfig) != 2 {
@@ -151,3 +234,30 @@ func splitRuntimeConfig(rtConfig string) (string, string, error) {
 
 	return runtimeType, runtimeSocket, nil
 }
+
+// CheckCRISocket verifies the socket of an external CRI runtime, given as <type>:<socket>, accepts connections
+func CheckCRISocket(criSocket string) error {
+	_, rtSock, err := splitRuntimeConfig(criSocket)
+	if err != nil {
+		return err
+	}
+	sockURL, err := url.Parse(rtSock)
+	if err != nil {
+		return errors.Wrapf(err, "invalid CRI socket %s", rtSock)
+	}
+
+	address := sockURL.Host
+	switch sockURL.Scheme {
+	case "unix":
+		address = sockURL.Path
+	case "tcp":
+	default:
+		return fmt.Errorf("unsupported CRI socket %s, must be either a unix:// or tcp:// address", rtSock)
+	}
+
+	conn, err := net.DialTimeout(sockURL.Scheme, address, 5*time.Second)
+	if err != nil {
+		return errors.Wrapf(err, "CRI runtime is not reachable on %s", rtSock)
+	}
+	return conn.Close()
+}
@@ -65,3 +65,25 @@ func TestCRISocketParsing(t *testing.T) {
 	}
 
 }
+
+func TestAppendExtraArgs(t *testing.T) {
+	args := []string{"--config=/var/lib/k0s/kubelet-config.yaml", "--rotate-certificates"}
+
+	extended, err := appendExtraArgs(args, map[string]string{
+		"system-reserved": "cpu=500m,memory=1Gi",
+		"--eviction-hard": "memory.available<500Mi",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"--config=/var/lib/k0s/kubelet-config.yaml",
+		"--rotate-certificates",
+		"--eviction-hard=memory.available<500Mi",
+		"--system-reserved=cpu=500m,memory=1Gi",
+	}, extended)
+
+	_, err = appendExtraArgs(args, map[string]string{"rotate-certificates": "false"})
+	require.EqualError(t, err, "cannot override kubelet flag: rotate-certificates")
+
+	_, err = appendExtraArgs(args, map[string]string{"cluster-dns": "8.8.8.8"})
+	require.EqualError(t, err, "cannot override kubelet flag: cluster-dns")
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/k0sproject/k0s/pkg/constant"
 	k8sutil "github.com/k0sproject/k0s/pkg/kubernetes"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -45,14 +46,24 @@ func NewKubeletConfigClient(kubeconfigPath string) (*KubeletConfigClient, error)
 
 // Get reads the config from kube api
 func (k *KubeletConfigClient) Get(profile string) (string, error) {
+	config, _, err := k.GetWithExtraArgs(profile)
+	return config, err
+}
+
+// GetWithExtraArgs reads the config and the additional kubelet flags of the profile from kube api
+func (k *KubeletConfigClient) GetWithExtraArgs(profile string) (string, map[string]string, error) {
 	cmName := fmt.Sprintf("kubelet-config-%s-%s", profile, constant.KubernetesMajorMinorVersion)
 	cm, err := k.kubeClient.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), cmName, v1.GetOptions{})
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get kubelet config from API")
+		return "", nil, errors.Wrap(err, "failed to get kubelet config from API")
 	}
 	config := cm.Data["kubelet"]
 	if config == "" {
-		return "", fmt.Errorf("no config found with key 'kubelet' in %s", cmName)
+		return "", nil, fmt.Errorf("no config found with key 'kubelet' in %s", cmName)
+	}
+	var extraArgs map[string]string
+	if err := yaml.Unmarshal([]byte(cm.Data["extraArgs"]), &extraArgs); err != nil {
+		return "", nil, errors.Wrapf(err, "invalid extraArgs in %s", cmName)
 	}
-	return config, nil
+	return config, extraArgs, nil
 }
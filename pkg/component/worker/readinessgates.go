@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+)
+
+// ReadinessGatesTaint keeps the pods off a node registered with readiness gates until all of them pass
+const ReadinessGatesTaint = "node.k0sproject.io/readiness-gates"
+
+// readinessGatesInterval is how often the node is checked for the readiness gates
+const readinessGatesInterval = 5 * time.Second
+
+// NodeReadinessGates waits for the node of the embedded worker to be ready and for its readiness gate conditions to be
+// true, then removes the ReadinessGatesTaint. The kubelet can't remove the taint itself, as the NodeRestriction
+// admission forbids nodes from modifying their taints, so the controller does it with the admin kubeconfig.
+type NodeReadinessGates struct {
+	Gates []string
+
+	nodeName string
+	client   kubernetes.Interface
+	log      *logrus.Entry
+	mu       sync.Mutex
+	passed   bool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Init creates the client for the node
+func (n *NodeReadinessGates) Init() error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	// the kubelet registers the node with the lowercased hostname
+	n.nodeName = strings.ToLower(hostname)
+	n.log = logrus.WithFields(logrus.Fields{"component": "readinessgates", "node": n.nodeName})
+	n.client, err = kubeutil.Client(constant.AdminKubeconfigConfigPath)
+	return err
+}
+
+// Run starts waiting for the readiness gates in the background
+func (n *NodeReadinessGates) Run() error {
+	n.stop = make(chan struct{})
+	n.done = make(chan struct{})
+	go func() {
+		defer close(n.done)
+		ticker := time.NewTicker(readinessGatesInterval)
+		defer ticker.Stop()
+		for {
+			err := n.check()
+			if err == nil {
+				n.mu.Lock()
+				n.passed = true
+				n.mu.Unlock()
+				n.log.Info("readiness gates passed, node is schedulable")
+				return
+			}
+			n.log.Debugf("waiting for the readiness gates: %s", err)
+			select {
+			case <-ticker.C:
+			case <-n.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// check removes the taint once the node passes all readiness gates
+func (n *NodeReadinessGates) check() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	node, err := n.client.CoreV1().Nodes().Get(ctx, n.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := checkReadinessGates(node, n.Gates); err != nil {
+		return err
+	}
+	var taints []corev1.Taint
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != ReadinessGatesTaint {
+			taints = append(taints, taint)
+		}
+	}
+	if len(taints) == len(node.Spec.Taints) {
+		return nil
+	}
+	node.Spec.Taints = taints
+	if _, err := n.client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to remove the %s taint: %v", ReadinessGatesTaint, err)
+	}
+	return nil
+}
+
+// checkReadinessGates returns an error unless the node is ready and all the gate conditions are true
+func checkReadinessGates(node *corev1.Node, gates []string) error {
+	conditions := map[corev1.NodeConditionType]corev1.ConditionStatus{}
+	for _, cond := range node.Status.Conditions {
+		conditions[cond.Type] = cond.Status
+	}
+	var pending []string
+	for _, gate := range append([]string{string(corev1.NodeReady)}, gates...) {
+		if conditions[corev1.NodeConditionType(gate)] != corev1.ConditionTrue {
+			pending = append(pending, gate)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("conditions not true yet: %s", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Ready returns nil once the node passed its readiness gates
+func (n *NodeReadinessGates) Ready() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.passed {
+		return fmt.Errorf("node %s has not passed its readiness gates yet", n.nodeName)
+	}
+	return nil
+}
+
+// Stop stops waiting for the readiness gates
+func (n *NodeReadinessGates) Stop() error {
+	if n.stop != nil {
+		close(n.stop)
+		<-n.done
+		n.stop = nil
+	}
+	return nil
+}
+
+// Health-check interface
+func (n *NodeReadinessGates) Healthy() error { return nil }
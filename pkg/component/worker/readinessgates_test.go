@@ -0,0 +1,41 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCheckReadinessGates(t *testing.T) {
+	node := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: "example.com/NetworkReady", Status: corev1.ConditionFalse},
+			},
+		},
+	}
+
+	assert.NoError(t, checkReadinessGates(node, nil))
+	assert.EqualError(t, checkReadinessGates(node, []string{"example.com/NetworkReady", "StorageReady"}),
+		"conditions not true yet: example.com/NetworkReady, StorageReady")
+
+	node.Status.Conditions[1].Status = corev1.ConditionTrue
+	assert.NoError(t, checkReadinessGates(node, []string{"example.com/NetworkReady"}))
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+)
+
+// Change is a difference between two configs at a path such as spec.api.address
+type Change struct {
+	Path string
+	// Old is the value in the old config, empty if the path is only set in the new one
+	Old string
+	// New is the value in the new config, empty if the path is only set in the old one
+	New string
+
+	inOld bool
+	inNew bool
+}
+
+func (c Change) String() string {
+	switch {
+	case !c.inOld:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.New)
+	case !c.inNew:
+		return fmt.Sprintf("- %s: %s", c.Path, c.Old)
+	case c.Old == c.New:
+		// a redacted secret changed
+		return fmt.Sprintf("~ %s: %s (changed)", c.Path, c.New)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// Diff returns the changes from the old to the new config, sorted by path. Both configs are compared with their
+// defaults applied, and the secret values are redacted the same way as in the process arguments.
+func Diff(old, new *v1beta1.ClusterConfig) ([]Change, error) {
+	oldValues, err := flattenConfig(old)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := flattenConfig(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for path, oldValue := range oldValues {
+		newValue, inNew := newValues[path]
+		if !inNew || newValue.raw != oldValue.raw {
+			changes = append(changes, Change{Path: path, Old: oldValue.shown, New: newValue.shown, inOld: true, inNew: inNew})
+		}
+	}
+	for path, newValue := range newValues {
+		if _, inOld := oldValues[path]; !inOld {
+			changes = append(changes, Change{Path: path, New: newValue.shown, inNew: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// value is a leaf value of a config, compared as is but shown redacted
+type value struct {
+	raw   string
+	shown string
+}
+
+// flattenConfig returns the leaf values of the config by their paths
+func flattenConfig(clusterConfig *v1beta1.ClusterConfig) (map[string]value, error) {
+	data, err := yaml.Marshal(clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	var config interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	values := map[string]value{}
+	flatten("", "", config, values)
+	return values, nil
+}
+
+func flatten(path, key string, node interface{}, values map[string]value) {
+	switch v := node.(type) {
+	case map[interface{}]interface{}:
+		for k, val := range v {
+			name := fmt.Sprint(k)
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			flatten(childPath, name, val, values)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			values[path] = value{raw: "[]", shown: "[]"}
+		}
+		for idx, val := range v {
+			flatten(fmt.Sprintf("%s[%d]", path, idx), key, val, values)
+		}
+	case string:
+		values[path] = value{raw: v, shown: strings.TrimPrefix(supervisor.RedactArg("--"+key+"="+v), "--"+key+"=")}
+	case nil:
+		values[path] = value{raw: "null", shown: "null"}
+	default:
+		values[path] = value{raw: fmt.Sprint(v), shown: fmt.Sprint(v)}
+	}
+}
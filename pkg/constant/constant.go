@@ -15,55 +15,58 @@ limitations under the License.
 */
 package constant
 
+import "path/filepath"
+
 const (
-	// DataDir folder contains all k0s state
-	DataDir = "/var/lib/k0s"
+	// DefaultDataDir is the data directory used unless relocated with SetDataDir
+	DefaultDataDir = "/var/lib/k0s"
+	// DataDirEnv is the environment variable relocating DataDir, it is also passed to the processes k0s supervises
+	DataDirEnv = "K0S_DATA_DIR"
+	// SkipKernelSetupEnv is the environment variable of the worker --skip-kernel-setup flag
+	SkipKernelSetupEnv = "K0S_SKIP_KERNEL_SETUP"
+	// DisableTelemetryEnv is the environment variable of the --disable-telemetry flag
+	DisableTelemetryEnv = "K0S_DISABLE_TELEMETRY"
 	// DataDirMode is the expected directory permissions for DataDir
 	DataDirMode = 0755
-	// EtcdDataDir contains etcd state
-	EtcdDataDir = "/var/lib/k0s/etcd"
 	// EtcdDataDirMode is the expected directory permissions for EtcdDataDir. see https://docs.datadoghq.com/security_monitoring/default_rules/cis-kubernetes-1.5.1-1.1.11/
 	EtcdDataDirMode = 0700
-	// CertRootDir defines the root location for all pki related artifacts
-	CertRootDir = "/var/lib/k0s/pki"
 	// CertRootDirMode is the expected directory permissions for CertRootDir.
 	CertRootDirMode = 0751
-	//EtcdCertDir contains etcd certificates
-	EtcdCertDir = "/var/lib/k0s/pki/etcd"
 	// EtcdCertDirMode is the expected directory permissions for EtcdCertDir
 	EtcdCertDirMode = 0711
+	// HostsFilePath is the hosts file k0s writes the configured host aliases to
+	HostsFilePath = "/etc/hosts"
+	// AuditLogPath is the default location of the API server audit log
+	AuditLogPath = "/var/log/k0s/audit/audit.log"
 	// CertMode is the expected permissions for certificates. see: https://docs.datadoghq.com/security_monitoring/default_rules/cis-kubernetes-1.5.1-1.1.20/
 	CertMode = 0644
 	// CertSecureMode is the expected file permissions for secure files. see: https://docs.datadoghq.com/security_monitoring/default_rules/cis-kubernetes-1.5.1-1.1.13/
 	// this relates to files like: admin.conf, kube-apiserver.yaml, certificate files, and more
 	CertSecureMode = 0640
-	// BinDir defines the location for all pki related binaries
-	BinDir = "/var/lib/k0s/bin"
 	// BinDirMode is the expected directory permissions for BinDir
 	BinDirMode = 0755
 	// RunDir defines the location of supervised pid files and sockets
 	RunDir = "/run/k0s"
 	// RunDirMode is the expected permissions of RunDir
 	RunDirMode = 0755
+	// ControlSocketPath defines the location of the local control socket of the k0s server
+	ControlSocketPath = "/run/k0s/control.sock"
+	// PreflightResultsPath holds the results of the latest startup preflight checks
+	PreflightResultsPath = "/run/k0s/preflight.json"
+	// ControlSocketMode is the expected file permissions for the control socket
+	ControlSocketMode = 0600
 	// PidFileMode is the expected file permissions for pid files
 	PidFileMode = 0644
-	// ManifestsDir defines the location for all stack manifests
-	ManifestsDir = "/var/lib/k0s/manifests"
 	// ManifestsDirMode is the expected directory permissions for ManifestsDir
 	ManifestsDirMode = 0644
+	// ContainerdConfigPath defines the location of the user provided containerd config
+	ContainerdConfigPath = "/etc/k0s/containerd.toml"
 
-	// KubeletBootstrapConfigPath defines the default path for kubelet bootstrap auth config
-	KubeletBootstrapConfigPath = "/var/lib/k0s/kubelet-bootstrap.conf"
-	// KubeletAuthConfigPath defines the default kubelet auth config path
-	KubeletAuthConfigPath = "/var/lib/k0s/kubelet.conf"
 	// KubeletVolumePluginDir defines the location for kubelet plugins volume executables
 	KubeletVolumePluginDir = "/usr/libexec/k0s/kubelet-plugins/volume/exec"
 	// KubeletVolumePlugindDirMode is the expected directory permissions for KubeleteVolumePluginDir
 	KubeletVolumePluginDirMode = 0700
 
-	// AdminKubeconfigConfigPath defines the cluster admin kubeconfig location
-	AdminKubeconfigConfigPath = "/var/lib/k0s/pki/admin.conf"
-
 	// Group defines group name for shared directories
 	Group = "k0s"
 
@@ -89,20 +92,98 @@ const (
 	DefaultPSP = "00-k0s-privileged"
 
 	// Image Constants
-	KonnectivityImage          = "us.gcr.io/k8s-artifacts-prod/kas-network-proxy/proxy-agent"
-	KonnectivityImageVersion   = "v0.0.13"
-	MetricsImage               = "gcr.io/k8s-staging-metrics-server/metrics-server"
-	MetricsImageVersion        = "v0.3.7"
-	KubeProxyImage             = "k8s.gcr.io/kube-proxy"
-	KubeProxyImageVersion      = "v1.19.0"
-	CoreDNSImage               = "docker.io/coredns/coredns"
-	CoreDNSImageVersion        = "1.7.0"
-	CalicoImage                = "calico/cni"
-	CalicoImageVersion         = "v3.16.2"
-	FlexVolumeImage            = "calico/pod2daemon-flexvol"
-	FlexVolumeImageVersion     = "v3.16.2"
-	CalicoNodeImage            = "calico/node"
-	CalicoNodeImageVersion     = "v3.16.2"
-	KubeControllerImage        = "calico/kube-controllers"
-	KubeControllerImageVersion = "v3.16.2"
+	KonnectivityImage                  = "us.gcr.io/k8s-artifacts-prod/kas-network-proxy/proxy-agent"
+	KonnectivityImageVersion           = "v0.0.13"
+	MetricsImage                       = "gcr.io/k8s-staging-metrics-server/metrics-server"
+	MetricsImageVersion                = "v0.3.7"
+	KubeProxyImage                     = "k8s.gcr.io/kube-proxy"
+	KubeProxyImageVersion              = "v1.19.0"
+	CoreDNSImage                       = "docker.io/coredns/coredns"
+	CoreDNSImageVersion                = "1.7.0"
+	CalicoImage                        = "calico/cni"
+	CalicoImageVersion                 = "v3.16.2"
+	FlexVolumeImage                    = "calico/pod2daemon-flexvol"
+	FlexVolumeImageVersion             = "v3.16.2"
+	CalicoNodeImage                    = "calico/node"
+	CalicoNodeImageVersion             = "v3.16.2"
+	KubeControllerImage                = "calico/kube-controllers"
+	KubeControllerImageVersion         = "v3.16.2"
+	CiliumImage                        = "quay.io/cilium/cilium"
+	CiliumImageVersion                 = "v1.9.1"
+	CiliumOperatorImage                = "quay.io/cilium/operator-generic"
+	CiliumOperatorImageVersion         = "v1.9.1"
+	KubeRouterCNIImage                 = "cloudnativelabs/kube-router"
+	KubeRouterCNIImageVersion          = "v1.1.0"
+	KubeRouterCNIInstallerImage        = "quay.io/k0sproject/cni-node"
+	KubeRouterCNIInstallerImageVersion = "0.1.0"
 )
+
+// ReservedEnvVars are the variables in the K0S_ namespace k0s reads for other purposes than overriding config fields
+var ReservedEnvVars = []string{DataDirEnv, SkipKernelSetupEnv, DisableTelemetryEnv}
+
+// Paths below are derived from DataDir and change when it is relocated with SetDataDir
+var (
+	// DataDir folder contains all k0s state
+	DataDir string
+	// EtcdDataDir contains etcd state
+	EtcdDataDir string
+	// CertRootDir defines the root location for all pki related artifacts
+	CertRootDir string
+	//EtcdCertDir contains etcd certificates
+	EtcdCertDir string
+	// TrustedCABundlePath is the system CA bundle combined with the user given trusted CAs, used by k0s and all the processes it runs
+	TrustedCABundlePath string
+	// AuditPolicyPath is the audit policy k0s writes for the API server if none is configured
+	AuditPolicyPath string
+	// BinDir defines the location for all pki related binaries
+	BinDir string
+	// ControllerLockPath is the lock preventing several k0s controllers from using the data directory
+	ControllerLockPath string
+	// WorkerLockPath is the lock preventing several k0s workers from using the data directory, a controller and a
+	// worker can share the data directory as their state is separate
+	WorkerLockPath string
+	// TelemetryIDPath is the random telemetry id persisted for the random telemetry id source
+	TelemetryIDPath string
+	// NodeIDPath is the random id persisted in place of the machine id when the machine id can't be read
+	NodeIDPath string
+	// ManifestsDir defines the location for all stack manifests
+	ManifestsDir string
+	// ContainerdGeneratedConfigPath defines the location of the containerd config generated by k0s
+	ContainerdGeneratedConfigPath string
+	// KubeletBootstrapConfigPath defines the default path for kubelet bootstrap auth config
+	KubeletBootstrapConfigPath string
+	// KubeletAuthConfigPath defines the default kubelet auth config path
+	KubeletAuthConfigPath string
+	// AdminKubeconfigConfigPath defines the cluster admin kubeconfig location
+	AdminKubeconfigConfigPath string
+	// ControllerManagerKubeconfigPath defines the kubeconfig location of the controller manager
+	ControllerManagerKubeconfigPath string
+	// SchedulerKubeconfigPath defines the kubeconfig location of the scheduler
+	SchedulerKubeconfigPath string
+)
+
+func init() {
+	SetDataDir(DefaultDataDir)
+}
+
+// SetDataDir relocates DataDir and recomputes all the paths derived from it
+func SetDataDir(dir string) {
+	DataDir = filepath.Clean(dir)
+	EtcdDataDir = filepath.Join(DataDir, "etcd")
+	CertRootDir = filepath.Join(DataDir, "pki")
+	EtcdCertDir = filepath.Join(CertRootDir, "etcd")
+	TrustedCABundlePath = filepath.Join(CertRootDir, "trusted-ca-bundle.crt")
+	AuditPolicyPath = filepath.Join(DataDir, "audit-policy.yaml")
+	BinDir = filepath.Join(DataDir, "bin")
+	ControllerLockPath = filepath.Join(DataDir, "k0s-controller.lock")
+	WorkerLockPath = filepath.Join(DataDir, "k0s-worker.lock")
+	TelemetryIDPath = filepath.Join(DataDir, "telemetry-id")
+	NodeIDPath = filepath.Join(DataDir, "node-id")
+	ManifestsDir = filepath.Join(DataDir, "manifests")
+	ContainerdGeneratedConfigPath = filepath.Join(DataDir, "containerd.toml")
+	KubeletBootstrapConfigPath = filepath.Join(DataDir, "kubelet-bootstrap.conf")
+	KubeletAuthConfigPath = filepath.Join(DataDir, "kubelet.conf")
+	AdminKubeconfigConfigPath = filepath.Join(CertRootDir, "admin.conf")
+	ControllerManagerKubeconfigPath = filepath.Join(CertRootDir, "ccm.conf")
+	SchedulerKubeconfigPath = filepath.Join(CertRootDir, "scheduler.conf")
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package constant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDataDir(t *testing.T) {
+	defer SetDataDir(DefaultDataDir)
+
+	assert.Equal(t, "/var/lib/k0s/pki", CertRootDir)
+
+	SetDataDir("/mnt/k0s/")
+	assert.Equal(t, "/mnt/k0s", DataDir)
+	assert.Equal(t, "/mnt/k0s/etcd", EtcdDataDir)
+	assert.Equal(t, "/mnt/k0s/pki", CertRootDir)
+	assert.Equal(t, "/mnt/k0s/pki/etcd", EtcdCertDir)
+	assert.Equal(t, "/mnt/k0s/pki/admin.conf", AdminKubeconfigConfigPath)
+	assert.Equal(t, "/mnt/k0s/kubelet.conf", KubeletAuthConfigPath)
+	assert.Equal(t, "/mnt/k0s/bin", BinDir)
+}
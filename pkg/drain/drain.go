@@ -0,0 +1,210 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultMaxConcurrency is the default number of pods evicted in parallel
+const DefaultMaxConcurrency = 5
+
+// retryInterval is how long to wait before retrying an eviction blocked by a PodDisruptionBudget
+// and between the checks whether an evicted pod is gone
+var retryInterval = 5 * time.Second
+
+// Drainer cordons a node and evicts its pods through the eviction API, so PodDisruptionBudgets are respected
+type Drainer struct {
+	Client kubernetes.Interface
+	// MaxConcurrency is the number of pods evicted in parallel
+	MaxConcurrency int
+	// GracePeriod overrides the termination grace period of the evicted pods, the pods' own is used if zero
+	GracePeriod time.Duration
+
+	log *logrus.Entry
+}
+
+// BlockedError is returned when evictions were still refused because of PodDisruptionBudgets when the drain timed out
+type BlockedError struct {
+	Pods []string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("evictions blocked by PodDisruptionBudgets: %s", strings.Join(e.Pods, ", "))
+}
+
+// Drain cordons the node and evicts all of its pods except those managed by DaemonSets, mirror pods and finished pods.
+// It returns when all evicted pods are gone or the context is done.
+func (d *Drainer) Drain(ctx context.Context, nodeName string) error {
+	d.log = logrus.WithField("node", nodeName)
+	if err := d.cordon(ctx, nodeName); err != nil {
+		return err
+	}
+
+	podList, err := d.Client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods on node %s: %v", nodeName, err)
+	}
+	pods := evictablePods(podList.Items)
+	d.log.Infof("evicting %d pods, %d at a time", len(pods), d.maxConcurrency())
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		blocked []string
+		failed  []string
+	)
+	sem := make(chan struct{}, d.maxConcurrency())
+	for i := range pods {
+		pod := pods[i]
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := d.evict(ctx, pod)
+			if err == nil {
+				return
+			}
+			name := pod.Namespace + "/" + pod.Name
+			mu.Lock()
+			defer mu.Unlock()
+			if apierrors.IsTooManyRequests(err) {
+				blocked = append(blocked, name)
+			} else {
+				failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to evict pods: %s", strings.Join(failed, ", "))
+	}
+	if len(blocked) > 0 {
+		return &BlockedError{Pods: blocked}
+	}
+	return nil
+}
+
+func (d *Drainer) maxConcurrency() int {
+	if d.MaxConcurrency <= 0 {
+		return DefaultMaxConcurrency
+	}
+	return d.MaxConcurrency
+}
+
+func (d *Drainer) cordon(ctx context.Context, nodeName string) error {
+	node, err := d.Client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", nodeName, err)
+	}
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	node.Spec.Unschedulable = true
+	if _, err := d.Client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", nodeName, err)
+	}
+	d.log.Info("cordoned")
+	return nil
+}
+
+// gracePeriodSeconds converts the grace period to the whole seconds of the API, rounding up so that a sub-second
+// grace period doesn't become 0, which would kill the pods immediately
+func gracePeriodSeconds(gracePeriod time.Duration) int64 {
+	return int64(math.Ceil(gracePeriod.Seconds()))
+}
+
+// evict evicts the pod, retrying while a PodDisruptionBudget blocks it, and waits for the pod to be gone
+func (d *Drainer) evict(ctx context.Context, pod corev1.Pod) error {
+	log := d.log.WithField("pod", pod.Namespace+"/"+pod.Name)
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	if d.GracePeriod > 0 {
+		seconds := gracePeriodSeconds(d.GracePeriod)
+		eviction.DeleteOptions = &metav1.DeleteOptions{GracePeriodSeconds: &seconds}
+	}
+
+	for {
+		err := d.Client.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		if err == nil || apierrors.IsNotFound(err) {
+			break
+		}
+		if !apierrors.IsTooManyRequests(err) {
+			return err
+		}
+		log.Warnf("eviction blocked by a PodDisruptionBudget, retrying in %s: %v", retryInterval, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryInterval):
+		}
+	}
+	log.Info("evicted")
+
+	for {
+		current, err := d.Client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("pod was evicted but is not gone yet: %v", ctx.Err())
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// evictablePods filters out the pods which are not evicted when draining: DaemonSet pods
+// would be recreated on the node right away, mirror pods are managed by the kubelet and finished pods hold no workload
+func evictablePods(pods []corev1.Pod) []corev1.Pod {
+	var evictable []corev1.Pod
+	for _, pod := range pods {
+		if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if controller := metav1.GetControllerOf(&pod); controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		evictable = append(evictable, pod)
+	}
+	return evictable
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testPod(name string, modify func(*corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+		Spec:       corev1.PodSpec{NodeName: "worker"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if modify != nil {
+		modify(pod)
+	}
+	return pod
+}
+
+func TestDrain(t *testing.T) {
+	retryInterval = 10 * time.Millisecond
+	isController := true
+
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker"}},
+		testPod("app", nil),
+		testPod("protected", nil),
+		testPod("daemon", func(p *corev1.Pod) {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "daemon", Controller: &isController}}
+		}),
+		testPod("mirror", func(p *corev1.Pod) {
+			p.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}
+		}),
+		testPod("done", func(p *corev1.Pod) { p.Status.Phase = corev1.PodSucceeded }),
+	)
+
+	var mu sync.Mutex
+	var evicted []string
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+		if eviction.Name == "protected" {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		mu.Lock()
+		evicted = append(evicted, eviction.Name)
+		mu.Unlock()
+		return true, nil, client.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := (&Drainer{Client: client, MaxConcurrency: 1}).Drain(ctx, "worker")
+
+	require.IsType(t, &BlockedError{}, err)
+	assert.Equal(t, []string{"default/protected"}, err.(*BlockedError).Pods)
+	assert.Equal(t, []string{"app"}, evicted)
+
+	node, err := client.CoreV1().Nodes().Get(context.Background(), "worker", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+}
+
+func TestGracePeriodSeconds(t *testing.T) {
+	assert.Equal(t, int64(1), gracePeriodSeconds(500*time.Millisecond))
+	assert.Equal(t, int64(30), gracePeriodSeconds(30*time.Second))
+	assert.Equal(t, int64(31), gracePeriodSeconds(30*time.Second+time.Millisecond))
+}
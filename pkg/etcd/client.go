@@ -18,34 +18,42 @@ package etcd
 import (
 	"context"
 	"fmt"
+	"io"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/k0sproject/k0s/pkg/constant"
 	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
 	"go.etcd.io/etcd/pkg/transport"
 )
 
-var (
-	etcdClientCertFile = filepath.Join(constant.CertRootDir, "apiserver-etcd-client.crt")
-	etcdClientKeyFile  = filepath.Join(constant.CertRootDir, "apiserver-etcd-client.key")
-	etcdTrustedCAFile  = filepath.Join(constant.EtcdCertDir, "ca.crt")
-)
+// leaderTransferConfirmTimeout is how long MoveLeader waits for the target to report itself as the leader
+const leaderTransferConfirmTimeout = 10 * time.Second
+
+// clientTLSInfo points to the client certificates in the current data dir
+func clientTLSInfo() transport.TLSInfo {
+	return transport.TLSInfo{
+		CertFile:      filepath.Join(constant.CertRootDir, "apiserver-etcd-client.crt"),
+		KeyFile:       filepath.Join(constant.CertRootDir, "apiserver-etcd-client.key"),
+		TrustedCAFile: filepath.Join(constant.EtcdCertDir, "ca.crt"),
+	}
+}
 
 // Client is our internal helper to access some of the etcd APIs
 type Client struct {
-	client *clientv3.Client
+	client   *clientv3.Client
+	endpoint string
 }
 
-// NewClient creates new Client
-func NewClient() (*Client, error) {
-	client := &Client{}
-	tlsInfo := transport.TLSInfo{
-		CertFile:      etcdClientCertFile,
-		KeyFile:       etcdClientKeyFile,
-		TrustedCAFile: etcdTrustedCAFile,
-	}
+// NewClient creates new Client connecting to the local etcd member at endpoint, its client URL
+func NewClient(endpoint string) (*Client, error) {
+	client := &Client{endpoint: endpoint}
+	tlsInfo := clientTLSInfo()
 
 	tlsConfig, err := tlsInfo.ClientConfig()
 	if err != nil {
@@ -53,7 +61,7 @@ func NewClient() (*Client, error) {
 	}
 
 	cli, _ := clientv3.New(clientv3.Config{
-		Endpoints: []string{"https://127.0.0.1:2379"},
+		Endpoints: []string{endpoint},
 		TLS:       tlsConfig,
 	})
 
@@ -123,6 +131,99 @@ func (c *Client) DeleteMember(ctx context.Context, peerID uint64) error {
 	return err
 }
 
+// FindMember returns the id of the member with the given hex id or name
+func (c *Client) FindMember(ctx context.Context, idOrName string) (uint64, error) {
+	resp, err := c.client.MemberList(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "etcd member list failed")
+	}
+	id, idErr := strconv.ParseUint(idOrName, 16, 64)
+	for _, m := range resp.Members {
+		if (idErr == nil && m.ID == id) || (m.Name != "" && m.Name == idOrName) {
+			return m.ID, nil
+		}
+	}
+	return 0, errors.Errorf("member not found: %s", idOrName)
+}
+
+// MoveLeader transfers the raft leadership from the local member to the target member. etcd only accepts the
+// transfer from the leader, so the local member has to be the current leader. The target has to be a started voting
+// member and the cluster has to have quorum. The transfer is confirmed by the local member seeing the target as the
+// leader.
+func (c *Client) MoveLeader(ctx context.Context, targetID uint64) error {
+	resp, err := c.client.MemberList(ctx)
+	if err != nil {
+		return errors.Wrap(err, "etcd member list failed")
+	}
+	names := map[uint64]string{}
+	var target *etcdserverpb.Member
+	for _, m := range resp.Members {
+		names[m.ID] = m.Name
+		if m.ID == targetID {
+			target = m
+		}
+	}
+	if target == nil {
+		return errors.Errorf("member %x not found", targetID)
+	}
+	if target.Name == "" {
+		return errors.Errorf("member %x has not started yet", targetID)
+	}
+	if target.IsLearner {
+		return errors.Errorf("member %s is a learner, it can't become the leader", target.Name)
+	}
+
+	status, err := c.client.Status(ctx, c.endpoint)
+	if err != nil {
+		return errors.Wrap(err, "etcd status failed")
+	}
+	if len(status.Errors) > 0 {
+		return errors.Errorf("local etcd member is unhealthy: %s", strings.Join(status.Errors, ", "))
+	}
+	if status.Leader == targetID {
+		return nil
+	}
+	if status.Header.MemberId != status.Leader {
+		return errors.Errorf("the local member %s is not the leader, run the command on the controller of the leader %s", names[status.Header.MemberId], names[status.Leader])
+	}
+	// a linearizable read only succeeds with quorum, i.e. with the target among the members following the leader
+	if _, err := c.client.Get(ctx, "health"); err != nil {
+		return errors.Wrap(err, "etcd cluster is unhealthy")
+	}
+
+	if _, err := c.client.MoveLeader(ctx, targetID); err != nil {
+		return errors.Wrapf(err, "failed to move the leadership to %s", target.Name)
+	}
+
+	deadline := time.Now().Add(leaderTransferConfirmTimeout)
+	for {
+		status, err := c.client.Status(ctx, c.endpoint)
+		if err == nil && status.Leader == targetID {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("the leadership did not move to %s within %s", target.Name, leaderTransferConfirmTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Snapshot streams a snapshot of the backend database of the local member to w
+func (c *Client) Snapshot(ctx context.Context, w io.Writer) error {
+	rc, err := c.client.Snapshot(ctx)
+	if err != nil {
+		return errors.Wrap(err, "etcd snapshot failed")
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(w, rc)
+	return errors.Wrap(err, "etcd snapshot failed")
+}
+
 // Close closes the etcd client
 func (c *Client) Close() {
 	c.client.Close()
@@ -0,0 +1,58 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// boltMagic is the magic number of the bbolt meta pages etcd stores its data in
+const boltMagic = 0xED0CDAED
+
+// boltMagicOffset is the offset of the magic number within the first meta page, right after the page header
+const boltMagicOffset = 16
+
+// CheckDataDir verifies the etcd data dir holds a member with a backend database and a write-ahead log
+func CheckDataDir(dataDir string) error {
+	member := filepath.Join(dataDir, "member")
+	db := filepath.Join(member, "snap", "db")
+	f, err := os.Open(db)
+	if err != nil {
+		return fmt.Errorf("no etcd database found in %s: %v", dataDir, err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, boltMagicOffset+4)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return fmt.Errorf("etcd database %s is truncated: %v", db, err)
+	}
+	if binary.LittleEndian.Uint32(buf[boltMagicOffset:]) != boltMagic {
+		return fmt.Errorf("etcd database %s is corrupt, it is not a bbolt database", db)
+	}
+
+	wals, err := filepath.Glob(filepath.Join(member, "wal", "*.wal"))
+	if err != nil {
+		return err
+	}
+	if len(wals) == 0 {
+		return fmt.Errorf("no etcd write-ahead log found in %s", filepath.Join(member, "wal"))
+	}
+	return nil
+}
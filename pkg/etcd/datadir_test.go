@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDataDir(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "etcd-data")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir)
+
+	assert.Error(t, CheckDataDir(dataDir))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "member", "snap"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "member", "wal"), 0700))
+	db := filepath.Join(dataDir, "member", "snap", "db")
+
+	require.NoError(t, ioutil.WriteFile(db, []byte("short"), 0600))
+	assert.Error(t, CheckDataDir(dataDir))
+
+	page := make([]byte, 4096)
+	require.NoError(t, ioutil.WriteFile(db, page, 0600))
+	assert.Error(t, CheckDataDir(dataDir))
+
+	binary.LittleEndian.PutUint32(page[boltMagicOffset:], boltMagic)
+	require.NoError(t, ioutil.WriteFile(db, page, 0600))
+	err = CheckDataDir(dataDir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "write-ahead log")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dataDir, "member", "wal", "0000000000000000-0000000000000000.wal"), page, 0600))
+	assert.NoError(t, CheckDataDir(dataDir))
+}
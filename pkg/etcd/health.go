@@ -2,22 +2,55 @@ package etcd
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/pkg/transport"
 )
 
-// CheckEtcdReady returns true if etcd responds to the metrics endpoint with a status code of 200
-func CheckEtcdReady() error {
-	c, err := NewClient()
+// healthCheckTimeout bounds a single health check of an etcd member
+const healthCheckTimeout = 5 * time.Second
+
+// healthTransport is shared by the health checks, it's created on the first check. The client certificate is read
+// on each handshake, so it survives the certificate being renewed.
+var (
+	healthTransportMu sync.Mutex
+	healthTransport   *http.Transport
+)
+
+// getHealthTransport returns the transport of the health checks
+func getHealthTransport() (*http.Transport, error) {
+	healthTransportMu.Lock()
+	defer healthTransportMu.Unlock()
+	if healthTransport == nil {
+		tr, err := transport.NewTransport(clientTLSInfo(), healthCheckTimeout)
+		if err != nil {
+			return nil, err
+		}
+		healthTransport = tr
+	}
+	return healthTransport, nil
+}
+
+// CheckEtcdReady returns nil if the etcd member at endpoint responds to the metrics endpoint with a status code of
+// 200. It checks once, taking up to a few seconds.
+func CheckEtcdReady(endpoint string) error {
+	c, err := NewClient(endpoint)
 	if err != nil {
 		logrus.Errorf("failed to initialize etcd client: %v", err)
 		return err
 	}
-	memberList, err := c.client.MemberList(context.Background())
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	memberList, err := c.client.MemberList(ctx)
 	if err != nil {
 		logrus.Errorf("failed to fetch etcd member list: %v\n", err)
 		return err
@@ -32,26 +65,26 @@ func CheckEtcdReady() error {
 	// the metrics endpoint was selected as a health endpoint in the official etcd docs: https://etcd.io/docs/v3.4.0/op-guide/monitoring/
 	u.Path = "/metrics"
 
-	tr, err := transport.NewTransport(transport.TLSInfo{
-		CertFile:      etcdClientCertFile,
-		KeyFile:       etcdClientKeyFile,
-		TrustedCAFile: etcdTrustedCAFile,
-	}, 5*time.Second)
+	tr, err := getHealthTransport()
 	if err != nil {
 		logrus.Errorf("error encountered setting up healthcheck TLS config: %v\n", err)
+		return err
 	}
 
-	resp, err := tr.RoundTrip(&http.Request{
-		Header: make(http.Header),
-		Method: http.MethodGet,
-		URL:    u,
-	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := tr.RoundTrip(req)
 	if err != nil {
 		logrus.Errorf("error accessing health endpoint: %v\n", err)
 		return err
 	}
+	defer resp.Body.Close()
+	// drain the metrics so the connection is reused by the next check
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		logrus.Printf("received unexpected status code from endpoint. expected %v, received %v", http.StatusOK, resp.StatusCode)
+		return fmt.Errorf("received unexpected status code from endpoint. expected %v, received %v", http.StatusOK, resp.StatusCode)
 	}
 
 	return nil
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Member describes a member of the etcd cluster
+type Member struct {
+	ID uint64
+	// Name is empty until the member has started
+	Name      string
+	PeerURLs  []string
+	IsLearner bool
+	// Local is true for the member the client is connected to
+	Local bool
+}
+
+// String returns the name of the member, or its hex id if it hasn't started yet
+func (m Member) String() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return fmt.Sprintf("%x", m.ID)
+}
+
+// Members returns the members of the etcd cluster
+func (c *Client) Members(ctx context.Context) ([]Member, error) {
+	resp, err := c.client.MemberList(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "etcd member list failed")
+	}
+	status, err := c.client.Status(ctx, c.endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "etcd status failed")
+	}
+	members := make([]Member, 0, len(resp.Members))
+	for _, m := range resp.Members {
+		members = append(members, Member{
+			ID:        m.ID,
+			Name:      m.Name,
+			PeerURLs:  m.PeerURLs,
+			IsLearner: m.IsLearner,
+			Local:     m.ID == status.Header.MemberId,
+		})
+	}
+	return members, nil
+}
+
+// FindMemberByIDOrPeerURL returns the member with the given hex id or peer URL
+func FindMemberByIDOrPeerURL(members []Member, idOrPeerURL string) (Member, error) {
+	id, idErr := strconv.ParseUint(idOrPeerURL, 16, 64)
+	for _, m := range members {
+		if idErr == nil && m.ID == id {
+			return m, nil
+		}
+		for _, peerURL := range m.PeerURLs {
+			if peerURL == idOrPeerURL {
+				return m, nil
+			}
+		}
+	}
+	return Member{}, errors.Errorf("member not found: %s", idOrPeerURL)
+}
+
+// CheckMemberRemoval refuses the removal of the local member and returns a warning if removing the member would
+// leave the cluster without quorum or without tolerance for failures. Only the members which have started count as
+// available, as the health of the other members can't be checked from the local member.
+func CheckMemberRemoval(members []Member, target Member) (string, error) {
+	if target.Local {
+		return "", errors.Errorf("member %s is the local member, remove it from another controller or use k0s etcd leave", target)
+	}
+	if target.IsLearner {
+		// learners don't vote, so they don't count for the quorum
+		return "", nil
+	}
+	voting, started := 0, 0
+	for _, m := range members {
+		if m.IsLearner || m.ID == target.ID {
+			continue
+		}
+		voting++
+		if m.Name != "" {
+			started++
+		}
+	}
+	quorum := voting/2 + 1
+	if started < quorum {
+		return fmt.Sprintf("after removing member %s only %d of the %d remaining voting members have started, fewer than the quorum of %d", target, started, voting, quorum), nil
+	}
+	if voting <= 2 {
+		return fmt.Sprintf("after removing member %s the cluster has %d voting members and tolerates no member failures", target, voting), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberRemoval(t *testing.T) {
+	local := Member{ID: 0x1, Name: "controller1", PeerURLs: []string{"https://10.0.0.1:2380"}, Local: true}
+	second := Member{ID: 0x2, Name: "controller2", PeerURLs: []string{"https://10.0.0.2:2380"}}
+	third := Member{ID: 0x3, Name: "controller3", PeerURLs: []string{"https://10.0.0.3:2380"}}
+	fourth := Member{ID: 0xa4, Name: "controller4", PeerURLs: []string{"https://10.0.0.4:2380"}}
+	unstarted := Member{ID: 0xa5, PeerURLs: []string{"https://10.0.0.5:2380"}}
+	learner := Member{ID: 0xa6, Name: "controller6", PeerURLs: []string{"https://10.0.0.6:2380"}, IsLearner: true}
+
+	t.Run("find by id or peer URL", func(t *testing.T) {
+		members := []Member{local, second, fourth}
+		m, err := FindMemberByIDOrPeerURL(members, "a4")
+		require.NoError(t, err)
+		assert.Equal(t, fourth.ID, m.ID)
+
+		m, err = FindMemberByIDOrPeerURL(members, "https://10.0.0.2:2380")
+		require.NoError(t, err)
+		assert.Equal(t, second.ID, m.ID)
+
+		_, err = FindMemberByIDOrPeerURL(members, "https://10.0.0.9:2380")
+		assert.Error(t, err)
+	})
+
+	t.Run("local member", func(t *testing.T) {
+		_, err := CheckMemberRemoval([]Member{local, second, third}, local)
+		assert.Error(t, err)
+	})
+
+	t.Run("keeps fault tolerance", func(t *testing.T) {
+		warning, err := CheckMemberRemoval([]Member{local, second, third, fourth}, fourth)
+		require.NoError(t, err)
+		assert.Empty(t, warning)
+	})
+
+	t.Run("loses fault tolerance", func(t *testing.T) {
+		warning, err := CheckMemberRemoval([]Member{local, second, third}, third)
+		require.NoError(t, err)
+		assert.Contains(t, warning, "tolerates no member failures")
+	})
+
+	t.Run("breaks quorum", func(t *testing.T) {
+		members := []Member{local, second, third, unstarted, {ID: 0xa7, PeerURLs: []string{"https://10.0.0.7:2380"}}}
+		warning, err := CheckMemberRemoval(members, third)
+		require.NoError(t, err)
+		assert.Contains(t, warning, "fewer than the quorum")
+	})
+
+	t.Run("learner", func(t *testing.T) {
+		warning, err := CheckMemberRemoval([]Member{local, second, learner}, learner)
+		require.NoError(t, err)
+		assert.Empty(t, warning)
+	})
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package leaderelection
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// ReleaseLock gives up the leader election lock of a kube component, so another candidate takes
+// over right away instead of waiting for the lease to expire. The lock is only released if it's
+// held by holderPrefix, the kube components use "<hostname>_<uuid>" as their identity. The
+// component must not be running anymore, it would just renew the lock otherwise.
+func ReleaseLock(ctx context.Context, client kubernetes.Interface, lockType, namespace, name, holderPrefix string) (bool, error) {
+	lock, err := resourcelock.New(lockType, namespace, name, client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{})
+	if err != nil {
+		return false, err
+	}
+	record, _, err := lock.Get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !strings.HasPrefix(record.HolderIdentity, holderPrefix) {
+		return false, nil
+	}
+
+	// the same record client-go writes when the leader releases the lock on cancel
+	return true, lock.Update(ctx, resourcelock.LeaderElectionRecord{
+		LeaderTransitions: record.LeaderTransitions,
+	})
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package leaderelection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestReleaseLock(t *testing.T) {
+	holder := "controller-1_0c2f6d6e"
+	fakeClient := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-scheduler", Namespace: "kube-system"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+	})
+
+	released, err := ReleaseLock(context.TODO(), fakeClient, resourcelock.LeasesResourceLock, "kube-system", "kube-scheduler", "controller-2_")
+	assert.NoError(t, err)
+	assert.False(t, released)
+
+	released, err = ReleaseLock(context.TODO(), fakeClient, resourcelock.LeasesResourceLock, "kube-system", "kube-scheduler", "controller-1_")
+	assert.NoError(t, err)
+	assert.True(t, released)
+
+	lease, err := fakeClient.CoordinationV1().Leases("kube-system").Get(context.TODO(), "kube-scheduler", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", *lease.Spec.HolderIdentity)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	mu sync.Mutex
+	// levels are the log levels of single components set with SetLevels
+	levels = map[string]logrus.Level{}
+	// loggers are the loggers of the components with their own log level
+	loggers = map[string]*logrus.Logger{}
+	// requested are the names of the components which asked for a logger
+	requested = map[string]bool{}
+)
+
+// SetLevels parses a comma separated list of component=level pairs, e.g. etcd=debug,konnectivity=trace,*=info, and
+// sets the log level of the named components. The * level applies to all the other components.
+func SetLevels(spec string) error {
+	parsed := map[string]logrus.Level{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid log level %q, expected component=level", pair)
+		}
+		level, err := logrus.ParseLevel(parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid log level of %s: %s", parts[0], err.Error())
+		}
+		parsed[parts[0]] = level
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if level, ok := parsed["*"]; ok {
+		logrus.SetLevel(level)
+		delete(parsed, "*")
+	}
+	levels = parsed
+	loggers = map[string]*logrus.Logger{}
+	return nil
+}
+
+// ForComponent returns the logger of the named component, which logs at the level set with SetLevels for the
+// component, and at the level of the standard logger otherwise
+func ForComponent(name string) *logrus.Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	requested[name] = true
+	level, ok := levels[name]
+	if !ok {
+		return logrus.WithField("component", name)
+	}
+	logger, ok := loggers[name]
+	if !ok {
+		std := logrus.StandardLogger()
+		logger = &logrus.Logger{
+			Out:          std.Out,
+			Formatter:    std.Formatter,
+			Hooks:        std.Hooks,
+			Level:        level,
+			ExitFunc:     std.ExitFunc,
+			ReportCaller: std.ReportCaller,
+		}
+		loggers[name] = logger
+	}
+	return logger.WithField("component", name)
+}
+
+// UnusedLevels returns the sorted names of the components given a level with SetLevels that never asked for a
+// logger, most likely misspelled ones
+func UnusedLevels() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var unused []string
+	for name := range levels {
+		if !requested[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package logging
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLevels(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	assert.NoError(t, SetLevels("etcd=debug, konnectivity=trace,*=warn,misspelled=error"))
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	assert.Equal(t, logrus.DebugLevel, ForComponent("etcd").Logger.Level)
+	assert.Equal(t, logrus.TraceLevel, ForComponent("konnectivity").Logger.Level)
+	assert.Equal(t, logrus.StandardLogger(), ForComponent("kubelet").Logger)
+	assert.Equal(t, "etcd", ForComponent("etcd").Data["component"])
+	assert.Equal(t, []string{"misspelled"}, UnusedLevels())
+
+	assert.Error(t, SetLevels("etcd"))
+	assert.Error(t, SetLevels("etcd=loud"))
+	assert.NoError(t, SetLevels(""))
+	assert.Equal(t, logrus.StandardLogger(), ForComponent("etcd").Logger)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package performance
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Progress prints the progress of the component stages and the timer checkpoints to a terminal. The stage begun last
+// is shown on a live line, which is replaced by a permanent line with the outcome once the stage ends.
+type Progress struct {
+	out      io.Writer
+	mu       sync.Mutex
+	started  map[string]time.Time
+	pending  []string
+	liveLine bool
+}
+
+// NewProgress creates a Progress printing to out, nil if out is not a terminal, the progress is only logged then
+func NewProgress(out *os.File) *Progress {
+	if !terminal.IsTerminal(int(out.Fd())) {
+		return nil
+	}
+	return newProgress(out)
+}
+
+func newProgress(out io.Writer) *Progress {
+	return &Progress{
+		out:     out,
+		started: map[string]time.Time{},
+	}
+}
+
+// Started shows the stage of the component on the live line
+func (p *Progress) Started(stage, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	step := stage + " " + name
+	p.started[step] = time.Now()
+	p.pending = append(p.pending, step)
+	p.clearLiveLine()
+	p.printLiveLine()
+}
+
+// Finished prints the outcome of the stage of the component, e.g. "starting apiserver... ok (3.2s)"
+func (p *Progress) Finished(stage, name string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	step := stage + " " + name
+	elapsed := time.Since(p.started[step]).Seconds()
+	delete(p.started, step)
+	for i, s := range p.pending {
+		if s == step {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			break
+		}
+	}
+
+	p.clearLiveLine()
+	if err != nil {
+		fmt.Fprintf(p.out, "%s... failed (%.1fs): %s\n", step, elapsed, err)
+	} else {
+		fmt.Fprintf(p.out, "%s... ok (%.1fs)\n", step, elapsed)
+	}
+	p.printLiveLine()
+}
+
+// Checkpoint prints a timer checkpoint, it can be passed to Timer.Notify
+func (p *Progress) Checkpoint(name string, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clearLiveLine()
+	fmt.Fprintf(p.out, "%s after %.1fs\n", name, duration.Seconds())
+	p.printLiveLine()
+}
+
+func (p *Progress) printLiveLine() {
+	if len(p.pending) == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "%s...", p.pending[len(p.pending)-1])
+	if others := len(p.pending) - 1; others > 0 {
+		fmt.Fprintf(p.out, " (%d more pending)", others)
+	}
+	p.liveLine = true
+}
+
+func (p *Progress) clearLiveLine() {
+	if p.liveLine {
+		// return to the start of the line and erase it
+		fmt.Fprint(p.out, "\r\033[K")
+		p.liveLine = false
+	}
+}
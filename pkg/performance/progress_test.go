@@ -0,0 +1,46 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package performance
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := newProgress(out)
+
+	p.Started("initializing", "etcd")
+	p.Started("initializing", "apiserver")
+	assert.Equal(t, "initializing etcd...\r\033[Kinitializing apiserver... (1 more pending)", out.String())
+
+	out.Reset()
+	p.Finished("initializing", "apiserver", nil)
+	assert.Regexp(t, "^\r\033\\[Kinitializing apiserver... ok \\(0.0s\\)\ninitializing etcd...$", out.String())
+
+	out.Reset()
+	p.Finished("initializing", "etcd", errors.New("boom"))
+	assert.Regexp(t, "^\r\033\\[Kinitializing etcd... failed \\(0.0s\\): boom\n$", out.String())
+
+	out.Reset()
+	p.Checkpoint("finished-component-init", 1500*time.Millisecond)
+	assert.Equal(t, "finished-component-init after 1.5s\n", out.String())
+}
@@ -17,19 +17,27 @@ package performance
 
 import (
 	"errors"
-	"github.com/sirupsen/logrus"
+	"sync"
 	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/logging"
 )
 
 // The Timer is a performance measuring tool. You should enable bufferOutput if you want to
 // programmatically decide when to output messages. If buffering is disabled, Checkpoint
 // timings will be logged immediately when recorded. Buffering can be useful when you
 // want to see all the recorded timings in a single place, to make comparison easy.
+// Checkpoints can be recorded concurrently, e.g. by components started in the background.
 type Timer struct {
+	mu           sync.Mutex
 	log          *logrus.Entry
 	bufferOutput bool
 	startedAt    time.Time
 	buffer       []checkpoint
+	recorded     []CheckpointTiming
+	notify       []func(name string, duration time.Duration)
 }
 
 type checkpoint struct {
@@ -38,9 +46,15 @@ type checkpoint struct {
 	err      error
 }
 
+// CheckpointTiming is a checkpoint recorded by the timer and the time elapsed since the timer was started
+type CheckpointTiming struct {
+	Name    string        `json:"name"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
 func NewTimer(name string) *Timer {
 	return &Timer{
-		log:          logrus.WithField("component", "performance-timer").WithField("target", name),
+		log:          logging.ForComponent("performance-timer").WithField("target", name),
 		bufferOutput: false,
 	}
 }
@@ -52,6 +66,14 @@ func (t *Timer) Buffer() *Timer {
 	return t
 }
 
+// Notify will make the timer call f with every recorded checkpoint, regardless of the buffering. Notify can be
+// called several times, the functions are called in the order they were added.
+func (t *Timer) Notify(f func(name string, duration time.Duration)) *Timer {
+	t.notify = append(t.notify, f)
+
+	return t
+}
+
 // Start will start the timer. It returns itself to allow easy chaining of create + start
 func (t *Timer) Start() *Timer {
 	t.startedAt = time.Now()
@@ -61,19 +83,28 @@ func (t *Timer) Start() *Timer {
 
 // Checkpoint records the time since the timer was started
 func (t *Timer) Checkpoint(name string) {
+	t.mu.Lock()
 	// if the timer was never started, we'll record an errored checkpoint that Output can recognise
 	if t.startedAt.IsZero() {
 		t.buffer = append(t.buffer, checkpoint{
 			name: name,
 			err:  errors.New("failed to record checkpoint, timer not started"),
 		})
+		t.mu.Unlock()
 		return
 	}
 
+	duration := time.Since(t.startedAt)
 	t.buffer = append(t.buffer, checkpoint{
-		duration: time.Since(t.startedAt),
+		duration: duration,
 		name:     name,
 	})
+	t.recorded = append(t.recorded, CheckpointTiming{Name: name, Elapsed: duration})
+	t.mu.Unlock()
+
+	for _, notify := range t.notify {
+		notify(name, duration)
+	}
 
 	if !t.bufferOutput {
 		t.Output()
@@ -82,6 +113,9 @@ func (t *Timer) Checkpoint(name string) {
 
 // Output will loop through the message buffer and output all messages in order.
 func (t *Timer) Output() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	for {
 		if len(t.buffer) == 0 {
 			return
@@ -106,3 +140,52 @@ func (t *Timer) Output() {
 			Debug("checkpoint recorded")
 	}
 }
+
+// Checkpoints returns all the checkpoints recorded so far in order, unlike Output they are kept by the timer
+func (t *Timer) Checkpoints() []CheckpointTiming {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	checkpoints := make([]CheckpointTiming, len(t.recorded))
+	copy(checkpoints, t.recorded)
+	return checkpoints
+}
+
+// Between returns the time between the first recordings of the two checkpoints, false if either wasn't recorded
+func (t *Timer) Between(from, to string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var fromElapsed, toElapsed time.Duration
+	var fromFound, toFound bool
+	for _, c := range t.recorded {
+		if c.Name == from && !fromFound {
+			fromElapsed, fromFound = c.Elapsed, true
+		}
+		if c.Name == to && !toFound {
+			toElapsed, toFound = c.Elapsed, true
+		}
+	}
+	if !fromFound || !toFound {
+		return 0, false
+	}
+	return toElapsed - fromElapsed, true
+}
+
+// LogSummary logs the checkpoints recorded so far as a single line, with the seconds since the start by checkpoint
+// name as fields, so log based monitoring can pick them up. The given fields are added to the line.
+func (t *Timer) LogSummary(fields logrus.Fields) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := logrus.Fields{}
+	for _, c := range t.recorded {
+		if _, ok := summary[c.Name]; !ok {
+			summary[c.Name] = c.Elapsed.Seconds()
+		}
+	}
+	for k, v := range fields {
+		summary[k] = v
+	}
+	t.log.WithFields(summary).Info("checkpoint summary")
+}
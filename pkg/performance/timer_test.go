@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package performance
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerCheckpoints(t *testing.T) {
+	timer := NewTimer("test").Buffer().Start()
+	timer.Checkpoint("finished-component-init")
+	time.Sleep(10 * time.Millisecond)
+	timer.Checkpoint("started-worker")
+	timer.Output()
+
+	checkpoints := timer.Checkpoints()
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, "finished-component-init", checkpoints[0].Name)
+	assert.Equal(t, "started-worker", checkpoints[1].Name)
+
+	between, ok := timer.Between("finished-component-init", "started-worker")
+	assert.True(t, ok)
+	assert.Equal(t, checkpoints[1].Elapsed-checkpoints[0].Elapsed, between)
+	assert.True(t, between >= 10*time.Millisecond)
+
+	_, ok = timer.Between("finished-component-init", "never-reached")
+	assert.False(t, ok)
+
+	logger, hook := test.NewNullLogger()
+	timer.log = logrus.NewEntry(logger)
+	timer.LogSummary(logrus.Fields{"worker-start-seconds": between.Seconds()})
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, checkpoints[0].Elapsed.Seconds(), entry.Data["finished-component-init"])
+	assert.Equal(t, checkpoints[1].Elapsed.Seconds(), entry.Data["started-worker"])
+	assert.Equal(t, between.Seconds(), entry.Data["worker-start-seconds"])
+}
+
+func TestTimerConcurrentCheckpoints(t *testing.T) {
+	timer := NewTimer("test").Buffer().Start()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			timer.Checkpoint(fmt.Sprintf("reconciler-%d-started", i))
+		}(i)
+	}
+	wg.Wait()
+	timer.Output()
+	assert.Len(t, timer.Checkpoints(), 10)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package preflight
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// minMemory is the memory below which the control plane is likely to run into trouble
+const minMemory = 1 << 30
+
+// ServerChecks returns the checks for running a server with the given config
+func ServerChecks(clusterConfig *config.ClusterConfig) []Check {
+	checks := []Check{
+		{Name: "running as root", Severity: SeverityError, Run: checkRoot},
+		{Name: "data directory writable", Severity: SeverityError, Run: checkWritable(constant.DataDir)},
+		{Name: "memory", Severity: SeverityWarning, Run: checkMemory},
+	}
+	ports := []int{6443, 9443, 8132, 8133}
+	if clusterConfig.Spec.Storage.Type == config.EtcdStorageType && !clusterConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
+		ports = append(ports, clusterConfig.Spec.Storage.Etcd.GetClientPort(), clusterConfig.Spec.Storage.Etcd.GetPeerPort())
+		checks = append(checks, Check{Name: "etcd data directory writable", Severity: SeverityError, Run: checkWritable(clusterConfig.Spec.Storage.Etcd.GetDataDir())})
+	}
+	for _, port := range ports {
+		checks = append(checks, Check{Name: fmt.Sprintf("port %d available", port), Severity: SeverityError, Run: checkPortFree(port)})
+	}
+	return checks
+}
+
+func checkRoot() (string, error) {
+	if os.Geteuid() != 0 {
+		return "", fmt.Errorf("k0s server needs to run as root")
+	}
+	return "", nil
+}
+
+func checkWritable(dir string) func() (string, error) {
+	return func() (string, error) {
+		if !util.IsDirectory(dir) {
+			// created on startup
+			return fmt.Sprintf("%s does not exist yet", dir), nil
+		}
+		return dir, util.CheckWritable(dir)
+	}
+}
+
+func checkPortFree(port int) func() (string, error) {
+	return func() (string, error) {
+		l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			return "", fmt.Errorf("port %d is in use, is k0s or another Kubernetes distribution already running?", port)
+		}
+		return "", l.Close()
+	}
+}
+
+func checkMemory() (string, error) {
+	data, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return "", err
+		}
+		total := kb * 1024
+		if total < minMemory {
+			return "", fmt.Errorf("%dMiB of memory, at least %dMiB recommended", total>>20, minMemory>>20)
+		}
+		return fmt.Sprintf("%dMiB", total>>20), nil
+	}
+	return "", fmt.Errorf("MemTotal not found in /proc/meminfo")
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package preflight
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Severity tells whether a failed check prevents k0s from starting
+type Severity string
+
+const (
+	// SeverityError checks must pass for k0s to start
+	SeverityError Severity = "error"
+	// SeverityWarning checks only warn about a setup which might cause issues
+	SeverityWarning Severity = "warning"
+)
+
+// Check is a single preflight check. Run returns an optional detail on success and the reason on failure.
+type Check struct {
+	Name     string
+	Severity Severity
+	Run      func() (string, error)
+}
+
+// Result is the outcome of a single preflight check
+type Result struct {
+	Name     string   `json:"name"`
+	Passed   bool     `json:"passed"`
+	Detail   string   `json:"detail,omitempty"`
+	Severity Severity `json:"severity"`
+}
+
+func (r Result) String() string {
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+		if r.Severity == SeverityWarning {
+			status = "WARN"
+		}
+	}
+	if r.Detail == "" {
+		return fmt.Sprintf("[%s] %s", status, r.Name)
+	}
+	return fmt.Sprintf("[%s] %s: %s", status, r.Name, r.Detail)
+}
+
+// Run runs all the checks in order
+func Run(checks []Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		detail, err := check.Run()
+		result := Result{
+			Name:     check.Name,
+			Passed:   err == nil,
+			Detail:   detail,
+			Severity: check.Severity,
+		}
+		if err != nil {
+			result.Detail = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Errors returns an error describing the failed checks of error severity, nil if there are none
+func Errors(results []Result) error {
+	var failed []string
+	for _, r := range results {
+		if !r.Passed && r.Severity == SeverityError {
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Name, r.Detail))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("preflight checks failed:\n%s", strings.Join(failed, "\n"))
+}
+
+// Save writes the results as json, so they can be inspected even if k0s failed to start
+func Save(path string, results []Result) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Load reads the results written by Save
+func Load(path string) ([]Result, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var results []Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package preflight
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "ok", Severity: SeverityError, Run: func() (string, error) { return "fine", nil }},
+		{Name: "warn", Severity: SeverityWarning, Run: func() (string, error) { return "", errors.New("low memory") }},
+		{Name: "fail", Severity: SeverityError, Run: func() (string, error) { return "", errors.New("port in use") }},
+	}
+
+	results := Run(checks)
+	require.Len(t, results, 3)
+	assert.Equal(t, "[PASS] ok: fine", results[0].String())
+	assert.Equal(t, "[WARN] warn: low memory", results[1].String())
+	assert.Equal(t, "[FAIL] fail: port in use", results[2].String())
+
+	err := Errors(results)
+	require.Error(t, err)
+	assert.Equal(t, "preflight checks failed:\nfail: port in use", err.Error())
+
+	assert.NoError(t, Errors(results[:2]))
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preflight")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "preflight.json")
+	results := []Result{
+		{Name: "root", Passed: true, Severity: SeverityError},
+		{Name: "memory", Passed: false, Detail: "512MiB", Severity: SeverityWarning},
+	}
+	require.NoError(t, Save(path, results))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, results, loaded)
+}
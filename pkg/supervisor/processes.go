@@ -0,0 +1,104 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const redacted = "<redacted>"
+
+// Process describes a process launched by a supervisor
+type Process struct {
+	Name    string
+	Command []string
+}
+
+var (
+	processesMu sync.Mutex
+	processes   = map[string]Process{}
+
+	secretFlag = regexp.MustCompile(`(?i)(token|password|passwd|secret)`)
+	// matches the key of a key=value argument, with or without leading dashes
+	argKey    = regexp.MustCompile(`^-{0,2}[A-Za-z0-9_.-]+$`)
+	urlScheme = regexp.MustCompile(`^[a-z][a-z0-9+.-]*://`)
+	// matches credentials in both URLs (user:pass@host after the scheme) and mysql style DSNs (user:pass@tcp(host))
+	credentials = regexp.MustCompile(`^([^:/@]*):[^@]*@`)
+	// matches the secret key=value pairs within a value: DSN query params (?password=x&sslmode=disable), lists
+	// (user=k0s,password=x) and keyword DSNs (host=db password=x)
+	secretPair = regexp.MustCompile(`(?i)((?:^|[\s?&;,])[A-Za-z0-9_.-]*(?:token|password|passwd|secret)[A-Za-z0-9_.-]*=)[^\s&;,]*`)
+)
+
+func registerProcess(s *Supervisor) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+	processes[s.Name] = Process{
+		Name:    s.Name,
+		Command: append([]string{s.BinPath}, s.Args...),
+	}
+}
+
+func unregisterProcess(s *Supervisor) {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+	delete(processes, s.Name)
+}
+
+// Processes returns the command lines of all currently supervised processes, sorted
+// by name. Values of secret bearing flags and credentials in URLs are redacted.
+func Processes() []Process {
+	processesMu.Lock()
+	defer processesMu.Unlock()
+
+	result := make([]Process, 0, len(processes))
+	for _, p := range processes {
+		command := make([]string, len(p.Command))
+		for i, arg := range p.Command {
+			command[i] = RedactArg(arg)
+		}
+		result = append(result, Process{Name: p.Name, Command: command})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// RedactArg redacts the secret material of a single command line argument: the value of a --flag=value or
+// key=value argument with a secret key, and the credentials and secret params of URLs and DSNs
+func RedactArg(arg string) string {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 || !argKey.MatchString(parts[0]) {
+		return redactValue(arg)
+	}
+	key, value := parts[0], parts[1]
+
+	// keys pointing to files holding secrets are fine to show
+	isReference := strings.HasSuffix(key, "-file") || strings.HasSuffix(key, "-path") || strings.HasSuffix(key, "-dir")
+	if secretFlag.MatchString(key) && !isReference {
+		return key + "=" + redacted
+	}
+	return key + "=" + redactValue(value)
+}
+
+// redactValue redacts the credentials of a URL or DSN and the values of its secret params
+func redactValue(value string) string {
+	// the scheme is left out, so that it isn't taken for the user of a URL without a password
+	scheme := urlScheme.FindString(value)
+	value = scheme + credentials.ReplaceAllString(strings.TrimPrefix(value, scheme), "${1}:"+redacted+"@")
+	return secretPair.ReplaceAllString(value, "${1}"+redacted)
+}
@@ -26,11 +26,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/k0sproject/k0s/pkg/assets"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/util"
-	"github.com/sirupsen/logrus"
 )
 
+// DefaultTimeoutStop is how long a process gets to exit after SIGTERM before it is killed
+const DefaultTimeoutStop = 30 * time.Second
+
 // Supervisor is dead simple and stupid process supervisor, just tries to keep the process running in a while-true loop
 type Supervisor struct {
 	Name    string
@@ -40,15 +44,20 @@ type Supervisor struct {
 	PidFile string
 	UID     int
 	GID     int
-	cmd     *exec.Cmd
-	quit    chan bool
-	done    chan bool
+	// TimeoutStop is the grace period between SIGTERM and SIGKILL on shutdown, DefaultTimeoutStop if not set
+	TimeoutStop time.Duration
+	// Exits receives why the process exited on its own. If set, the supervisor does not respawn
+	// the process but stops supervising it, leaving the restart to the receiver.
+	Exits chan<- error
+	cmd   *exec.Cmd
+	quit  chan bool
+	done  chan bool
 }
 
 // processWaitQuit waits for a process to exit or a shut down signal
 // returns true if shutdown is requested
 func (s *Supervisor) processWaitQuit() bool {
-	log := logrus.WithField("component", s.Name)
+	log := logging.ForComponent(s.Name)
 	waitresult := make(chan error)
 	go func() {
 		waitresult <- s.cmd.Wait()
@@ -63,19 +72,25 @@ func (s *Supervisor) processWaitQuit() bool {
 
 	select {
 	case <-s.quit:
-		for {
-			log.Infof("Shutting down pid %d", s.cmd.Process.Pid)
-			err := s.cmd.Process.Signal(syscall.SIGTERM)
-			if err != nil {
-				log.Warnf("Failed to send SIGTERM to pid %d: %s", s.cmd.Process.Pid, err)
-			}
-			select {
-			case <-time.After(5 * time.Second):
-				continue
-			case <-waitresult:
-				return true
+		timeout := s.TimeoutStop
+		if timeout <= 0 {
+			timeout = DefaultTimeoutStop
+		}
+		log.Infof("Shutting down pid %d", s.cmd.Process.Pid)
+		err := s.cmd.Process.Signal(syscall.SIGTERM)
+		if err != nil {
+			log.Warnf("Failed to send SIGTERM to pid %d: %s", s.cmd.Process.Pid, err)
+		}
+		select {
+		case <-time.After(timeout):
+			log.Warnf("pid %d did not exit within %s after SIGTERM, killing it", s.cmd.Process.Pid, timeout)
+			if err := s.cmd.Process.Kill(); err != nil {
+				log.Warnf("Failed to kill pid %d: %s", s.cmd.Process.Pid, err)
 			}
+			<-waitresult
+		case <-waitresult:
 		}
+		return true
 	case err := <-waitresult:
 		if err != nil {
 			log.Warn(err)
@@ -88,17 +103,19 @@ func (s *Supervisor) processWaitQuit() bool {
 
 // Supervise Starts supervising the given process
 func (s *Supervisor) Supervise() {
-	log := logrus.WithField("component", s.Name)
+	log := logging.ForComponent(s.Name)
 	s.quit = make(chan bool)
 	s.done = make(chan bool)
 	s.PidFile = path.Join(constant.RunDir, s.Name) + ".pid"
 	if err := util.InitDirectory(constant.RunDir, constant.RunDirMode); err != nil {
 		log.Warnf("failed to initialize dir: %v", err)
 	}
+	registerProcess(s)
 	go func() {
 		log.Info("Starting to supervise")
 		defer func() {
-			s.done <- true
+			unregisterProcess(s)
+			close(s.done)
 		}()
 		for {
 			s.cmd = exec.Command(s.BinPath, s.Args...)
@@ -112,7 +129,11 @@ func (s *Supervisor) Supervise() {
 			s.cmd.Stdout = log.Writer()
 			s.cmd.Stderr = log.Writer()
 
-			err := s.cmd.Start()
+			// never run a binary which has been tampered with since it was extracted
+			err := assets.VerifyBinary(s.BinPath)
+			if err == nil {
+				err = s.cmd.Start()
+			}
 			if err != nil {
 				log.Warnf("Failed to start: %s", err)
 			} else {
@@ -120,6 +141,16 @@ func (s *Supervisor) Supervise() {
 				if s.processWaitQuit() {
 					return
 				}
+				err = fmt.Errorf("process exited: %s", s.cmd.ProcessState)
+			}
+
+			if s.Exits != nil {
+				// a previous exit not picked up yet is still pending, the receiver is restarting already
+				select {
+				case s.Exits <- err:
+				default:
+				}
+				return
 			}
 
 			// TODO Maybe some backoff thingy would be nice
@@ -139,12 +170,39 @@ func (s *Supervisor) Supervise() {
 // Stop stops the supervised
 func (s *Supervisor) Stop() error {
 	if s.quit != nil {
-		s.quit <- true
-		<-s.done
+		// the supervising goroutine is gone already if the process exited while reported through Exits
+		select {
+		case s.quit <- true:
+			<-s.done
+		case <-s.done:
+		}
 	}
 	return nil
 }
 
+// RunningPid returns the pid of the process supervised with the given name,
+// as recorded in its pid file, if that process is still alive
+func RunningPid(name string) (int, bool) {
+	pidFile := path.Join(constant.RunDir, name) + ".pid"
+	data, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return 0, false
+	}
+	// signal 0 only checks the process exists, a stale pid file of a crashed process is ignored
+	if err := process.Signal(syscall.Signal(0)); err != nil {
+		return 0, false
+	}
+	return pid, true
+}
+
 // Modifies the current processes env so that we inject k0s embedded bins into path
 func getEnv() []string {
 	env := os.Environ()
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessWaitQuitKillsAfterTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM cannot be ignored on windows")
+	}
+	dir, err := ioutil.TempDir("", "supervisor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &Supervisor{
+		Name:        "ignores-sigterm",
+		PidFile:     filepath.Join(dir, "ignores-sigterm.pid"),
+		TimeoutStop: 100 * time.Millisecond,
+		quit:        make(chan bool),
+	}
+	s.cmd = exec.Command("/bin/sh", "-c", "trap '' TERM; while true; do sleep 0.1; done")
+	require.NoError(t, s.cmd.Start())
+
+	go func() { s.quit <- true }()
+	start := time.Now()
+	assert.True(t, s.processWaitQuit())
+	assert.True(t, time.Since(start) < DefaultTimeoutStop)
+	assert.False(t, s.cmd.ProcessState.Success())
+}
+
+func TestSuperviseStopHonoursTimeoutStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM cannot be ignored on windows")
+	}
+	dir, err := ioutil.TempDir("", "supervisor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	constant.SetDataDir(dir)
+	defer constant.SetDataDir(constant.DefaultDataDir)
+
+	s := &Supervisor{
+		Name:        "ignores-sigterm",
+		BinPath:     "/bin/sh",
+		Args:        []string{"-c", "trap '' TERM; while true; do sleep 0.1; done"},
+		UID:         os.Getuid(),
+		GID:         os.Getgid(),
+		TimeoutStop: 200 * time.Millisecond,
+	}
+	s.Supervise()
+	require.Eventually(t, func() bool {
+		_, running := RunningPid(s.Name)
+		return running
+	}, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, s.Stop())
+	assert.True(t, time.Since(start) < DefaultTimeoutStop, "the process got the default grace period instead of %s", s.TimeoutStop)
+	_, running := RunningPid(s.Name)
+	assert.False(t, running)
+}
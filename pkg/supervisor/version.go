@@ -0,0 +1,84 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// versionTimeout bounds how long a binary may take to print its version
+const versionTimeout = 5 * time.Second
+
+type cachedVersion struct {
+	modTime time.Time
+	size    int64
+	version string
+}
+
+var (
+	versionsMu sync.Mutex
+	versions   = map[string]cachedVersion{}
+
+	versionPattern = regexp.MustCompile(`v?[0-9]+\.[0-9]+\.[0-9]+[0-9A-Za-z.+-]*`)
+)
+
+// Version returns the version of the supervised binary
+func (s *Supervisor) Version() string {
+	return BinaryVersion(s.BinPath)
+}
+
+// BinaryVersion returns the version the binary prints with --version, "unknown" if it can't tell. The result
+// is cached until the binary changes, so the binary is run only once per version.
+func BinaryVersion(binPath string) string {
+	info, err := os.Stat(binPath)
+	if err != nil {
+		return "unknown"
+	}
+
+	versionsMu.Lock()
+	cached, ok := versions[binPath]
+	versionsMu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.version
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	// some binaries print the version to stderr
+	out, _ := exec.CommandContext(ctx, binPath, "--version").CombinedOutput()
+	version := parseVersion(string(out))
+
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	versions[binPath] = cachedVersion{modTime: info.ModTime(), size: info.Size(), version: version}
+	return version
+}
+
+// parseVersion picks the version number out of the first line of --version output mentioning one
+func parseVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if v := versionPattern.FindString(line); v != "" {
+			return v
+		}
+	}
+	return "unknown"
+}
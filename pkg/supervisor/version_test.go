@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: "etcd Version: 3.4.13\nGit SHA: ae9734ed2\nGo Version: go1.12.17\n", want: "3.4.13"},
+		{output: "Kubernetes v1.19.4+k0s.1\n", want: "v1.19.4+k0s.1"},
+		{output: "containerd github.com/containerd/containerd v1.4.3 269548fa27e0089a8b8278fc4fc781d7f65a939b\n", want: "v1.4.3"},
+		{output: "flag provided but not defined: -version\nUsage of konnectivity-server:\n", want: "unknown"},
+		{output: "", want: "unknown"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseVersion(tt.output))
+	}
+}
+
+func TestBinaryVersionCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, "fake")
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho x >> " + calls + "\necho 'fake version v1.2.3'\n"
+	require.NoError(t, ioutil.WriteFile(bin, []byte(script), 0700))
+
+	assert.Equal(t, "v1.2.3", BinaryVersion(bin))
+	assert.Equal(t, "v1.2.3", BinaryVersion(bin))
+	data, err := ioutil.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "x"))
+
+	// a new binary is run again
+	require.NoError(t, ioutil.WriteFile(bin, []byte(script+"echo 'fake version v1.2.4'\n"), 0700))
+	BinaryVersion(bin)
+	data, err = ioutil.ReadFile(calls)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "x"))
+
+	assert.Equal(t, "unknown", BinaryVersion(filepath.Join(dir, "missing")))
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/build"
+	"github.com/k0sproject/k0s/pkg/preflight"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// Info holds the host and cluster diagnostics attached to bug reports
+type Info struct {
+	K0sVersion    string                  `json:"k0sVersion"`
+	OS            string                  `json:"os"`
+	Arch          string                  `json:"arch"`
+	KernelVersion string                  `json:"kernelVersion"`
+	CgroupVersion string                  `json:"cgroupVersion"`
+	KernelModules []string                `json:"kernelModules"`
+	Config        interface{}             `json:"config,omitempty"`
+	Status        *v1beta1.StatusResponse `json:"status,omitempty"`
+	Preflight     []preflight.Result      `json:"preflight,omitempty"`
+	Errors        []string                `json:"errors,omitempty"`
+}
+
+// Collect gathers the host information. Failures are recorded in Errors, so that as much as possible gets collected.
+func Collect() *Info {
+	info := &Info{
+		K0sVersion: build.Version,
+		Arch:       runtime.GOARCH,
+	}
+
+	if data, err := ioutil.ReadFile("/etc/os-release"); err != nil {
+		info.AddError("os", err)
+	} else {
+		info.OS = parseOSRelease(string(data))
+	}
+
+	if data, err := ioutil.ReadFile("/proc/sys/kernel/osrelease"); err != nil {
+		info.AddError("kernel version", err)
+	} else {
+		info.KernelVersion = strings.TrimSpace(string(data))
+	}
+
+	info.CgroupVersion = util.CgroupVersion()
+
+	if data, err := ioutil.ReadFile("/proc/modules"); err != nil {
+		info.AddError("kernel modules", err)
+	} else {
+		info.KernelModules = parseModules(string(data))
+	}
+
+	return info
+}
+
+// AddError records a failure to collect the named information
+func (i *Info) AddError(what string, err error) {
+	i.Errors = append(i.Errors, fmt.Sprintf("failed to collect %s: %s", what, err.Error()))
+}
+
+// SetConfig adds the cluster config with the secrets redacted
+func (i *Info) SetConfig(clusterConfig *v1beta1.ClusterConfig) error {
+	data, err := yaml.Marshal(clusterConfig)
+	if err != nil {
+		return err
+	}
+	var config interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	i.Config = redact("", config)
+	return nil
+}
+
+// redact converts the yaml maps to json compatible ones and redacts the values of secret keys
+// and the credentials in URLs, the same way as for the process arguments
+func redact(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = redact(fmt.Sprint(k), val)
+		}
+		return m
+	case []interface{}:
+		for idx, val := range v {
+			v[idx] = redact(key, val)
+		}
+		return v
+	case string:
+		return strings.TrimPrefix(supervisor.RedactArg("--"+key+"="+v), "--"+key+"=")
+	default:
+		return v
+	}
+}
+
+func parseOSRelease(data string) string {
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(line, "PRETTY_NAME=") {
+			return strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), `"`)
+		}
+	}
+	return ""
+}
+
+func parseModules(data string) []string {
+	var modules []string
+	for _, line := range strings.Split(data, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			modules = append(modules, fields[0])
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
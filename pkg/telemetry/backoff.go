@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/segmentio/analytics-go.v3"
+)
+
+// maxBackoffTicks is the most sends skipped after consecutive failures to deliver the telemetry
+const maxBackoffTicks = 32
+
+// backoff makes the component skip sends after failures to deliver the telemetry, so an unreachable endpoint
+// is neither hammered nor logged about on every tick. It is the analytics.Callback of the segment client.
+type backoff struct {
+	mu       sync.Mutex
+	log      *logrus.Entry
+	failures int
+	skip     int
+}
+
+// Success resets the backoff once the endpoint is reachable again
+func (b *backoff) Success(analytics.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures > 0 {
+		b.log.Info("telemetry delivered again")
+	}
+	b.failures, b.skip = 0, 0
+}
+
+// Failure doubles the sends skipped with each consecutive failure, up to maxBackoffTicks.
+// Only the first failure is logged as a warning.
+func (b *backoff) Failure(_ analytics.Message, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.skip = maxBackoffTicks
+	if b.failures < 6 {
+		b.skip = 1<<uint(b.failures) - 1
+	}
+	if b.failures == 1 {
+		b.log.WithError(err).Warning("can't deliver telemetry, backing off until the endpoint is reachable")
+		return
+	}
+	b.log.WithError(err).Debugf("can't deliver telemetry, skipping the next %d sends", b.skip)
+}
+
+// next tells whether the telemetry is sent on this tick
+func (b *backoff) next() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.skip > 0 {
+		b.skip--
+		return false
+	}
+	return true
+}
+
+// segmentLogger passes the logs of the segment client on at debug level, the failures are reported by the backoff
+type segmentLogger struct {
+	log *logrus.Entry
+}
+
+func (l segmentLogger) Logf(format string, args ...interface{}) {
+	l.log.Debugf(format, args...)
+}
+
+func (l segmentLogger) Errorf(format string, args ...interface{}) {
+	l.log.Debugf(format, args...)
+}
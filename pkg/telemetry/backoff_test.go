@@ -0,0 +1,39 @@
+package telemetry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{log: logrus.NewEntry(logrus.New())}
+	assert.True(t, b.next())
+
+	sends := func(ticks int) (sent int) {
+		for i := 0; i < ticks; i++ {
+			if b.next() {
+				sent++
+			}
+		}
+		return sent
+	}
+
+	b.Failure(nil, errors.New("connection refused"))
+	assert.Equal(t, 1, b.skip)
+	assert.Equal(t, 1, sends(2))
+
+	b.Failure(nil, errors.New("connection refused"))
+	assert.Equal(t, 3, b.skip)
+	assert.Equal(t, 1, sends(4))
+
+	for i := 0; i < 10; i++ {
+		b.Failure(nil, errors.New("connection refused"))
+	}
+	assert.Equal(t, maxBackoffTicks, b.skip)
+
+	b.Success(nil)
+	assert.True(t, b.next())
+}
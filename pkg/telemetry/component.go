@@ -6,6 +6,7 @@ import (
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
@@ -19,25 +20,42 @@ type Component struct {
 	kubernetesClient kubernetes.Interface
 	analyticsClient  analyticsClient
 
-	log      *logrus.Entry
-	stopCh   chan struct{}
-	interval time.Duration
+	log        *logrus.Entry
+	stopCh     chan struct{}
+	interval   time.Duration
+	instanceID string
+	backoff    *backoff
 }
 
 // Init set up for external service clients (segment, k8s api)
 func (c *Component) Init() error {
-	c.log = logrus.WithField("component", "telemetry")
+	c.log = logging.ForComponent("telemetry")
 
 	if segmentToken == "" {
 		c.log.Info("no token, telemetry is disabled")
 		return nil
 	}
 
+	id, err := instanceID(c.ClusterConfig.Telemetry, c.ClusterConfig.Spec.API.Address, constant.TelemetryIDPath)
+	if err != nil {
+		c.log.WithError(err).Warning("can't determine the telemetry instance id, telemetry is disabled")
+		return nil
+	}
+	c.instanceID = id
+	c.log.WithField("idSource", c.ClusterConfig.Telemetry.IDSource).Info("telemetry instance id determined")
+
+	c.backoff = &backoff{log: c.log}
+	analyticsClient, err := newSegmentClient(segmentToken, c.ClusterConfig.Telemetry.Endpoint, c.backoff)
+	if err != nil {
+		c.log.WithError(err).Warning("can't init segment client, telemetry is disabled")
+		c.instanceID = ""
+		return nil
+	}
+	c.analyticsClient = analyticsClient
+	c.log.Info("segment client has been init")
+
 	c.interval = c.ClusterConfig.Telemetry.Interval
 	c.stopCh = make(chan struct{})
-	c.log.Info("kube client has been init")
-	c.analyticsClient = newSegmentClient(segmentToken)
-	c.log.Info("segment client has been init")
 	return nil
 }
 
@@ -53,8 +71,7 @@ func (c *Component) retrieveKubeClient(ch chan struct{}) {
 
 // Run runs work cycle
 func (c *Component) Run() error {
-	if segmentToken == "" {
-		c.log.Info("no token, telemetry is disabled")
+	if !c.enabled() {
 		return nil
 	}
 	initedCh := make(chan struct{})
@@ -67,8 +84,7 @@ func (c *Component) Run() error {
 
 // Run does nothing
 func (c *Component) Stop() error {
-	if segmentToken == "" {
-		c.log.Info("no token, telemetry is disabled")
+	if !c.enabled() {
 		return nil
 	}
 	close(c.stopCh)
@@ -78,6 +94,11 @@ func (c *Component) Stop() error {
 	return nil
 }
 
+// enabled returns true if Init set up the sending of the telemetry
+func (c *Component) enabled() bool {
+	return segmentToken != "" && c.instanceID != ""
+}
+
 // Healthy checks health
 func (c *Component) Healthy() error {
 	return nil
@@ -88,7 +109,9 @@ func (c Component) run() {
 	for {
 		select {
 		case <-ticker.C:
-			c.sendTelemetry()
+			if c.backoff.next() {
+				c.sendTelemetry()
+			}
 		case <-c.stopCh:
 			return
 		}
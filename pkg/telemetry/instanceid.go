@@ -0,0 +1,34 @@
+package telemetry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/util"
+)
+
+// appID keys the hashes of the ids, the same way the machine id is protected
+const appID = "k0sproject-k0s"
+
+// instanceID returns the anonymous id the telemetry is sent with, from the configured id source. The random id is
+// read from or persisted to idPath, so it stays the same across restarts. The machine id source uses the node id
+// derived from the machine id and the API address.
+func instanceID(telemetryConfig *config.ClusterTelemetry, apiAddress string, idPath string) (string, error) {
+	switch telemetryConfig.IDSource {
+	case config.TelemetryIDSourceClusterID:
+		return protectedID(telemetryConfig.ClusterID), nil
+	case config.TelemetryIDSourceRandom:
+		return util.PersistedRandomID(idPath, "telemetry id")
+	default:
+		return util.NodeID(apiAddress)
+	}
+}
+
+// protectedID hashes the id so the configured value itself is never sent
+func protectedID(id string) string {
+	mac := hmac.New(sha256.New, []byte(id))
+	mac.Write([]byte(appID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
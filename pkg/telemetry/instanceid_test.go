@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+)
+
+func TestInstanceID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-telemetry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	idPath := path.Join(dir, "telemetry-id")
+
+	t.Run("cluster id is hashed", func(t *testing.T) {
+		telemetryConfig := &config.ClusterTelemetry{IDSource: config.TelemetryIDSourceClusterID, ClusterID: "prod-eu-1"}
+		id, err := instanceID(telemetryConfig, "10.0.0.1", idPath)
+		require.NoError(t, err)
+		assert.NotContains(t, id, "prod-eu-1")
+
+		again, err := instanceID(telemetryConfig, "10.0.0.1", idPath)
+		require.NoError(t, err)
+		assert.Equal(t, id, again)
+
+		other, err := instanceID(&config.ClusterTelemetry{IDSource: config.TelemetryIDSourceClusterID, ClusterID: "prod-us-1"}, "10.0.0.1", idPath)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, other)
+	})
+
+	t.Run("random id is persisted", func(t *testing.T) {
+		telemetryConfig := &config.ClusterTelemetry{IDSource: config.TelemetryIDSourceRandom}
+		id, err := instanceID(telemetryConfig, "10.0.0.1", idPath)
+		require.NoError(t, err)
+		assert.Len(t, id, 32)
+		assert.FileExists(t, idPath)
+
+		again, err := instanceID(telemetryConfig, "10.0.0.1", idPath)
+		require.NoError(t, err)
+		assert.Equal(t, id, again)
+	})
+}
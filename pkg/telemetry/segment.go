@@ -12,6 +12,11 @@ type analyticsClient interface {
 	Close() error
 }
 
-func newSegmentClient(segmentToken string) analyticsClient {
-	return analytics.New(segmentToken)
+// newSegmentClient creates the client sending to the endpoint, Segment itself if empty
+func newSegmentClient(segmentToken string, endpoint string, backoff *backoff) (analyticsClient, error) {
+	return analytics.NewWithConfig(segmentToken, analytics.Config{
+		Endpoint: endpoint,
+		Callback: backoff,
+		Logger:   segmentLogger{log: backoff.log},
+	})
 }
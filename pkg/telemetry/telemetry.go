@@ -4,13 +4,23 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/pkg/errors"
+
 	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/etcd"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/logging"
 	"github.com/k0sproject/k0s/pkg/util"
 	"gopkg.in/segmentio/analytics-go.v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// machineID is replaced in the tests, the machine id differs on every host
+var machineID = util.MachineID
+
+// telemetryData is all the telemetry sends, besides the anonymous instance id. It must never hold anything
+// identifying the cluster, such as the API address or SANs, the cluster id is only sent hashed.
 type telemetryData struct {
 	StorageType            string
 	ClusterID              string
@@ -59,16 +69,14 @@ func (c Component) getStorageType() string {
 	return "unknown"
 }
 
+// getClusterID derives the cluster id from the machine id of the controller, which is already protected, and
+// hashes it once more so it can't be correlated with the other ids k0s derives from the machine id
 func (c Component) getClusterID() (string, error) {
-	ns, err := c.kubernetesClient.CoreV1().Namespaces().Get(context.Background(),
-		"kube-system",
-		metav1.GetOptions{})
-
+	id, err := machineID()
 	if err != nil {
-		return "", fmt.Errorf("can't find kube-system namespace: %v", err)
+		return "", fmt.Errorf("can't get machine id: %v", err)
 	}
-
-	return fmt.Sprintf("kube-system:%s", ns.UID), nil
+	return protectedID("cluster:" + id), nil
 }
 
 func (c Component) getWorkerNodeCount() (int, error) {
@@ -82,7 +90,7 @@ func (c Component) getWorkerNodeCount() (int, error) {
 func (c Component) getControlPlaneNodeCount() (int, error) {
 	switch c.ClusterConfig.Spec.Storage.Type {
 	case config.EtcdStorageType:
-		cl, err := etcd.NewClient()
+		cl, err := etcd.NewClient(c.ClusterConfig.Spec.Storage.Etcd.ClientURL())
 		if err != nil {
 			return 0, fmt.Errorf("can't get etcd client: %v", err)
 		}
@@ -97,23 +105,63 @@ func (c Component) getControlPlaneNodeCount() (int, error) {
 	}
 }
 
-func (c Component) sendTelemetry() {
+// heartbeat collects the telemetry into the message sent to segment
+func (c Component) heartbeat() (analytics.Track, error) {
 	data, err := c.collectTelemetry()
 	if err != nil {
-		c.log.WithError(err).Warning("can't prepare telemetry data")
-		return
+		return analytics.Track{}, err
 	}
-	c.log.WithField("data", data).Info("sending telemetry")
-	if err := c.analyticsClient.Enqueue(analytics.Track{
-		AnonymousId: machineID(),
+	return analytics.Track{
+		AnonymousId: c.instanceID,
 		Event:       heartbeatEvent,
 		Properties:  data.asProperties(),
-	}); err != nil {
+	}, nil
+}
+
+func (c Component) sendTelemetry() {
+	track, err := c.heartbeat()
+	if err != nil {
+		c.log.WithError(err).Warning("can't prepare telemetry data")
+		return
+	}
+	c.log.WithField("data", track.Properties).Info("sending telemetry")
+	if err := c.analyticsClient.Enqueue(track); err != nil {
 		c.log.WithError(err).Warning("can't send telemetry data")
 	}
 }
 
-func machineID() string {
-	id, _ := util.MachineID()
-	return id
+// Preview collects the telemetry k0s would send for the cluster config, without sending it
+func Preview(clusterConfig *config.ClusterConfig, version string) (*PreviewMessage, error) {
+	c := Component{
+		ClusterConfig: clusterConfig,
+		Version:       version,
+		log:           logging.ForComponent("telemetry"),
+	}
+	id, err := instanceID(clusterConfig.Telemetry, clusterConfig.Spec.API.Address, constant.TelemetryIDPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't determine the telemetry instance id")
+	}
+	c.instanceID = id
+	c.kubernetesClient, err = kubeutil.Client(constant.AdminKubeconfigConfigPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "can't init kube client")
+	}
+
+	track, err := c.heartbeat()
+	if err != nil {
+		return nil, err
+	}
+	return &PreviewMessage{
+		AnonymousID: track.AnonymousId,
+		Event:       track.Event,
+		Properties:  track.Properties,
+	}, nil
+}
+
+// PreviewMessage is the part of the message sent to segment k0s fills in, segment adds the timestamps and
+// the version of its client
+type PreviewMessage struct {
+	AnonymousID string                 `json:"anonymousId"`
+	Event       string                 `json:"event"`
+	Properties  map[string]interface{} `json:"properties"`
 }
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/k0sproject/k0s/pkg/apis/v1beta1"
+)
+
+func TestHeartbeat(t *testing.T) {
+	defer func(orig func() (string, error)) { machineID = orig }(machineID)
+	machineID = func() (string, error) { return "4b1c7a4e", nil }
+
+	clusterConfig := config.DefaultClusterConfig()
+	clusterConfig.Spec.API.Address = "10.0.0.1"
+	clusterConfig.Spec.API.SANs = []string{"k8s.example.com"}
+	clusterConfig.Spec.Storage = &config.StorageSpec{Type: config.KineStorageType, Kine: config.DefaultKineConfig()}
+
+	c := Component{
+		ClusterConfig: clusterConfig,
+		Version:       "v0.9.0",
+		instanceID:    "instance",
+		log:           logrus.NewEntry(logrus.New()),
+		kubernetesClient: fake.NewSimpleClientset(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}},
+		),
+	}
+	track, err := c.heartbeat()
+	require.NoError(t, err)
+
+	assert.Equal(t, "instance", track.AnonymousId)
+	assert.Equal(t, heartbeatEvent, track.Event)
+	assert.Equal(t, protectedID("cluster:4b1c7a4e"), track.Properties["clusterID"])
+	assert.Equal(t, "v0.9.0", track.Properties["version"])
+	assert.Equal(t, 1, track.Properties["workerNodesCount"])
+
+	// only the known fields are sent, none identifying the cluster
+	var keys []string
+	for k, v := range track.Properties {
+		keys = append(keys, k)
+		for _, identifying := range []string{"4b1c7a4e", "10.0.0.1", "k8s.example.com"} {
+			assert.NotContains(t, fmt.Sprint(v), identifying)
+		}
+	}
+	assert.ElementsMatch(t, []string{"storageType", "clusterID", "workerNodesCount", "controlPlaneNodesCount", "version"}, keys)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ExpiryExtension is the name of the kubeconfig extension the expiry of a join token is stored in
+const ExpiryExtension = "k0s-token"
+
+// ExpiredError is returned for a join token used after its expiry
+type ExpiredError struct {
+	Expiry time.Time
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("join token expired at %s, create a new one with k0s token create", e.Expiry.Format(time.RFC3339))
+}
+
+type expiryExtension struct {
+	Expiry time.Time `json:"expiry"`
+}
+
+// Expiry returns the expiry stored in the decoded join token, zero time if the token never expires or was
+// created by a k0s version not storing it
+func Expiry(tokenBytes []byte) (time.Time, error) {
+	kubeconfig, err := clientcmd.Load(tokenBytes)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to parse token contents")
+	}
+	ext, ok := kubeconfig.Extensions[ExpiryExtension].(*runtime.Unknown)
+	if !ok {
+		return time.Time{}, nil
+	}
+	var expiry expiryExtension
+	if err := json.Unmarshal(ext.Raw, &expiry); err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to parse token expiry")
+	}
+	return expiry.Expiry, nil
+}
+
+// CheckExpiry returns an *ExpiredError if the decoded join token has expired by now
+func CheckExpiry(tokenBytes []byte, now time.Time) error {
+	expiry, err := Expiry(tokenBytes)
+	if err != nil {
+		return err
+	}
+	if !expiry.IsZero() && !now.Before(expiry) {
+		return &ExpiredError{Expiry: expiry}
+	}
+	return nil
+}
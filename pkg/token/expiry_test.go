@@ -0,0 +1,62 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testExpiryExtension = `extensions:
+- name: k0s-token
+  extension:
+    expiry: "2020-11-01T12:00:00Z"
+`
+
+func TestExpiry(t *testing.T) {
+	expiry, err := Expiry([]byte(testKubeconfig + testExpiryExtension))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC), expiry.UTC())
+
+	expiry, err = Expiry([]byte(testKubeconfig))
+	require.NoError(t, err)
+	assert.True(t, expiry.IsZero())
+}
+
+func TestCheckExpiry(t *testing.T) {
+	tokenBytes := []byte(testKubeconfig + testExpiryExtension)
+
+	assert.NoError(t, CheckExpiry(tokenBytes, time.Date(2020, 10, 31, 0, 0, 0, 0, time.UTC)))
+
+	err := CheckExpiry(tokenBytes, time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC))
+	require.Error(t, err)
+	assert.IsType(t, &ExpiredError{}, err)
+
+	assert.NoError(t, CheckExpiry([]byte(testKubeconfig), time.Now()))
+}
+
+func TestInspectExpiry(t *testing.T) {
+	encoded, err := JoinEncode(bytes.NewBufferString(testKubeconfig + testExpiryExtension))
+	require.NoError(t, err)
+
+	info, err := Inspect(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "2020-11-01T12:00:00Z", info.Expiry)
+}
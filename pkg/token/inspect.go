@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// RoleWorker is the role of the tokens joining workers
+	RoleWorker = "worker"
+	// RoleController is the role of the tokens joining controllers
+	RoleController = "controller"
+
+	workerUser     = "kubelet-bootstrap"
+	controllerUser = "controller-bootstrap"
+	redacted       = "<redacted>"
+)
+
+// JoinTokenInfo holds the non-secret contents of a join token
+type JoinTokenInfo struct {
+	Role          string   `yaml:"role"`
+	JoinEndpoints []string `yaml:"joinEndpoints"`
+	// ClusterID is the SHA256 fingerprint of the cluster CA certificate
+	ClusterID string `yaml:"clusterID"`
+	CASubject string `yaml:"caSubject,omitempty"`
+	TokenID   string `yaml:"tokenID"`
+	Token     string `yaml:"token"`
+	Expiry    string `yaml:"expiry"`
+}
+
+// Inspect decodes a join token and returns its non-secret contents. The
+// token secret is redacted. The expiry is left empty for tokens not storing
+// it, see Expiry.
+func Inspect(encodedToken string) (*JoinTokenInfo, error) {
+	tokenBytes, err := JoinDecode(encodedToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode token")
+	}
+
+	kubeconfig, err := clientcmd.Load(tokenBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse token contents")
+	}
+
+	info := &JoinTokenInfo{}
+	for _, cluster := range kubeconfig.Clusters {
+		info.JoinEndpoints = append(info.JoinEndpoints, cluster.Server)
+		if info.ClusterID == "" && len(cluster.CertificateAuthorityData) > 0 {
+			info.ClusterID, info.CASubject = caIdentity(cluster.CertificateAuthorityData)
+		}
+	}
+
+	for name, authInfo := range kubeconfig.AuthInfos {
+		info.Role = roleOfUser(name)
+		if info.Role == "" {
+			info.Role = fmt.Sprintf("unknown (user %s)", name)
+		}
+		if authInfo.Token != "" {
+			info.TokenID = strings.SplitN(authInfo.Token, ".", 2)[0]
+			info.Token = fmt.Sprintf("%s.%s", info.TokenID, redacted)
+		}
+	}
+
+	expiry, err := Expiry(tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !expiry.IsZero() {
+		info.Expiry = expiry.Format(time.RFC3339)
+	}
+
+	return info, nil
+}
+
+// Role returns the role of the join token with the given contents, empty if its user is not one k0s creates tokens for
+func Role(kubeconfig *clientcmdapi.Config) string {
+	for name := range kubeconfig.AuthInfos {
+		if role := roleOfUser(name); role != "" {
+			return role
+		}
+	}
+	return ""
+}
+
+func roleOfUser(name string) string {
+	switch name {
+	case workerUser:
+		return RoleWorker
+	case controllerUser:
+		return RoleController
+	}
+	return ""
+}
+
+func caIdentity(caData []byte) (string, string) {
+	block, _ := pem.Decode(caData)
+	if block == nil {
+		return "", ""
+	}
+	sum := sha256.Sum256(block.Bytes)
+	fingerprint := "sha256:" + hex.EncodeToString(sum[:])
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fingerprint, ""
+	}
+	return fingerprint, cert.Subject.String()
+}
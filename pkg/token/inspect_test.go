@@ -0,0 +1,78 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+clusters:
+- cluster:
+    server: https://10.0.0.1:6443
+    certificate-authority-data: Zm9v
+  name: k0s
+contexts:
+- context:
+    cluster: k0s
+    user: kubelet-bootstrap
+  name: k0s
+current-context: k0s
+kind: Config
+preferences: {}
+users:
+- name: kubelet-bootstrap
+  user:
+    token: abcdef.0123456789abcdef
+`
+
+func TestInspect(t *testing.T) {
+	encoded, err := JoinEncode(bytes.NewBufferString(testKubeconfig))
+	require.NoError(t, err)
+
+	info, err := Inspect(encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, "worker", info.Role)
+	assert.Equal(t, []string{"https://10.0.0.1:6443"}, info.JoinEndpoints)
+	assert.Equal(t, "abcdef", info.TokenID)
+	assert.NotContains(t, info.Token, "0123456789abcdef")
+}
+
+func TestInspectInvalidToken(t *testing.T) {
+	_, err := Inspect("not a token")
+	assert.Error(t, err)
+}
+
+func TestRole(t *testing.T) {
+	kubeconfig, err := clientcmd.Load([]byte(testKubeconfig))
+	require.NoError(t, err)
+	assert.Equal(t, RoleWorker, Role(kubeconfig))
+
+	kubeconfig.AuthInfos[controllerUser] = kubeconfig.AuthInfos[workerUser]
+	delete(kubeconfig.AuthInfos, workerUser)
+	assert.Equal(t, RoleController, Role(kubeconfig))
+
+	kubeconfig.AuthInfos["someone"] = kubeconfig.AuthInfos[controllerUser]
+	delete(kubeconfig.AuthInfos, controllerUser)
+	assert.Equal(t, "", Role(kubeconfig))
+}
@@ -18,16 +18,32 @@ package token
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	k8sutil "github.com/k0sproject/k0s/pkg/kubernetes"
 	"github.com/k0sproject/k0s/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 )
 
+// RevokedTokensConfigMap is the ConfigMap in kube-system recording the IDs of the revoked join tokens with the
+// time they would have expired, empty for the tokens which never expire. The entries are pruned once expired.
+const RevokedTokensConfigMap = "k0s-revoked-tokens"
+
+// Token describes a join token issued by k0s
+type Token struct {
+	ID   string
+	Role string
+	// Expiry is zero for a token which never expires
+	Expiry time.Time
+}
+
 // NewManager creates a new token manager using given kubeconfig
 func NewManager(kubeconfig string) (*Manager, error) {
 	logrus.Debugf("loading kubeconfig from: %s", kubeconfig)
@@ -45,8 +61,8 @@ type Manager struct {
 	client kubernetes.Interface
 }
 
-// Create creates a new bootstrap token
-func (m *Manager) Create(valid time.Duration, role string) (string, error) {
+// Create creates a new bootstrap token expiring at the given time, never if zero
+func (m *Manager) Create(expiry time.Time, role string) (string, error) {
 	tokenID := util.RandomString(6)
 	tokenSecret := util.RandomString(16)
 
@@ -55,8 +71,8 @@ func (m *Manager) Create(valid time.Duration, role string) (string, error) {
 	data := make(map[string]string)
 	data["token-id"] = tokenID
 	data["token-secret"] = tokenSecret
-	if valid != 0 {
-		data["expiration"] = time.Now().Add(valid).UTC().Format(time.RFC3339)
+	if !expiry.IsZero() {
+		data["expiration"] = expiry.UTC().Format(time.RFC3339)
 		logrus.Debugf("Set expiry to %s", data["expiration"])
 	}
 
@@ -87,3 +103,148 @@ func (m *Manager) Create(valid time.Duration, role string) (string, error) {
 
 	return token, nil
 }
+
+// Expiry returns the expiration time of the given bootstrap token, zero time if the token never expires
+func (m *Manager) Expiry(tokenID string) (time.Time, error) {
+	secret, err := m.client.CoreV1().Secrets("kube-system").Get(context.TODO(), fmt.Sprintf("bootstrap-token-%s", tokenID), metav1.GetOptions{})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expiration, ok := secret.Data["expiration"]
+	if !ok {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, string(expiration))
+}
+
+// List returns the join tokens which have neither expired nor been revoked, sorted by ID
+func (m *Manager) List() ([]Token, error) {
+	secrets, err := m.client.CoreV1().Secrets("kube-system").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("type=%s", v1.SecretTypeBootstrapToken),
+	})
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := m.revokedTokens()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var tokens []Token
+	for _, secret := range secrets.Items {
+		t := Token{
+			ID:   string(secret.Data["token-id"]),
+			Role: RoleWorker,
+		}
+		if _, ok := revoked[t.ID]; ok || t.ID == "" {
+			continue
+		}
+		if string(secret.Data["usage-controller-join"]) == "true" {
+			t.Role = RoleController
+		}
+		if expiration, ok := secret.Data["expiration"]; ok {
+			if t.Expiry, err = time.Parse(time.RFC3339, string(expiration)); err != nil {
+				return nil, errors.Wrapf(err, "invalid expiration of token %s", t.ID)
+			}
+			if !now.Before(t.Expiry) {
+				continue
+			}
+		}
+		tokens = append(tokens, t)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID < tokens[j].ID })
+	return tokens, nil
+}
+
+// Invalidate revokes the join token with the given ID. The ID is recorded in the RevokedTokensConfigMap, which
+// the join API consults, and the bootstrap token secret is deleted so the API server refuses the token too. IDs of
+// tokens which don't exist are refused, unless revoked already.
+func (m *Manager) Invalidate(tokenID string) error {
+	secrets := m.client.CoreV1().Secrets("kube-system")
+	secret, err := secrets.Get(context.TODO(), fmt.Sprintf("bootstrap-token-%s", tokenID), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		revoked, err := m.Revoked(tokenID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return nil
+		}
+		return fmt.Errorf("unknown token %s, k0s token list prints the tokens which can be used", tokenID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to look up token %s", tokenID)
+	}
+	// kept until the token would have expired anyway
+	expiry := string(secret.Data["expiration"])
+
+	configMaps := m.client.CoreV1().ConfigMaps("kube-system")
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(context.TODO(), RevokedTokensConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      RevokedTokensConfigMap,
+					Namespace: "kube-system",
+				},
+				Data: map[string]string{tokenID: expiry},
+			}
+			_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		pruneRevokedTokens(cm.Data, time.Now())
+		cm.Data[tokenID] = expiry
+		_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to record token %s as revoked", tokenID)
+	}
+
+	err = secrets.Delete(context.TODO(), fmt.Sprintf("bootstrap-token-%s", tokenID), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete token %s", tokenID)
+	}
+	return nil
+}
+
+// pruneRevokedTokens drops the revoked tokens which have expired by now, they are refused anyway
+func pruneRevokedTokens(revoked map[string]string, now time.Time) {
+	for id, expiry := range revoked {
+		if expiry == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, expiry); err == nil && !now.Before(t) {
+			delete(revoked, id)
+		}
+	}
+}
+
+// Revoked returns true if the join token with the given ID has been invalidated
+func (m *Manager) Revoked(tokenID string) (bool, error) {
+	revoked, err := m.revokedTokens()
+	if err != nil {
+		return false, err
+	}
+	_, ok := revoked[tokenID]
+	return ok, nil
+}
+
+func (m *Manager) revokedTokens() (map[string]string, error) {
+	cm, err := m.client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), RevokedTokensConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cm.Data, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// bootstrapTokenSecret returns the secret the API server stores a bootstrap token as, the fake client doesn't
+// convert the StringData Create sets
+func bootstrapTokenSecret(id string, data map[string]string) *v1.Secret {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bootstrap-token-" + id,
+			Namespace: "kube-system",
+		},
+		Type: v1.SecretTypeBootstrapToken,
+		Data: map[string][]byte{"token-id": []byte(id)},
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestManagerInvalidate(t *testing.T) {
+	workerID, controllerID, expiredID := "abcdef", "ghijkl", "mnopqr"
+	m := &Manager{client: fake.NewSimpleClientset(
+		bootstrapTokenSecret(workerID, nil),
+		bootstrapTokenSecret(controllerID, map[string]string{
+			"usage-controller-join": "true",
+			"expiration":            time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+		}),
+		bootstrapTokenSecret(expiredID, map[string]string{
+			"expiration": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		}),
+	)}
+
+	tokens, err := m.List()
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	require.NoError(t, m.Invalidate(workerID))
+	// invalidating twice is fine
+	require.NoError(t, m.Invalidate(workerID))
+
+	revoked, err := m.Revoked(workerID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = m.Revoked(controllerID)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = m.client.CoreV1().Secrets("kube-system").Get(context.TODO(), "bootstrap-token-"+workerID, metav1.GetOptions{})
+	assert.Error(t, err)
+
+	tokens, err = m.List()
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, controllerID, tokens[0].ID)
+	assert.Equal(t, RoleController, tokens[0].Role)
+	assert.False(t, tokens[0].Expiry.IsZero())
+
+	err = m.Invalidate("zzzzzz")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown token zzzzzz")
+	revoked, err = m.Revoked("zzzzzz")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestManagerInvalidatePrunesExpired(t *testing.T) {
+	expiredID, validID := "abcdef", "ghijkl"
+	m := &Manager{client: fake.NewSimpleClientset(
+		bootstrapTokenSecret(expiredID, map[string]string{"expiration": time.Now().Add(time.Second).UTC().Format(time.RFC3339)}),
+		bootstrapTokenSecret(validID, map[string]string{"expiration": time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}),
+	)}
+	require.NoError(t, m.Invalidate(expiredID))
+	cm, err := m.client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), RevokedTokensConfigMap, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, cm.Data, expiredID)
+
+	// past its expiry the token is refused anyway, its entry goes with the next revocation
+	cm.Data[expiredID] = time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+	_, err = m.client.CoreV1().ConfigMaps("kube-system").Update(context.TODO(), cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, m.Invalidate(validID))
+
+	cm, err = m.client.CoreV1().ConfigMaps("kube-system").Get(context.TODO(), RevokedTokensConfigMap, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, cm.Data, expiredID)
+	assert.Contains(t, cm.Data, validID)
+}
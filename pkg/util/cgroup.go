@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// unified cgroup hierarchy versions
+const (
+	CgroupV1 = "v1"
+	CgroupV2 = "v2"
+)
+
+// CgroupVersion detects the cgroup version of the host, the unified hierarchy exposes the available controllers at the root
+func CgroupVersion() string {
+	if FileExists("/sys/fs/cgroup/cgroup.controllers") {
+		return CgroupV2
+	}
+	return CgroupV1
+}
+
+var sliceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_.:]+(-[a-zA-Z0-9_.:]+)*\.slice$`)
+
+// CgroupParentPath validates the cgroup parent and returns its path within the cgroup hierarchy. The parent is
+// a systemd slice name, e.g. k0s.slice or tenants-k0s.slice where the dashes denote the nesting, or on cgroup v1
+// also a plain cgroupfs path such as /k0s. On cgroup v2 the hierarchy is managed by systemd, so only slices work.
+func CgroupParentPath(parent, cgroupVersion string) (string, error) {
+	if strings.HasPrefix(parent, "/") {
+		if cgroupVersion != CgroupV1 {
+			return "", fmt.Errorf("cgroup parent %q must be a systemd slice name, e.g. k0s.slice, on cgroup %s", parent, cgroupVersion)
+		}
+		if p := path.Clean(parent); p != parent || p == "/" {
+			return "", fmt.Errorf("cgroup parent %q must be a clean absolute path below the root cgroup", parent)
+		}
+		return parent, nil
+	}
+
+	if !sliceNameRegexp.MatchString(parent) || parent == "-.slice" {
+		return "", fmt.Errorf("invalid cgroup parent %q, must be a systemd slice name such as k0s.slice", parent)
+	}
+	// systemd nests the slice a-b.slice as /a.slice/a-b.slice
+	parts := strings.Split(strings.TrimSuffix(parent, ".slice"), "-")
+	p := ""
+	for i := range parts {
+		p += "/" + strings.Join(parts[:i+1], "-") + ".slice"
+	}
+	return p, nil
+}
+
+// SystemCgroupPath returns the path of the cgroup below the cgroup parent path the kubelet and containerd are placed
+// in. It is kept apart from the pods under the parent, so that the kubelet can reserve resources for it with
+// --kube-reserved-cgroup. Below a systemd slice it is the nested slice, e.g. /k0s.slice/k0s-system.slice, below a
+// cgroupfs path the system subgroup, e.g. /k0s/system.
+func SystemCgroupPath(parentPath string) string {
+	if base := path.Base(parentPath); strings.HasSuffix(base, ".slice") {
+		return path.Join(parentPath, strings.TrimSuffix(base, ".slice")+"-system.slice")
+	}
+	return path.Join(parentPath, "system")
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupParentPath(t *testing.T) {
+	tests := []struct {
+		parent  string
+		version string
+		want    string
+		valid   bool
+	}{
+		{parent: "k0s.slice", version: CgroupV2, want: "/k0s.slice", valid: true},
+		{parent: "tenants-k0s.slice", version: CgroupV2, want: "/tenants.slice/tenants-k0s.slice", valid: true},
+		{parent: "k0s.slice", version: CgroupV1, want: "/k0s.slice", valid: true},
+		{parent: "/k0s", version: CgroupV1, want: "/k0s", valid: true},
+		{parent: "/k0s", version: CgroupV2, valid: false},
+		{parent: "/", version: CgroupV1, valid: false},
+		{parent: "/k0s/../x", version: CgroupV1, valid: false},
+		{parent: "k0s", version: CgroupV2, valid: false},
+		{parent: "-k0s.slice", version: CgroupV2, valid: false},
+		{parent: "a--b.slice", version: CgroupV2, valid: false},
+		{parent: "k0s slice.slice", version: CgroupV2, valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.parent+" "+tt.version, func(t *testing.T) {
+			got, err := CgroupParentPath(tt.parent, tt.version)
+			if tt.valid {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestSystemCgroupPath(t *testing.T) {
+	assert.Equal(t, "/k0s.slice/k0s-system.slice", SystemCgroupPath("/k0s.slice"))
+	assert.Equal(t, "/tenants.slice/tenants-k0s.slice/tenants-k0s-system.slice", SystemCgroupPath("/tenants.slice/tenants-k0s.slice"))
+	assert.Equal(t, "/k0s/system", SystemCgroupPath("/k0s"))
+}
@@ -46,6 +46,16 @@ func GetAllDirs(base string) ([]string, error) {
 	return dirs, nil
 }
 
+// CheckWritable verifies files can be created in the given directory
+func CheckWritable(dir string) error {
+	f, err := ioutil.TempFile(dir, ".k0s-write-check")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable: %v", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
 // InitDirectory creates a path if it does not exist, and verifies its permissions, if it does
 func InitDirectory(path string, perm os.FileMode) error {
 	// if directory doesn't exist, this will create it
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	hostsBlockBegin = "# BEGIN k0s managed host aliases"
+	hostsBlockEnd   = "# END k0s managed host aliases"
+)
+
+// UpdateHostsFile replaces the k0s managed block of the hosts file with the given entries, the lines outside of it
+// are kept byte for byte. A new block is appended after a blank line, which is removed along with the block once there
+// are no entries anymore. The file is replaced atomically.
+func UpdateHostsFile(path string, entries []string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := string(data)
+
+	lines := strings.SplitAfter(content, "\n")
+	begin, end := -1, len(lines)-1
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if begin < 0 && trimmed == hostsBlockBegin {
+			begin = i
+		} else if begin >= 0 && trimmed == hostsBlockEnd {
+			end = i
+			break
+		}
+	}
+	if begin < 0 && len(entries) == 0 {
+		return nil
+	}
+
+	before, after := lines, []string(nil)
+	if begin >= 0 {
+		before, after = lines[:begin], lines[end+1:]
+		// the blank line separating the block belongs to it
+		if n := len(before); n > 0 && strings.TrimRight(before[n-1], "\r\n") == "" {
+			before = before[:n-1]
+		}
+	}
+
+	updated := strings.Join(before, "")
+	if len(entries) > 0 {
+		if updated != "" {
+			if !strings.HasSuffix(updated, "\n") {
+				updated += "\n"
+			}
+			updated += "\n"
+		}
+		updated += hostsBlockBegin + "\n" + strings.Join(entries, "\n") + "\n" + hostsBlockEnd + "\n"
+	}
+	updated += strings.Join(after, "")
+	if updated == content {
+		return nil
+	}
+	return replaceFile(path, []byte(updated), 0644)
+}
+
+// replaceFile atomically replaces the file with the given data by renaming a temporary file in the same directory
+// over it, keeping the mode and owner of the existing file. A hosts file bind mounted into a container can't be
+// renamed over, it's written in place instead.
+func replaceFile(path string, data []byte, mode os.FileMode) error {
+	uid, gid := -1, -1
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid, gid = int(stat.Uid), int(stat.Gid)
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".k0s-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if uid >= 0 {
+		if err := os.Chown(tmp.Name(), uid, gid); err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if linkErr, ok := err.(*os.LinkError); ok && linkErr.Err == syscall.EBUSY {
+		return ioutil.WriteFile(path, data, mode)
+	}
+	return err
+}
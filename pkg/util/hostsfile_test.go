@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateHostsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hosts")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	hostsFile := filepath.Join(dir, "hosts")
+	original := "127.0.0.1\tlocalhost\n::1\tlocalhost\n"
+	require.NoError(t, ioutil.WriteFile(hostsFile, []byte(original), 0644))
+
+	read := func() string {
+		data, err := ioutil.ReadFile(hostsFile)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	require.NoError(t, UpdateHostsFile(hostsFile, []string{"10.0.0.5\toidc.internal"}))
+	assert.Equal(t, original+"\n# BEGIN k0s managed host aliases\n10.0.0.5\toidc.internal\n# END k0s managed host aliases\n", read())
+
+	require.NoError(t, UpdateHostsFile(hostsFile, []string{"10.0.0.6\twebhook.internal"}))
+	assert.Equal(t, original+"\n# BEGIN k0s managed host aliases\n10.0.0.6\twebhook.internal\n# END k0s managed host aliases\n", read())
+
+	require.NoError(t, UpdateHostsFile(hostsFile, nil))
+	assert.Equal(t, original, read())
+
+	// the lines around the block are kept as they are, including the ones k0s doesn't understand
+	custom := "127.0.0.1 localhost   # loopback\r\n\n\n# BEGIN k0s managed host aliases\n10.0.0.5\toidc.internal\n# END k0s managed host aliases\n  garbage line\n10.1.0.1 db"
+	require.NoError(t, ioutil.WriteFile(hostsFile, []byte(custom), 0640))
+	require.NoError(t, os.Chmod(hostsFile, 0640))
+	require.NoError(t, UpdateHostsFile(hostsFile, []string{"10.0.0.6\twebhook.internal"}))
+	assert.Equal(t, "127.0.0.1 localhost   # loopback\r\n\n\n# BEGIN k0s managed host aliases\n10.0.0.6\twebhook.internal\n# END k0s managed host aliases\n  garbage line\n10.1.0.1 db", read())
+	require.NoError(t, UpdateHostsFile(hostsFile, nil))
+	assert.Equal(t, "127.0.0.1 localhost   # loopback\r\n\n  garbage line\n10.1.0.1 db", read())
+	info, err := os.Stat(hostsFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	require.NoError(t, UpdateHostsFile(filepath.Join(dir, "missing"), nil))
+	_, err = os.Stat(filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -16,7 +16,9 @@ limitations under the License.
 package util
 
 import (
+	"fmt"
 	"net"
+	"os"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -63,3 +65,40 @@ func FirstPublicAddress() (string, error) {
 
 	return "127.0.0.1", nil
 }
+
+// NodeSANs returns the names this node is reachable by: the first non-local address and the hostname
+func NodeSANs() ([]string, error) {
+	address, err := FirstPublicAddress()
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get hostname")
+	}
+	return []string{address, hostname}, nil
+}
+
+// IsLocalAddress checks whether the given IP address is assigned to one of the network interfaces
+// of the node. The unspecified addresses 0.0.0.0 and :: are considered local.
+func IsLocalAddress(address string) (bool, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false, fmt.Errorf("%q is not a valid IP address", address)
+	}
+	if ip.IsUnspecified() {
+		return true, nil
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list network interfaces")
+	}
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
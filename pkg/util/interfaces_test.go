@@ -0,0 +1,43 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLocalAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		local   bool
+		err     bool
+	}{
+		{"127.0.0.1", true, false},
+		{"0.0.0.0", true, false},
+		{"::", true, false},
+		{"192.0.2.1", false, false},
+		{"not-an-ip", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			local, err := IsLocalAddress(tt.address)
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.local, local)
+		})
+	}
+}
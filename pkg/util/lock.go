@@ -0,0 +1,146 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DataDirLock is an exclusive lock preventing several k0s processes from using the same data directory.
+// The lock file holds the pid of the process which acquired it.
+type DataDirLock struct {
+	path string
+	file *os.File
+}
+
+// LockedError is returned when the lock is held by another process
+type LockedError struct {
+	Path string
+	Pid  int
+	// Stale is set if the process which acquired the lock is not running anymore
+	Stale bool
+}
+
+func (e *LockedError) Error() string {
+	if e.Stale {
+		return fmt.Sprintf("%s is still held although the process %d which locked it is not running anymore, remove the stale lock with `k0s unlock`", e.Path, e.Pid)
+	}
+	return fmt.Sprintf("%s is locked by the running process %d, is k0s already running?", e.Path, e.Pid)
+}
+
+// LockDataDir acquires the lock at the given path
+func LockDataDir(path string) (*DataDirLock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			if err == syscall.EWOULDBLOCK {
+				pid := readLockPid(path)
+				return nil, &LockedError{Path: path, Pid: pid, Stale: !processAlive(pid)}
+			}
+			return nil, err
+		}
+		// the previous holder removes the file when unlocking, if it did so between the open and the flock the
+		// lock is held on a file which no longer excludes anyone, so start over with the current file
+		current, err := isLockFile(f, path)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if !current {
+			f.Close()
+			continue
+		}
+		if err := f.Truncate(0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &DataDirLock{path: path, file: f}, nil
+	}
+}
+
+// isLockFile checks whether the open file is still the one at path
+func isLockFile(f *os.File, path string) (bool, error) {
+	opened, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	current, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(opened, current), nil
+}
+
+// Unlock releases the lock and removes the lock file. A process which opened the file before it was removed
+// notices it in LockDataDir and locks a new file instead.
+func (l *DataDirLock) Unlock() error {
+	os.Remove(l.path)
+	return l.file.Close()
+}
+
+// ForceUnlock removes a stale lock file, e.g. one still held by a child of a crashed k0s process.
+// It refuses to do so if the process which acquired the lock is still running. Returns the pid of
+// that process, 0 if unknown.
+func ForceUnlock(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	pid := readLockPid(path)
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil && pid > 0 && processAlive(pid) {
+		return pid, &LockedError{Path: path, Pid: pid}
+	}
+	return pid, os.Remove(path)
+}
+
+func readLockPid(path string) int {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
+}
+
+// processAlive checks whether a process with the given pid exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataDirLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "k0s.lock")
+
+	lock, err := LockDataDir(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), readLockPid(path))
+
+	_, err = LockDataDir(path)
+	assert.IsType(t, &LockedError{}, err)
+	assert.False(t, err.(*LockedError).Stale)
+
+	// the lock is held by a live process
+	_, err = ForceUnlock(path)
+	assert.IsType(t, &LockedError{}, err)
+
+	require.NoError(t, lock.Unlock())
+	assert.False(t, FileExists(path))
+
+	lock, err = LockDataDir(path)
+	require.NoError(t, err)
+	require.NoError(t, lock.Unlock())
+}
+
+func TestForceUnlockStale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "k0s.lock")
+
+	// a lock file left behind by a crashed process, the pid is beyond the kernel's pid_max
+	require.NoError(t, ioutil.WriteFile(path, []byte(strconv.Itoa(1<<23)), 0600))
+	pid, err := ForceUnlock(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1<<23, pid)
+	assert.False(t, FileExists(path))
+}
+
+func TestDataDirLockRemovedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "k0s.lock")
+
+	lock, err := LockDataDir(path)
+	require.NoError(t, err)
+	// opened by another process just before the holder unlocks
+	opened, err := os.Open(path)
+	require.NoError(t, err)
+	defer opened.Close()
+	require.NoError(t, lock.Unlock())
+
+	current, err := isLockFile(opened, path)
+	require.NoError(t, err)
+	assert.False(t, current)
+
+	lock, err = LockDataDir(path)
+	require.NoError(t, err)
+	defer lock.Unlock()
+	current, err = isLockFile(opened, path)
+	require.NoError(t, err)
+	assert.False(t, current)
+	current, err = isLockFile(lock.file, path)
+	require.NoError(t, err)
+	assert.True(t, current)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// nodeIDLength is the length of the node id, in hex characters
+const nodeIDLength = 16
+
+// NodeID returns a short id for the node, stable across restarts, derived from the machine id and the given API address.
+// If the machine id can't be read, as in some container environments, a random id persisted in the data dir is used instead.
+func NodeID(address string) (string, error) {
+	return nodeID(address, MachineID, constant.NodeIDPath)
+}
+
+func nodeID(address string, machineID func() (string, error), idPath string) (string, error) {
+	id, err := machineID()
+	if err != nil {
+		logrus.WithError(err).Debugf("can't read the machine id, using the node id persisted in %s", idPath)
+		if id, err = PersistedRandomID(idPath, "node id"); err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256([]byte(id + "/" + address))
+	return hex.EncodeToString(sum[:])[:nodeIDLength], nil
+}
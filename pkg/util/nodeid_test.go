@@ -0,0 +1,68 @@
+/*
+Copyright 2020 Mirantis, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package util
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "k0s-node-id")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	idPath := filepath.Join(dir, "node-id")
+
+	machineID := func() (string, error) { return "machine", nil }
+	noMachineID := func() (string, error) { return "", fmt.Errorf("no machine id") }
+
+	t.Run("derived from the machine id and address", func(t *testing.T) {
+		id, err := nodeID("10.0.0.1", machineID, idPath)
+		require.NoError(t, err)
+		assert.Len(t, id, nodeIDLength)
+
+		again, err := nodeID("10.0.0.1", machineID, idPath)
+		require.NoError(t, err)
+		assert.Equal(t, id, again)
+
+		other, err := nodeID("10.0.0.2", machineID, idPath)
+		require.NoError(t, err)
+		assert.NotEqual(t, id, other)
+		assert.NoFileExists(t, idPath)
+	})
+
+	t.Run("falls back to a persisted id", func(t *testing.T) {
+		id, err := nodeID("10.0.0.1", noMachineID, idPath)
+		require.NoError(t, err)
+		assert.Len(t, id, nodeIDLength)
+		assert.FileExists(t, idPath)
+
+		again, err := nodeID("10.0.0.1", noMachineID, idPath)
+		require.NoError(t, err)
+		assert.Equal(t, id, again)
+	})
+
+	t.Run("unwritable fallback", func(t *testing.T) {
+		_, err := nodeID("10.0.0.1", noMachineID, filepath.Join(dir, "missing", "node-id"))
+		assert.Error(t, err)
+	})
+}
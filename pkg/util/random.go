@@ -15,7 +15,14 @@ limitations under the License.
 */
 package util
 
-import "crypto/rand"
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 var letters = "abcdefghijklmnopqrstuvwxyz0123456789"
 
@@ -33,3 +40,21 @@ func RandomString(length int) string {
 	}
 	return string(bytes)
 }
+
+// PersistedRandomID returns the random id persisted at path, generating and persisting one first if there is none,
+// so that it stays the same across restarts. The name describes the id in the errors.
+func PersistedRandomID(path string, name string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil && strings.TrimSpace(string(data)) != "" {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrapf(err, "failed to read the %s from %s", name, path)
+	}
+
+	id := RandomString(32)
+	if err := ioutil.WriteFile(path, []byte(id+"\n"), 0600); err != nil {
+		return "", errors.Wrapf(err, "failed to persist the %s to %s", name, path)
+	}
+	return id, nil
+}